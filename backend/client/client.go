@@ -0,0 +1,227 @@
+// Package client is a Go client for the AOC Event Tracker API. It sends and
+// returns the server's own models types, so callers never copy structs.
+//
+//	c, err := client.New("https://tracker.example.mil", client.WithAPIKey(os.Getenv("AOC_API_KEY")))
+//	exercises, err := c.ListExercises(ctx, client.ExerciseFilter{})
+//
+// GET, PUT and DELETE requests are retried on network errors and on 429,
+// 502, 503 and 504 responses; POST and PATCH requests are sent once. Failed
+// requests return an *Error, which can be tested with errors.Is against
+// ErrNotFound, ErrValidation and the other sentinels.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 250 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with an API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.token = key }
+}
+
+// WithSessionToken authenticates requests with a session token returned by
+// Login
+func WithSessionToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient sends requests through hc instead of a client with a 30
+// second timeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times an idempotent request is retried and the
+// delay before the first retry, which doubles on each attempt. Zero retries
+// turns retrying off.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = retries, backoff }
+}
+
+// WithUserAgent sets the User-Agent header, so the server's request logs
+// show which tool made a call
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client for the server at baseURL, such as
+// "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "aoc-event-tracker-go-client",
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// get decodes the JSON response to a GET of path into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// do sends a request with body encoded as JSON and decodes a JSON response
+// into out, or reads the raw response into a *[]byte. A nil body sends no
+// body and a nil out discards the response.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	attempts := 1
+	if method != http.MethodPost && method != http.MethodPatch {
+		attempts += c.retries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.delay(attempt, lastErr)); err != nil {
+				return lastErr
+			}
+		}
+
+		resp, err := c.send(ctx, method, u.String(), payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		err = readResponse(resp, out)
+		if apiErr, ok := err.(*Error); ok && apiErr.temporary() {
+			lastErr = err
+			continue
+		}
+		return err
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil && method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	} else if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.httpClient.Do(req)
+}
+
+// delay is how long to wait before a retry: the server's Retry-After when it
+// sent one, otherwise exponential backoff
+func (c *Client) delay(attempt int, lastErr error) time.Duration {
+	if apiErr, ok := lastErr.(*Error); ok && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	d := c.backoff << (attempt - 1)
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// readResponse closes resp, returning an *Error for a non-2xx status and
+// otherwise decoding a JSON body into out or reading it into a *[]byte
+func readResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("reading %s response: %w", resp.Request.URL.Path, err)
+		}
+		*raw = body
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", resp.Request.URL.Path, err)
+	}
+	return nil
+}
+
+// idPath formats a path with an ID appended, such as /api/tasks/7
+func idPath(prefix string, id int) string {
+	return prefix + "/" + strconv.Itoa(id)
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by errors.Is against an *Error's status code
+var (
+	ErrUnauthorized = errors.New("authentication required")
+	ErrForbidden    = errors.New("permission denied")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+)
+
+// FieldError is one invalid field reported with a 422 response
+type FieldError struct {
+	Field   string `json:"field"` // Named as in the JSON payload, e.g. divisions[0].name
+	Code    string `json:"code"`  // required, too_long, invalid_choice, date_order, not_found or invalid
+	Message string `json:"message"`
+}
+
+// Error is a non-2xx response from the server
+type Error struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Message    string       // Response body, or the summary of a validation failure
+	Fields     []FieldError // Invalid fields, for 422 responses
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// Is reports whether target is the sentinel error for e's status code
+func (e *Error) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusUnprocessableEntity:
+		return target == ErrValidation
+	}
+	return false
+}
+
+// temporary reports whether the request may succeed if sent again
+func (e *Error) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func newError(resp *http.Response) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Method:     resp.Request.Method,
+		Path:       resp.Request.URL.Path,
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var failure struct {
+			Message string       `json:"message"`
+			Fields  []FieldError `json:"fields"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Message != "" {
+			e.Message, e.Fields = failure.Message, failure.Fields
+			return e
+		}
+	}
+	e.Message = strings.TrimSpace(string(body))
+	return e
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"srd-calendar-project/backend/internal/validation"
+	"testing"
+)
+
+// TestValidationError checks that FieldError decodes what the server writes,
+// since it is kept apart from the server's type to leave the database driver
+// out of client builds
+func TestValidationError(t *testing.T) {
+	var errs validation.Errors
+	errs.Add("divisions[0].teams[1].status", validation.CodeOneOf, "must be one of green, yellow, red")
+	w := httptest.NewRecorder()
+	validation.Write(w, errs)
+
+	resp := w.Result()
+	resp.Request = httptest.NewRequest(http.MethodPut, "/api/exercises/3", nil)
+	err := newError(resp)
+
+	want := []FieldError{{Field: "divisions[0].teams[1].status", Code: "invalid_choice", Message: "must be one of green, yellow, red"}}
+	if !reflect.DeepEqual(err.Fields, want) || !errors.Is(err, ErrValidation) {
+		t.Errorf("newError() = %+v, want fields %+v", err, want)
+	}
+}
@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ExerciseFilter narrows ListExercises. Zero values match all.
+type ExerciseFilter struct {
+	DivisionID   int
+	TeamID       int
+	DivisionName string
+	TeamName     string
+}
+
+// ListExercises returns the exercises the caller can see, with their
+// divisions, teams and events
+func (c *Client) ListExercises(ctx context.Context, filter ExerciseFilter) ([]Exercise, error) {
+	query := url.Values{}
+	if filter.DivisionID != 0 {
+		query.Set("division_id", strconv.Itoa(filter.DivisionID))
+	}
+	if filter.TeamID != 0 {
+		query.Set("team_id", strconv.Itoa(filter.TeamID))
+	}
+	if filter.DivisionName != "" {
+		query.Set("division_name", filter.DivisionName)
+	}
+	if filter.TeamName != "" {
+		query.Set("team_name", filter.TeamName)
+	}
+
+	var exercises []Exercise
+	err := c.get(ctx, "/api/exercises", query, &exercises)
+	return exercises, err
+}
+
+// CreateExercise creates an exercise and returns it with its ID
+func (c *Client) CreateExercise(ctx context.Context, exercise Exercise) (Exercise, error) {
+	var created Exercise
+	err := c.do(ctx, http.MethodPost, "/api/exercises", nil, exercise, &created)
+	return created, err
+}
+
+// UpdateExercise replaces the exercise with exercise.ID
+func (c *Client) UpdateExercise(ctx context.Context, exercise Exercise) (Exercise, error) {
+	var updated Exercise
+	err := c.do(ctx, http.MethodPut, idPath("/api/exercises", exercise.ID), nil, exercise, &updated)
+	return updated, err
+}
+
+// DeleteExercise deletes an exercise with its divisions, teams, events and
+// tasks
+func (c *Client) DeleteExercise(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/exercises", id), nil, nil, nil)
+}
+
+// ListDivisions returns an exercise's divisions with their teams
+func (c *Client) ListDivisions(ctx context.Context, exerciseID int) ([]Division, error) {
+	var divisions []Division
+	err := c.get(ctx, "/api/divisions", exerciseQuery(exerciseID), &divisions)
+	return divisions, err
+}
+
+// CreateDivision creates a division in division.ExerciseID
+func (c *Client) CreateDivision(ctx context.Context, division Division) (Division, error) {
+	var created Division
+	err := c.do(ctx, http.MethodPost, "/api/divisions", nil, division, &created)
+	return created, err
+}
+
+// UpdateDivision updates the name and learning objectives of the division
+// with division.ID
+func (c *Client) UpdateDivision(ctx context.Context, division Division) (Division, error) {
+	var updated Division
+	err := c.do(ctx, http.MethodPut, "/api/divisions/update", nil, division, &updated)
+	return updated, err
+}
+
+// DeleteDivision deletes a division and its teams
+func (c *Client) DeleteDivision(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/divisions", id), nil, nil, nil)
+}
+
+// CreateTeam creates a team in team.DivisionID
+func (c *Client) CreateTeam(ctx context.Context, team Team) (Team, error) {
+	var created Team
+	err := c.do(ctx, http.MethodPost, "/api/teams", nil, team, &created)
+	return created, err
+}
+
+// GetTeam returns a team
+func (c *Client) GetTeam(ctx context.Context, id int) (Team, error) {
+	var team Team
+	err := c.get(ctx, idPath("/api/teams", id), nil, &team)
+	return team, err
+}
+
+// ListTeams returns a division's teams
+func (c *Client) ListTeams(ctx context.Context, divisionID int) ([]Team, error) {
+	var teams []Team
+	err := c.get(ctx, idPath("/api/divisions", divisionID)+"/teams", nil, &teams)
+	return teams, err
+}
+
+// GetExerciseReadiness rolls up an exercise's team statuses in effect on day;
+// the zero time means today
+func (c *Client) GetExerciseReadiness(ctx context.Context, exerciseID int, day time.Time) (ExerciseReadiness, error) {
+	var rollup ExerciseReadiness
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/readiness", dayQuery(day), &rollup)
+	return rollup, err
+}
+
+// GetDivisionReadiness rolls up a division's team statuses in effect on day;
+// the zero time means today
+func (c *Client) GetDivisionReadiness(ctx context.Context, divisionID int, day time.Time) (DivisionReadiness, error) {
+	var rollup DivisionReadiness
+	err := c.get(ctx, idPath("/api/divisions", divisionID)+"/readiness", dayQuery(day), &rollup)
+	return rollup, err
+}
+
+// GetExerciseAnalytics reports how an exercise's statuses held up from the
+// first through the last day; zero times mean the exercise's own days
+func (c *Client) GetExerciseAnalytics(ctx context.Context, exerciseID int, from, to time.Time) (ExerciseAnalytics, error) {
+	var report ExerciseAnalytics
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/analytics", rangeQuery(from, to), &report)
+	return report, err
+}
+
+// GetAnalyticsTrend summarizes each exercise that has started, in start
+// order. Non-zero from and to keep the exercises running on those days.
+func (c *Client) GetAnalyticsTrend(ctx context.Context, from, to time.Time) ([]ExerciseTrend, error) {
+	var trend []ExerciseTrend
+	err := c.get(ctx, "/api/analytics/trend", rangeQuery(from, to), &trend)
+	return trend, err
+}
+
+// GetSitrep returns an exercise's situation report on day; the zero time
+// means today
+func (c *Client) GetSitrep(ctx context.Context, exerciseID int, day time.Time) (Sitrep, error) {
+	var report Sitrep
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/sitrep", dayQuery(day), &report)
+	return report, err
+}
+
+// RenderSitrep returns an exercise's situation report on day rendered with
+// its template; format is markdown or html
+func (c *Client) RenderSitrep(ctx context.Context, exerciseID int, day time.Time, format string) ([]byte, error) {
+	query := dayQuery(day)
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("format", format)
+	var out []byte
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/sitrep", query, &out)
+	return out, err
+}
+
+// GetSitrepTemplate returns the templates an exercise's situation reports
+// are rendered with
+func (c *Client) GetSitrepTemplate(ctx context.Context, exerciseID int) (SitrepTemplate, error) {
+	var tmpl SitrepTemplate
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/sitrep-template", nil, &tmpl)
+	return tmpl, err
+}
+
+// SetSitrepTemplate replaces an exercise's situation report templates; an
+// empty template goes back to the built-in one
+func (c *Client) SetSitrepTemplate(ctx context.Context, tmpl SitrepTemplate) (SitrepTemplate, error) {
+	var saved SitrepTemplate
+	err := c.do(ctx, http.MethodPut, idPath("/api/exercises", tmpl.ExerciseID)+"/sitrep-template", nil, tmpl, &saved)
+	return saved, err
+}
+
+// StatusBoardFilter narrows the status board. Empty fields match all.
+type StatusBoardFilter struct {
+	Divisions []string // Division names
+	Statuses  []string // green, yellow or red
+}
+
+// GetStatusBoard returns the teams of every exercise running today
+func (c *Client) GetStatusBoard(ctx context.Context, filter StatusBoardFilter) (StatusBoard, error) {
+	query := url.Values{}
+	for _, division := range filter.Divisions {
+		query.Add("division", division)
+	}
+	for _, status := range filter.Statuses {
+		query.Add("status", status)
+	}
+	var board StatusBoard
+	err := c.get(ctx, "/api/status-board", query, &board)
+	return board, err
+}
+
+// GetStaleStatuses lists the teams of an exercise whose status has gone
+// unconfirmed past the server's stale interval
+func (c *Client) GetStaleStatuses(ctx context.Context, exerciseID int) (StaleStatusReport, error) {
+	var report StaleStatusReport
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/stale-statuses", nil, &report)
+	return report, err
+}
+
+// GetEscalationPolicy returns an exercise's escalation steps
+func (c *Client) GetEscalationPolicy(ctx context.Context, exerciseID int) (EscalationPolicy, error) {
+	var policy EscalationPolicy
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/escalation-policy", nil, &policy)
+	return policy, err
+}
+
+// SetEscalationPolicy replaces an exercise's escalation steps; no steps turns
+// escalation off
+func (c *Client) SetEscalationPolicy(ctx context.Context, exerciseID int, steps []EscalationStep) (EscalationPolicy, error) {
+	var policy EscalationPolicy
+	body := EscalationPolicy{ExerciseID: exerciseID, Steps: steps}
+	err := c.do(ctx, http.MethodPut, idPath("/api/exercises", exerciseID)+"/escalation-policy", nil, body, &policy)
+	return policy, err
+}
+
+// ListEscalations returns an exercise's escalation history, newest first.
+// openOnly leaves out acknowledged escalations.
+func (c *Client) ListEscalations(ctx context.Context, exerciseID int, openOnly bool) ([]Escalation, error) {
+	var query url.Values
+	if openOnly {
+		query = url.Values{"open": {"true"}}
+	}
+	var escalations []Escalation
+	err := c.get(ctx, idPath("/api/exercises", exerciseID)+"/escalations", query, &escalations)
+	return escalations, err
+}
+
+// AcknowledgeEscalation acknowledges an escalation, stopping the remaining
+// steps until the team's status changes
+func (c *Client) AcknowledgeEscalation(ctx context.Context, id int, note string) (Escalation, error) {
+	var escalation Escalation
+	body := map[string]string{"note": note}
+	err := c.do(ctx, http.MethodPost, idPath("/api/escalations", id)+"/acknowledge", nil, body, &escalation)
+	return escalation, err
+}
+
+func dayQuery(day time.Time) url.Values {
+	if day.IsZero() {
+		return nil
+	}
+	return url.Values{"date": {day.Format("2006-01-02")}}
+}
+
+func rangeQuery(from, to time.Time) url.Values {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format("2006-01-02"))
+	}
+	return query
+}
+
+// UpdateTeam replaces the name, POC, status window and comments of the team
+// with team.ID
+func (c *Client) UpdateTeam(ctx context.Context, team Team) (Team, error) {
+	var updated Team
+	err := c.do(ctx, http.MethodPut, idPath("/api/teams", team.ID), nil, team, &updated)
+	return updated, err
+}
+
+// ReorderDivisions sets the order of an exercise's divisions. ids must list
+// every division of the exercise.
+func (c *Client) ReorderDivisions(ctx context.Context, exerciseID int, ids []int) ([]Division, error) {
+	var divisions []Division
+	err := c.do(ctx, http.MethodPut, idPath("/api/exercises", exerciseID)+"/divisions/order", nil,
+		map[string][]int{"division_ids": ids}, &divisions)
+	return divisions, err
+}
+
+// ReorderTeams sets the order of a division's teams. ids must list every
+// team of the division.
+func (c *Client) ReorderTeams(ctx context.Context, divisionID int, ids []int) ([]Team, error) {
+	var teams []Team
+	err := c.do(ctx, http.MethodPut, idPath("/api/divisions", divisionID)+"/teams/order", nil,
+		map[string][]int{"team_ids": ids}, &teams)
+	return teams, err
+}
+
+// MoveTeam moves a team to another division of its exercise, at position
+// (from 1) among that division's teams, or last when position is 0
+func (c *Client) MoveTeam(ctx context.Context, teamID, divisionID, position int) (Team, error) {
+	var moved Team
+	body := map[string]int{"division_id": divisionID, "position": position}
+	err := c.do(ctx, http.MethodPost, idPath("/api/teams", teamID)+"/move", nil, body, &moved)
+	return moved, err
+}
+
+// ConfirmTeamStatus records that a team's status is still accurate without
+// changing it
+func (c *Client) ConfirmTeamStatus(ctx context.Context, teamID int) (Team, error) {
+	var team Team
+	err := c.do(ctx, http.MethodPost, idPath("/api/teams", teamID)+"/confirm", nil, nil, &team)
+	return team, err
+}
+
+// TeamStatusUpdate sets one status on several teams. Give exactly one of
+// TeamIDs, DivisionID and ExerciseID. Nil window and comment fields keep
+// each team's values; a pointer to the zero time or an empty comment clears
+// them.
+type TeamStatusUpdate struct {
+	TeamIDs     []int      `json:"team_ids,omitempty"`
+	DivisionID  int        `json:"division_id,omitempty"`
+	ExerciseID  int        `json:"exercise_id,omitempty"`
+	Status      string     `json:"status"`
+	StatusStart *time.Time `json:"status_start,omitempty"`
+	StatusEnd   *time.Time `json:"status_end,omitempty"`
+	Comments    *string    `json:"comments,omitempty"`
+}
+
+// SetTeamStatuses applies update to every selected team in one transaction
+// and reports what changed for each
+func (c *Client) SetTeamStatuses(ctx context.Context, update TeamStatusUpdate) ([]TeamStatusResult, error) {
+	var results []TeamStatusResult
+	err := c.do(ctx, http.MethodPost, "/api/teams/status", nil, update, &results)
+	return results, err
+}
+
+// DeleteTeam deletes a team
+func (c *Client) DeleteTeam(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/teams", id), nil, nil, nil)
+}
+
+// ListEvents returns an exercise's events
+func (c *Client) ListEvents(ctx context.Context, exerciseID int) ([]Event, error) {
+	var events []Event
+	err := c.get(ctx, "/api/events", exerciseQuery(exerciseID), &events)
+	return events, err
+}
+
+// CreateEvent creates an event in event.ExerciseID
+func (c *Client) CreateEvent(ctx context.Context, event Event) (Event, error) {
+	var created Event
+	err := c.do(ctx, http.MethodPost, "/api/events", nil, event, &created)
+	return created, err
+}
+
+// UpdateEvent replaces the event with event.ID
+func (c *Client) UpdateEvent(ctx context.Context, event Event) error {
+	return c.do(ctx, http.MethodPut, idPath("/api/events", event.ID), nil, event, nil)
+}
+
+// DeleteEvent deletes an event
+func (c *Client) DeleteEvent(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/events", id), nil, nil, nil)
+}
+
+// Chat sends the chatbot a message and returns its reply
+func (c *Client) Chat(ctx context.Context, message string) (string, error) {
+	var reply struct {
+		Reply string `json:"reply"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/chatbot", nil, map[string]string{"message": message}, &reply)
+	return reply.Reply, err
+}
+
+func exerciseQuery(exerciseID int) url.Values {
+	return url.Values{"exercise_id": {strconv.Itoa(exerciseID)}}
+}
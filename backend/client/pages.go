@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"net/url"
+	"strconv"
+)
+
+const pageSize = 100
+
+// AuditFilter narrows AuditLog. Zero values match all.
+type AuditFilter struct {
+	ExerciseID int
+	ActorID    int
+	Action     string // Change type, e.g. "team.status_changed"
+}
+
+// AuditLog iterates over audit entries, newest first, fetching a page at a
+// time as the loop advances. Iteration stops after the first error.
+//
+//	for entry, err := range c.AuditLog(ctx, client.AuditFilter{ExerciseID: 3}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) AuditLog(ctx context.Context, filter AuditFilter) iter.Seq2[AuditEntry, error] {
+	query := url.Values{}
+	if filter.ExerciseID != 0 {
+		query.Set("exercise_id", strconv.Itoa(filter.ExerciseID))
+	}
+	if filter.ActorID != 0 {
+		query.Set("actor_id", strconv.Itoa(filter.ActorID))
+	}
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+	return pages(ctx, c, "/api/audit", query, func(e AuditEntry) int { return e.ID })
+}
+
+// WebhookDeliveries iterates over a subscription's deliveries, newest first,
+// optionally only those with status "pending", "delivered" or "failed"
+func (c *Client) WebhookDeliveries(ctx context.Context, subscriptionID int, status string) iter.Seq2[WebhookDelivery, error] {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	path := idPath("/api/webhooks", subscriptionID) + "/deliveries"
+	return pages(ctx, c, path, query, func(d WebhookDelivery) int { return d.ID })
+}
+
+// pages iterates over a list endpoint that pages with limit and before_id
+func pages[T any](ctx context.Context, c *Client, path string, query url.Values, id func(T) int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		query := maps.Clone(query)
+		query.Set("limit", strconv.Itoa(pageSize))
+		for {
+			var page []T
+			if err := c.get(ctx, path, query, &page); err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(page) < pageSize {
+				return
+			}
+			query.Set("before_id", strconv.Itoa(id(page[len(page)-1])))
+		}
+	}
+}
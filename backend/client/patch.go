@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Patch is a JSON Merge Patch: the fields to change, keyed by their JSON
+// names. A nil value clears the field.
+//
+//	c.PatchTeam(ctx, 12, client.Patch{"status": "red", "comments": "Comms outage"})
+type Patch map[string]interface{}
+
+// PatchExercise changes only the given fields of an exercise
+func (c *Client) PatchExercise(ctx context.Context, id int, patch Patch) (Exercise, error) {
+	var updated Exercise
+	err := c.do(ctx, http.MethodPatch, idPath("/api/exercises", id), nil, patch, &updated)
+	return updated, err
+}
+
+// PatchDivision changes only the given fields of a division
+func (c *Client) PatchDivision(ctx context.Context, id int, patch Patch) (Division, error) {
+	var updated Division
+	err := c.do(ctx, http.MethodPatch, idPath("/api/divisions", id), nil, patch, &updated)
+	return updated, err
+}
+
+// PatchTeam changes only the given fields of a team
+func (c *Client) PatchTeam(ctx context.Context, id int, patch Patch) (Team, error) {
+	var updated Team
+	err := c.do(ctx, http.MethodPatch, idPath("/api/teams", id), nil, patch, &updated)
+	return updated, err
+}
+
+// PatchEvent changes only the given fields of an event
+func (c *Client) PatchEvent(ctx context.Context, id int, patch Patch) (Event, error) {
+	var updated Event
+	err := c.do(ctx, http.MethodPatch, idPath("/api/events", id), nil, patch, &updated)
+	return updated, err
+}
+
+// PatchTask changes only the given fields of a task
+func (c *Client) PatchTask(ctx context.Context, id int, patch Patch) (Task, error) {
+	var updated Task
+	err := c.do(ctx, http.MethodPatch, idPath("/api/tasks", id), nil, patch, &updated)
+	return updated, err
+}
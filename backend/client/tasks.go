@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListTasks returns an exercise's tasks with their teams
+func (c *Client) ListTasks(ctx context.Context, exerciseID int) ([]Task, error) {
+	var tasks []Task
+	err := c.get(ctx, "/api/tasks", exerciseQuery(exerciseID), &tasks)
+	return tasks, err
+}
+
+// CreateTask creates a task in task.ExerciseID
+func (c *Client) CreateTask(ctx context.Context, task Task) (Task, error) {
+	var created Task
+	err := c.do(ctx, http.MethodPost, "/api/tasks", nil, task, &created)
+	return created, err
+}
+
+// UpdateTask replaces the task with task.ID
+func (c *Client) UpdateTask(ctx context.Context, task Task) (Task, error) {
+	var updated Task
+	err := c.do(ctx, http.MethodPut, idPath("/api/tasks", task.ID), nil, task, &updated)
+	return updated, err
+}
+
+// AssignTask assigns a task to one team, or unassigns it when teamID is nil
+func (c *Client) AssignTask(ctx context.Context, taskID int, teamID *int) error {
+	body := map[string]*int{"team_id": teamID}
+	return c.do(ctx, http.MethodPut, idPath("/api/tasks", taskID)+"/assign", nil, body, nil)
+}
+
+// SetTaskTeams replaces the teams a task is assigned to and returns them
+func (c *Client) SetTaskTeams(ctx context.Context, taskID int, teamIDs []int) ([]Team, error) {
+	if teamIDs == nil {
+		teamIDs = []int{}
+	}
+	var result struct {
+		Teams []Team `json:"teams"`
+	}
+	body := map[string][]int{"team_ids": teamIDs}
+	err := c.do(ctx, http.MethodPut, idPath("/api/tasks", taskID)+"/assign-multiple", nil, body, &result)
+	return result.Teams, err
+}
+
+// DeleteTask deletes a task
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, idPath("/api/tasks", id), nil, nil, nil)
+}
@@ -0,0 +1,42 @@
+package client
+
+import "srd-calendar-project/backend/internal/models"
+
+// The API's types, re-exported so programs outside this module can name them
+type (
+	Exercise            = models.Exercise
+	Division            = models.Division
+	Team                = models.Team
+	TeamStatusResult    = models.TeamStatusResult
+	Readiness           = models.Readiness
+	ExerciseReadiness   = models.ExerciseReadiness
+	DivisionReadiness   = models.DivisionReadiness
+	TeamReadiness       = models.TeamReadiness
+	StatusBoard         = models.StatusBoard
+	BoardExercise       = models.BoardExercise
+	BoardDivision       = models.BoardDivision
+	BoardTeam           = models.BoardTeam
+	StaleStatusReport   = models.StaleStatusReport
+	StaleTeam           = models.StaleTeam
+	EscalationPolicy    = models.EscalationPolicy
+	EscalationStep      = models.EscalationStep
+	Escalation          = models.Escalation
+	StatusTime          = models.StatusTime
+	Recovery            = models.Recovery
+	ExerciseAnalytics   = models.ExerciseAnalytics
+	DivisionAnalytics   = models.DivisionAnalytics
+	TeamAnalytics       = models.TeamAnalytics
+	DailyReadiness      = models.DailyReadiness
+	ExerciseTrend       = models.ExerciseTrend
+	Sitrep              = models.Sitrep
+	SitrepDivision      = models.SitrepDivision
+	SitrepTeam          = models.SitrepTeam
+	SitrepStatusChange  = models.SitrepStatusChange
+	SitrepTemplate      = models.SitrepTemplate
+	Event               = models.Event
+	Task                = models.Task
+	User                = models.User
+	AuditEntry          = models.AuditEntry
+	WebhookSubscription = models.WebhookSubscription
+	WebhookDelivery     = models.WebhookDelivery
+)
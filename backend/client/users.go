@@ -0,0 +1,11 @@
+package client
+
+import "context"
+
+// Me returns the user the client is authenticated as. For a service API key
+// this is the key's service account.
+func (c *Client) Me(ctx context.Context) (User, error) {
+	var user User
+	err := c.get(ctx, "/api/auth/me", nil, &user)
+	return user, err
+}
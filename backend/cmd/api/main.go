@@ -1,66 +1,149 @@
 package main
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"srd-calendar-project/backend/internal/audit"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/config"
 	"srd-calendar-project/backend/internal/database"
-	"srd-calendar-project/backend/internal/handlers"
+	"srd-calendar-project/backend/internal/escalation"
+	"srd-calendar-project/backend/internal/logging"
+	"srd-calendar-project/backend/internal/oidc"
+	"srd-calendar-project/backend/internal/readiness"
 	"srd-calendar-project/backend/internal/repository"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"srd-calendar-project/backend/internal/scheduler"
+	"srd-calendar-project/backend/internal/sitrep"
+	"srd-calendar-project/backend/internal/statuscheck"
+	"srd-calendar-project/backend/internal/stream"
+	"srd-calendar-project/backend/internal/webhooks"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
+	cfg, printConfig, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if printConfig {
+		out, _ := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	logging.Setup(cfg.Logging)
+	readiness.Configure(cfg.Readiness)
+	statuscheck.Configure(cfg.Status)
+	sitrep.Configure(cfg.Sitrep)
+
+	// Stop on SIGINT or SIGTERM: finish in-flight requests, then background work
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers run until workerCtx is cancelled, which happens only
+	// after the HTTP server has drained
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
 	// Initialize database connection
-	err := database.InitDB()
+	err = database.InitDB(ctx, cfg.Database)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
 	}
 	defer database.CloseDB()
 
+	// Record every change in the audit log and live stream, and queue webhook deliveries
+	changes.RegisterSink(audit.Record)
+	if cfg.Features.LiveStream {
+		changes.RegisterSink(stream.Record)
+		stream.Start(workerCtx, &workers)
+	}
+	if cfg.Features.Webhooks {
+		changes.RegisterSink(webhooks.Enqueue)
+		webhooks.StartWorker(workerCtx, &workers)
+	}
+
 	// Initialize repository with database
-	repository.Initialize()
-
-	r := chi.NewRouter()
-
-	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-
-	// Public routes
-	r.Get("/api/exercises", handlers.GetExercises)
-	r.Post("/api/exercises", handlers.CreateExerciseHandler)
-	r.Put("/api/exercises/{id}", handlers.UpdateExerciseHandler)
-	r.Delete("/api/exercises/{id}", handlers.DeleteExerciseHandler)
-
-	r.Get("/api/divisions", handlers.GetDivisionsForExercise)
-	r.Post("/api/divisions", handlers.CreateDivision)
-	r.Put("/api/divisions/update", handlers.UpdateDivision)
-	r.Delete("/api/divisions/{id}", handlers.DeleteDivision)
-	r.Post("/api/teams", handlers.CreateTeam)
-	r.Put("/api/team/update", handlers.UpdateTeam)
-	r.Delete("/api/teams/{id}", handlers.DeleteTeam)
-
-	// Event endpoints
-	r.Get("/api/events", handlers.GetEvents)
-	r.Post("/api/events", handlers.CreateEvent)
-	r.Put("/api/events/{id}", handlers.UpdateEvent)
-	r.Delete("/api/events/{id}", handlers.DeleteEvent)
-
-	// Task endpoints
-	r.Get("/api/tasks", handlers.GetTasks)
-	r.Post("/api/tasks", handlers.CreateTask)
-	r.Put("/api/tasks/{id}", handlers.UpdateTask)
-	r.Put("/api/tasks/{id}/assign", handlers.AssignTaskToTeam)
-	r.Put("/api/tasks/{id}/assign-multiple", handlers.AssignTaskToMultipleTeams)
-	r.Delete("/api/tasks/{id}", handlers.DeleteTask)
-
-	// Chatbot endpoint
-	r.Post("/api/chatbot", handlers.EnhancedChatbotHandler)
-
-	log.Println("Starting server on :8081")
-	if err := http.ListenAndServe(":8081", r); err != nil {
-		log.Fatalf("could not start server: %s\n", err)
+	repository.Initialize(ctx, cfg.Database.Seed)
+
+	// Create the first administrator account on a fresh database
+	auth.EnsureBootstrapUser(ctx, cfg.Auth.AdminUsername, cfg.Auth.AdminPassword)
+	authz.EnsureAdmin(ctx)
+	auth.StartSessionPruner(workerCtx, &workers)
+
+	// Periodic jobs: expire status windows, flag stale statuses and escalate
+	// long-held red and yellow statuses
+	scheduler.Add("status_check", time.Duration(cfg.Status.CheckInterval), statuscheck.Run)
+	scheduler.Add("escalation", time.Duration(cfg.Escalation.CheckInterval), escalation.Run)
+	scheduler.Start(workerCtx, &workers)
+
+	// Single sign-on is optional; a bad configuration is fatal rather than silently disabling it
+	if err := oidc.LoadConfig(cfg.Auth.OIDC); err != nil {
+		fatal("Invalid single sign-on configuration", err)
+	}
+
+	r := newRouter(cfg)
+
+	srv := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+	// Open change streams never go idle, so end them when shutdown begins
+	srv.RegisterOnShutdown(stream.Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if cfg.Server.TLSCertFile != "" {
+			slog.Info("Starting server", "addr", cfg.Server.Addr, "tls", true)
+			serverErr <- srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			slog.Info("Starting server", "addr", cfg.Server.Addr, "tls", false)
+			serverErr <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("Could not start server", err)
+	case <-ctx.Done():
 	}
+	stop()
+
+	slog.Info("Shutting down: draining requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error draining requests", "error", err)
+	}
+
+	slog.Info("Shutting down: stopping background workers")
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("Shutdown complete")
+	case <-shutdownCtx.Done():
+		slog.Warn("Shutdown timed out waiting for background workers")
+	}
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
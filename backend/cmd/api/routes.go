@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/cors"
+	"srd-calendar-project/backend/internal/handlers"
+	"srd-calendar-project/backend/internal/logging"
+	"srd-calendar-project/backend/internal/metrics"
+	"srd-calendar-project/backend/internal/openapi"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// newRouter registers every route and its middleware. Routes for features
+// turned off in cfg are left out.
+func newRouter(cfg config.Config) chi.Router {
+	r := chi.NewRouter()
+
+	// Middleware
+	r.Use(middleware.RequestID)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(middleware.Recoverer)
+	r.Use(cors.Middleware(cfg.CORS))
+	r.Use(auth.Middleware)
+	r.Use(authz.Middleware)
+
+	// Cancel the database work of a request that runs too long; the live
+	// stream is long-lived and is exempt
+	queryTimeout := func(next http.Handler) http.Handler { return next }
+	if cfg.Database.QueryTimeout > 0 {
+		queryTimeout = middleware.Timeout(time.Duration(cfg.Database.QueryTimeout))
+	}
+
+	// Probes and metrics for the deployment platform
+	r.Get("/healthz", handlers.Healthz)
+	r.Get("/readyz", handlers.Readyz)
+	r.Get("/metrics", metrics.Handler)
+
+	// API description
+	r.Get("/api/openapi.json", openapi.Handler)
+	r.Get("/api/docs", openapi.Docs)
+
+	// Authentication routes
+	r.With(queryTimeout).Get("/api/auth/config", handlers.GetAuthConfig)
+	r.With(queryTimeout).Post("/api/auth/login", handlers.Login)
+	r.With(queryTimeout).Post("/api/auth/oidc/login", handlers.StartOIDCLogin)
+	r.With(queryTimeout).Get("/api/auth/oidc/callback", handlers.OIDCCallback)
+
+	// Live change stream (Server-Sent Events)
+	if cfg.Features.LiveStream {
+		r.With(auth.RequireUser).Get("/api/stream", handlers.StreamChanges)
+	}
+
+	// Routes below require a signed-in user
+	r.With(queryTimeout).Group(func(r chi.Router) {
+		r.Use(auth.RequireUser)
+
+		r.Post("/api/auth/logout", handlers.Logout)
+		r.Get("/api/auth/me", handlers.GetCurrentUser)
+		r.Put("/api/auth/password", handlers.ChangePassword)
+
+		// User endpoints
+		r.Group(func(r chi.Router) {
+			r.Use(authz.Require(authz.UsersManage))
+
+			r.Get("/api/users", handlers.GetUsers)
+			r.Post("/api/users", handlers.CreateUser)
+			r.Put("/api/users/{id}", handlers.UpdateUser)
+			r.Delete("/api/users/{id}", handlers.DeleteUser)
+		})
+		r.Get("/api/audit", handlers.GetAuditLog)
+
+		// API key endpoints
+		r.Get("/api/api-keys", handlers.GetAPIKeys)
+		r.Post("/api/api-keys", handlers.CreateAPIKey)
+		r.Delete("/api/api-keys/{id}", handlers.RevokeAPIKey)
+
+		// Role grant endpoints
+		r.Get("/api/me/permissions", handlers.GetMyPermissions)
+		r.Get("/api/grants", handlers.GetGrants)
+		r.Post("/api/grants", handlers.CreateGrant)
+		r.Delete("/api/grants/{id}", handlers.DeleteGrant)
+
+		r.Get("/api/exercises", handlers.GetExercises)
+		r.Post("/api/exercises", handlers.CreateExerciseHandler)
+		r.Put("/api/exercises/{id}", handlers.UpdateExerciseHandler)
+		r.Patch("/api/exercises/{id}", handlers.PatchExercise)
+		r.Delete("/api/exercises/{id}", handlers.DeleteExerciseHandler)
+		r.Put("/api/exercises/{id}/divisions/order", handlers.ReorderDivisions)
+		r.Get("/api/exercises/{id}/readiness", handlers.GetExerciseReadiness)
+		r.Get("/api/exercises/{id}/analytics", handlers.GetExerciseAnalytics)
+		r.Get("/api/analytics/trend", handlers.GetAnalyticsTrend)
+		r.Get("/api/exercises/{id}/sitrep", handlers.GetSitrep)
+		r.Get("/api/exercises/{id}/sitrep-template", handlers.GetSitrepTemplate)
+		r.Put("/api/exercises/{id}/sitrep-template", handlers.SetSitrepTemplate)
+		r.Get("/api/exercises/{id}/stale-statuses", handlers.GetStaleStatuses)
+		r.Get("/api/exercises/{id}/escalation-policy", handlers.GetEscalationPolicy)
+		r.Put("/api/exercises/{id}/escalation-policy", handlers.SetEscalationPolicy)
+		r.Get("/api/exercises/{id}/escalations", handlers.GetEscalations)
+		r.Post("/api/escalations/{id}/acknowledge", handlers.AcknowledgeEscalation)
+
+		r.Get("/api/divisions", handlers.GetDivisionsForExercise)
+		r.Post("/api/divisions", handlers.CreateDivision)
+		r.Put("/api/divisions/update", handlers.UpdateDivision)
+		r.Patch("/api/divisions/{id}", handlers.PatchDivision)
+		r.Delete("/api/divisions/{id}", handlers.DeleteDivision)
+		r.Get("/api/divisions/{id}/teams", handlers.GetDivisionTeams)
+		r.Get("/api/divisions/{id}/readiness", handlers.GetDivisionReadiness)
+		r.Put("/api/divisions/{id}/teams/order", handlers.ReorderTeams)
+		r.Post("/api/teams", handlers.CreateTeam)
+		r.Post("/api/teams/status", handlers.SetTeamStatuses)
+		r.Get("/api/teams/{id}", handlers.GetTeam)
+		r.Put("/api/teams/{id}", handlers.UpdateTeam)
+		r.Patch("/api/teams/{id}", handlers.PatchTeam)
+		r.Post("/api/teams/{id}/move", handlers.MoveTeam)
+		r.Post("/api/teams/{id}/confirm", handlers.ConfirmTeamStatus)
+		r.Delete("/api/teams/{id}", handlers.DeleteTeam)
+		r.Get("/api/status-board", handlers.GetStatusBoard)
+
+		// Event endpoints
+		r.Get("/api/events", handlers.GetEvents)
+		r.Post("/api/events", handlers.CreateEvent)
+		r.Put("/api/events/{id}", handlers.UpdateEvent)
+		r.Patch("/api/events/{id}", handlers.PatchEvent)
+		r.Delete("/api/events/{id}", handlers.DeleteEvent)
+
+		// Task endpoints
+		r.Get("/api/tasks", handlers.GetTasks)
+		r.Post("/api/tasks", handlers.CreateTask)
+		r.Put("/api/tasks/{id}", handlers.UpdateTask)
+		r.Patch("/api/tasks/{id}", handlers.PatchTask)
+		r.Put("/api/tasks/{id}/assign", handlers.AssignTaskToTeam)
+		r.Put("/api/tasks/{id}/assign-multiple", handlers.AssignTaskToMultipleTeams)
+		r.Delete("/api/tasks/{id}", handlers.DeleteTask)
+
+		// Webhook endpoints
+		r.Group(func(r chi.Router) {
+			if !cfg.Features.Webhooks {
+				return
+			}
+			r.Use(authz.Require(authz.WebhooksManage))
+
+			r.Get("/api/webhooks", handlers.GetWebhooks)
+			r.Post("/api/webhooks", handlers.CreateWebhook)
+			r.Get("/api/webhooks/{id}", handlers.GetWebhook)
+			r.Put("/api/webhooks/{id}", handlers.UpdateWebhook)
+			r.Delete("/api/webhooks/{id}", handlers.DeleteWebhook)
+			r.Get("/api/webhooks/{id}/deliveries", handlers.GetWebhookDeliveries)
+			r.Get("/api/webhooks/{id}/deliveries/{deliveryID}", handlers.GetWebhookDelivery)
+			r.Post("/api/webhooks/{id}/deliveries/{deliveryID}/redeliver", handlers.RedeliverWebhook)
+		})
+
+		// Chatbot endpoint
+		if cfg.Features.Chatbot {
+			r.Post("/api/chatbot", handlers.EnhancedChatbotHandler)
+		}
+	})
+
+	return r
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/openapi"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestRoutesDocumented fails when a route is registered without being
+// described in the OpenAPI document, or described without being registered
+func TestRoutesDocumented(t *testing.T) {
+	cfg := config.Default()
+	cfg.Features.LiveStream = true
+	cfg.Features.Webhooks = true
+	cfg.Features.Chatbot = true
+
+	registered := map[string]bool{}
+	err := chi.Walk(newRouter(cfg), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+strings.TrimSuffix(route, "/")] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking routes: %v", err)
+	}
+
+	documented := map[string]bool{}
+	for _, op := range openapi.Operations() {
+		documented[op] = true
+		if !registered[op] {
+			t.Errorf("%s is documented but not registered", op)
+		}
+	}
+	for op := range registered {
+		if !documented[op] {
+			t.Errorf("%s is registered but missing from the OpenAPI document", op)
+		}
+	}
+}
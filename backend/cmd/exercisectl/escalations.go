@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"srd-calendar-project/backend/client"
+	"strings"
+	"time"
+)
+
+// parseStep reads an escalation step written STATUS:AFTER:NOTIFY, e.g.
+// red:2h:division_poc
+func parseStep(s string) (client.EscalationStep, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return client.EscalationStep{}, fmt.Errorf("step %q: use STATUS:AFTER:NOTIFY, e.g. red:2h:division_poc", s)
+	}
+	after, err := time.ParseDuration(parts[1])
+	if err != nil || after < time.Minute {
+		return client.EscalationStep{}, fmt.Errorf("step %q: AFTER must be a duration of at least 1m, e.g. 90m or 2h", s)
+	}
+	return client.EscalationStep{
+		Status:       strings.ToLower(parts[0]),
+		AfterMinutes: int(after / time.Minute),
+		Notify:       strings.ToLower(parts[2]),
+	}, nil
+}
+
+// afterText writes a step's time the way --step takes it, e.g. 2h or 90m
+func afterText(minutes int) string {
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func stepTable(policy client.EscalationPolicy) *table {
+	t := newTable(policy, "STATUS", "AFTER", "NOTIFY")
+	for _, step := range policy.Steps {
+		t.add(step.Status, afterText(step.AfterMinutes), step.Notify)
+	}
+	return t
+}
+
+func showEscalationPolicy(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	policy, err := a.client.GetEscalationPolicy(ctx, ex.ID)
+	if err != nil {
+		return err
+	}
+	return a.render(stepTable(policy))
+}
+
+// setEscalationPolicy replaces the exercise's steps with those given; no
+// steps turns escalation off
+func setEscalationPolicy(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var stepRefs stringList
+	fs.Var(&stepRefs, "step", "STATUS:AFTER:NOTIFY, e.g. red:2h:division_poc; repeat for several steps")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	steps := []client.EscalationStep{}
+	for _, ref := range stepRefs {
+		step, err := parseStep(ref)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, step)
+	}
+
+	policy, err := a.client.SetEscalationPolicy(ctx, ex.ID, steps)
+	if err != nil {
+		return err
+	}
+	if len(policy.Steps) == 0 {
+		return a.message(policy, "Escalation turned off for %s", ex.Name)
+	}
+	return a.render(stepTable(policy))
+}
+
+func listEscalations(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	open := fs.Bool("open", false, "only escalations not yet acknowledged")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	escalations, err := a.client.ListEscalations(ctx, ex.ID, *open)
+	if err != nil {
+		return err
+	}
+	t := newTable(escalations, "ID", "TRIGGERED", "DIVISION", "TEAM", "STATUS", "NOTIFY", "RECIPIENTS", "ACKNOWLEDGED", "BY")
+	for _, e := range escalations {
+		t.add(e.ID, e.TriggeredAt, e.DivisionName, e.TeamName, e.Status, e.Notify, e.Recipients, e.AcknowledgedAt, e.AcknowledgedBy)
+	}
+	return a.render(t)
+}
+
+func acknowledgeEscalation(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	note := fs.String("note", "", "what is being done about it")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(args[0], "escalation")
+	if err != nil {
+		return err
+	}
+
+	escalation, err := a.client.AcknowledgeEscalation(ctx, id, *note)
+	if err != nil {
+		return err
+	}
+	return a.message(escalation, "Acknowledged escalation %d: %s / %s stays %s without further escalation", escalation.ID, escalation.DivisionName, escalation.TeamName, escalation.Status)
+}
@@ -0,0 +1,748 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"srd-calendar-project/backend/client"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDate accepts a date (YYYY-MM-DD, taken as UTC midnight like the API
+// stores whole days) or an RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q; use YYYY-MM-DD or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// today is the current date as UTC midnight, comparable with exercise dates
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// active reports whether an exercise is running on day
+func active(ex client.Exercise, day time.Time) bool {
+	return !day.Before(ex.StartDate.UTC().Truncate(24*time.Hour)) && !day.After(ex.EndDate.UTC())
+}
+
+// findExercise resolves an exercise ID or name
+func (a *app) findExercise(ctx context.Context, ref string) (client.Exercise, error) {
+	exercises, err := a.client.ListExercises(ctx, client.ExerciseFilter{})
+	if err != nil {
+		return client.Exercise{}, err
+	}
+
+	if id, err := strconv.Atoi(ref); err == nil {
+		for _, ex := range exercises {
+			if ex.ID == id {
+				return ex, nil
+			}
+		}
+		return client.Exercise{}, fmt.Errorf("no exercise with ID %d", id)
+	}
+
+	var matches []client.Exercise
+	for _, ex := range exercises {
+		if strings.EqualFold(ex.Name, ref) {
+			matches = append(matches, ex)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return client.Exercise{}, fmt.Errorf("no exercise named %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return client.Exercise{}, fmt.Errorf("%d exercises are named %q; use an ID (%s)", len(matches), ref, exerciseIDs(matches))
+}
+
+func exerciseIDs(exercises []client.Exercise) string {
+	ids := make([]string, len(exercises))
+	for i, ex := range exercises {
+		ids[i] = strconv.Itoa(ex.ID)
+	}
+	return strings.Join(ids, ", ")
+}
+
+func findDivision(ex client.Exercise, name string) (client.Division, error) {
+	for _, d := range ex.Divisions {
+		if strings.EqualFold(d.Name, name) {
+			return d, nil
+		}
+	}
+	return client.Division{}, fmt.Errorf("exercise %q has no division named %q", ex.Name, name)
+}
+
+func findTeam(division client.Division, name string) (client.Team, error) {
+	for _, t := range division.Teams {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return client.Team{}, fmt.Errorf("division %q has no team named %q", division.Name, name)
+}
+
+func listExercises(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	onlyActive := fs.Bool("active", false, "only exercises running today")
+	division := fs.String("division", "", "only exercises with a division of this name")
+	team := fs.String("team", "", "only exercises with a team of this name")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	exercises, err := a.client.ListExercises(ctx, client.ExerciseFilter{DivisionName: *division, TeamName: *team})
+	if err != nil {
+		return err
+	}
+
+	shown := []client.Exercise{}
+	for _, ex := range exercises {
+		if !*onlyActive || active(ex, today()) {
+			shown = append(shown, ex)
+		}
+	}
+
+	t := newTable(shown, "ID", "NAME", "START", "END", "PRIORITY", "POC", "DIVISIONS")
+	for _, ex := range shown {
+		t.add(ex.ID, ex.Name, ex.StartDate, ex.EndDate, ex.Priority, ex.ExerciseEventPOC, len(ex.Divisions))
+	}
+	return a.render(t)
+}
+
+func showExercise(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if a.output == "table" {
+		fmt.Fprintf(a.out, "%s (ID %d), %s to %s, %s priority\n", ex.Name, ex.ID,
+			formatDate(ex.StartDate), formatDate(ex.EndDate), ex.Priority)
+		fmt.Fprintf(a.out, "POCs: event %s, SRD %s, CPD %s\n\n", ex.ExerciseEventPOC, ex.SRDPOC, ex.CPDPOC)
+	}
+	return a.render(teamTable(ex, ex.Divisions))
+}
+
+// exerciseReadiness shows the readiness of an exercise and each division on
+// a day
+func exerciseReadiness(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	date := fs.String("date", "", "day to roll up; defaults to today")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	var day time.Time
+	if *date != "" {
+		if day, err = parseDate(*date); err != nil {
+			return fmt.Errorf("--date: %w", err)
+		}
+	}
+
+	rollup, err := a.client.GetExerciseReadiness(ctx, ex.ID, day)
+	if err != nil {
+		return err
+	}
+	t := newTable(rollup, "DIVISION", "READINESS", "TEAMS", "GREEN", "YELLOW", "RED", "GREEN %")
+	row := func(name string, r client.Readiness) {
+		t.add(name, r.Status, r.Teams, r.Green, r.Yellow, r.Red, r.GreenPercent)
+	}
+	for _, division := range rollup.Divisions {
+		row(division.Name, division.Readiness)
+	}
+	row("(exercise)", rollup.Readiness)
+	if a.output == "table" {
+		fmt.Fprintf(a.out, "%s readiness on %s (%s)\n\n", ex.Name, rollup.Readiness.Date, rollup.Readiness.Rule)
+	}
+	return a.render(t)
+}
+
+// staleStatuses lists the teams whose status has not been set or confirmed
+// within the server's stale interval
+func staleStatuses(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	report, err := a.client.GetStaleStatuses(ctx, ex.ID)
+	if err != nil {
+		return err
+	}
+	t := newTable(report, "DIVISION", "TEAM", "STATUS", "POC", "UNCONFIRMED", "FLAGGED")
+	for _, team := range report.Teams {
+		t.add(team.DivisionName, team.Name, team.Status, team.POC, team.UnconfirmedFor, team.Flagged)
+	}
+	return a.render(t)
+}
+
+// dateRange parses the --from and --to flags of the analytics commands
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	var first, last time.Time
+	var err error
+	if from != "" {
+		if first, err = parseDate(from); err != nil {
+			return first, last, fmt.Errorf("--from: %w", err)
+		}
+	}
+	if to != "" {
+		if last, err = parseDate(to); err != nil {
+			return first, last, fmt.Errorf("--to: %w", err)
+		}
+	}
+	return first, last, nil
+}
+
+// mttrText shows a mean time to recover, blank when nothing recovered
+func mttrText(r client.Recovery) string {
+	if r.MTTRHours == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*r.MTTRHours, 'f', -1, 64)
+}
+
+// exerciseAnalytics shows the hours each division and team spent in each
+// status, or with --daily the share of each day spent in each status
+func exerciseAnalytics(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	from := fs.String("from", "", "first day; defaults to the exercise's start")
+	to := fs.String("to", "", "last day; defaults to the exercise's end")
+	daily := fs.Bool("daily", false, "show daily readiness instead of time in status")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	first, last, err := dateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	report, err := a.client.GetExerciseAnalytics(ctx, ex.ID, first, last)
+	if err != nil {
+		return err
+	}
+	if *daily {
+		t := newTable(report.Daily, "DATE", "DIVISION", "GREEN %", "YELLOW %", "RED %")
+		for _, day := range report.Daily {
+			for _, division := range day.Divisions {
+				t.add(day.Date, division.Name, division.GreenPercent, division.YellowPercent, division.RedPercent)
+			}
+			t.add(day.Date, "(exercise)", day.GreenPercent, day.YellowPercent, day.RedPercent)
+		}
+		return a.render(t)
+	}
+
+	t := newTable(report, "DIVISION", "TEAM", "GREEN H", "YELLOW H", "RED H", "GREEN %", "RED EPISODES", "MTTR H")
+	row := func(division, team string, st client.StatusTime, r client.Recovery) {
+		t.add(division, team, st.GreenHours, st.YellowHours, st.RedHours, st.GreenPercent, r.RedEpisodes, mttrText(r))
+	}
+	for _, division := range report.Divisions {
+		for _, team := range division.Teams {
+			row(division.Name, team.Name, team.Time, team.Recovery)
+		}
+		row(division.Name, "(division)", division.Time, division.Recovery)
+	}
+	row("(exercise)", "", report.Time, report.Recovery)
+	if a.output == "table" {
+		fmt.Fprintf(a.out, "%s status analytics, %s to %s\n\n", ex.Name, report.From, report.To)
+	}
+	return a.render(t)
+}
+
+// exerciseTrend compares the exercises that have started, oldest first
+func exerciseTrend(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	from := fs.String("from", "", "only exercises running on or after this day")
+	to := fs.String("to", "", "only exercises running on or before this day")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	first, last, err := dateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	trend, err := a.client.GetAnalyticsTrend(ctx, first, last)
+	if err != nil {
+		return err
+	}
+	t := newTable(trend, "EXERCISE", "START", "END", "GREEN %", "YELLOW %", "RED %", "RED H", "RED EPISODES", "MTTR H")
+	for _, ex := range trend {
+		t.add(ex.Name, ex.StartDate, ex.EndDate, ex.Time.GreenPercent, ex.Time.YellowPercent, ex.Time.RedPercent,
+			ex.Time.RedHours, ex.Recovery.RedEpisodes, mttrText(ex.Recovery))
+	}
+	return a.render(t)
+}
+
+func createExercise(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	name := fs.String("name", "", "exercise name")
+	start := fs.String("start", "", "start date")
+	end := fs.String("end", "", "end date")
+	priority := fs.String("priority", "medium", "high, medium or low")
+	description := fs.String("description", "", "description")
+	poc := fs.String("poc", "", "exercise/event POC")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	ex := client.Exercise{Name: *name, Priority: *priority, Description: *description, ExerciseEventPOC: *poc}
+	var err error
+	if ex.StartDate, err = parseDate(*start); err != nil {
+		return fmt.Errorf("--start: %w", err)
+	}
+	if ex.EndDate, err = parseDate(*end); err != nil {
+		return fmt.Errorf("--end: %w", err)
+	}
+
+	created, err := a.client.CreateExercise(ctx, ex)
+	if err != nil {
+		return err
+	}
+	return a.message(created, "Created exercise %d %q", created.ID, created.Name)
+}
+
+func deleteExercise(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteExercise(ctx, ex.ID); err != nil {
+		return err
+	}
+	return a.message(ex, "Deleted exercise %d %q", ex.ID, ex.Name)
+}
+
+func listDivisions(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	t := newTable(ex.Divisions, "ID", "NAME", "POC", "TEAMS", "LEARNING OBJECTIVES")
+	for _, d := range ex.Divisions {
+		t.add(d.ID, d.Name, d.POC, len(d.Teams), d.LearningObjectives)
+	}
+	return a.render(t)
+}
+
+func createDivision(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	objectives := fs.String("objectives", "", "learning objectives")
+	poc := fs.String("poc", "", "division lead, notified by escalation policies")
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	created, err := a.client.CreateDivision(ctx, client.Division{ExerciseID: ex.ID, Name: args[1], LearningObjectives: *objectives, POC: *poc})
+	if err != nil {
+		return err
+	}
+	return a.message(created, "Created division %d %q in %s", created.ID, created.Name, ex.Name)
+}
+
+func deleteDivision(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	division, err := findDivision(ex, args[1])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteDivision(ctx, division.ID); err != nil {
+		return err
+	}
+	return a.message(division, "Deleted division %q and its %d teams from %s", division.Name, len(division.Teams), ex.Name)
+}
+
+// teamTable lists the teams of divisions
+func teamTable(ex client.Exercise, divisions []client.Division) *table {
+	teams := []client.Team{}
+	for _, d := range divisions {
+		teams = append(teams, d.Teams...)
+	}
+
+	t := newTable(teams, "ID", "DIVISION", "TEAM", "STATUS", "FROM", "UNTIL", "POC", "COMMENTS")
+	for _, d := range divisions {
+		for _, team := range d.Teams {
+			t.add(team.ID, d.Name, team.Name, team.Status, team.StatusStart, team.StatusEnd, team.POC, team.Comments)
+		}
+	}
+	return t
+}
+
+func listTeams(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	divisions := ex.Divisions
+	if len(args) == 2 {
+		division, err := findDivision(ex, args[1])
+		if err != nil {
+			return err
+		}
+		divisions = []client.Division{division}
+	}
+	return a.render(teamTable(ex, divisions))
+}
+
+func createTeam(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	poc := fs.String("poc", "", "team POC")
+	weight := fs.Int("weight", 1, "how much the team counts toward readiness; 0 leaves it out")
+	args, err := a.parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	division, err := findDivision(ex, args[1])
+	if err != nil {
+		return err
+	}
+
+	created, err := a.client.CreateTeam(ctx, client.Team{
+		ExerciseID:      ex.ID,
+		DivisionID:      division.ID,
+		Name:            args[2],
+		POC:             *poc,
+		Status:          "green",
+		ReadinessWeight: *weight,
+	})
+	if err != nil {
+		return err
+	}
+	return a.message(created, "Created team %d %q in %s", created.ID, created.Name, division.Name)
+}
+
+func deleteTeam(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 3, 3)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	division, err := findDivision(ex, args[1])
+	if err != nil {
+		return err
+	}
+	team, err := findTeam(division, args[2])
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteTeam(ctx, team.ID); err != nil {
+		return err
+	}
+	return a.message(team, "Deleted team %q from %s", team.Name, division.Name)
+}
+
+func moveTeam(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	position := fs.Int("position", 0, "place among the new division's teams, from 1; default last")
+	args, err := a.parse(fs, args, 4, 4)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	division, err := findDivision(ex, args[1])
+	if err != nil {
+		return err
+	}
+	team, err := findTeam(division, args[2])
+	if err != nil {
+		return err
+	}
+	target, err := findDivision(ex, args[3])
+	if err != nil {
+		return err
+	}
+
+	moved, err := a.client.MoveTeam(ctx, team.ID, target.ID, *position)
+	if err != nil {
+		return err
+	}
+	return a.message(moved, "Moved team %q from %s to %s", moved.Name, division.Name, target.Name)
+}
+
+// setTeamStatus sets a team's status. Without --exercise the team is looked
+// up in the exercises running today.
+func setTeamStatus(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	exerciseRef := fs.String("exercise", "", "exercise ID or name; defaults to the active exercise with this team")
+	from := fs.String("from", "", "start of the status window; defaults to today when the status changes")
+	until := fs.String("until", "", "end of the status window")
+	comment := fs.String("comment", "", "comment, replacing the current one")
+	args, err := a.parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	divisionName, teamName, status := args[0], args[1], strings.ToLower(args[2])
+
+	ex, team, err := a.findActiveTeam(ctx, *exerciseRef, divisionName, teamName)
+	if err != nil {
+		return err
+	}
+
+	patch := client.Patch{"status": status}
+	if status != team.Status {
+		patch["status_start"] = today()
+		patch["status_end"] = nil
+	}
+	if *from != "" {
+		start, err := parseDate(*from)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		patch["status_start"] = start
+	}
+	if *until != "" {
+		end, err := parseDate(*until)
+		if err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+		patch["status_end"] = end
+	}
+	if *comment != "" {
+		patch["comments"] = *comment
+	}
+
+	if team, err = a.client.PatchTeam(ctx, team.ID, patch); err != nil {
+		return err
+	}
+	window := ""
+	if !team.StatusEnd.IsZero() {
+		window = " until " + formatDate(team.StatusEnd)
+	}
+	return a.message(team, "%s / %s in %s is now %s%s", divisionName, team.Name, ex.Name, team.Status, window)
+}
+
+// confirmTeamStatus records that a team's status is still accurate without
+// changing it
+func confirmTeamStatus(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	exerciseRef := fs.String("exercise", "", "exercise ID or name; defaults to the active exercise with this team")
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	divisionName, teamName := args[0], args[1]
+
+	ex, team, err := a.findActiveTeam(ctx, *exerciseRef, divisionName, teamName)
+	if err != nil {
+		return err
+	}
+	if team, err = a.client.ConfirmTeamStatus(ctx, team.ID); err != nil {
+		return err
+	}
+	return a.message(team, "Confirmed %s / %s in %s is %s", divisionName, team.Name, ex.Name, team.Status)
+}
+
+// setTeamStatuses sets one status on several teams of an exercise in a
+// single all-or-nothing update
+func setTeamStatuses(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	divisionName := fs.String("division", "", "only the teams of this division")
+	var teamRefs stringList
+	fs.Var(&teamRefs, "team", "DIVISION/TEAM to update; repeat for several teams")
+	from := fs.String("from", "", "start of the status window")
+	until := fs.String("until", "", "end of the status window")
+	comment := fs.String("comment", "", "comment, replacing each team's current one")
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *divisionName != "" && len(teamRefs) > 0 {
+		return fmt.Errorf("give --division or --team, not both")
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	update := client.TeamStatusUpdate{Status: strings.ToLower(args[1])}
+	switch {
+	case *divisionName != "":
+		division, err := findDivision(ex, *divisionName)
+		if err != nil {
+			return err
+		}
+		update.DivisionID = division.ID
+	case len(teamRefs) > 0:
+		for _, ref := range teamRefs {
+			divName, teamName, ok := strings.Cut(ref, "/")
+			if !ok {
+				return fmt.Errorf("--team %q: use DIVISION/TEAM", ref)
+			}
+			division, err := findDivision(ex, divName)
+			if err != nil {
+				return err
+			}
+			team, err := findTeam(division, teamName)
+			if err != nil {
+				return err
+			}
+			update.TeamIDs = append(update.TeamIDs, team.ID)
+		}
+	default:
+		update.ExerciseID = ex.ID
+	}
+	if *from != "" {
+		start, err := parseDate(*from)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		update.StatusStart = &start
+	}
+	if *until != "" {
+		end, err := parseDate(*until)
+		if err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+		update.StatusEnd = &end
+	}
+	if *comment != "" {
+		update.Comments = comment
+	}
+
+	results, err := a.client.SetTeamStatuses(ctx, update)
+	if err != nil {
+		return err
+	}
+	divisions := map[int]string{}
+	for _, division := range ex.Divisions {
+		divisions[division.ID] = division.Name
+	}
+	t := newTable(results, "ID", "DIVISION", "TEAM", "PREVIOUS", "STATUS", "CHANGED")
+	for _, result := range results {
+		t.add(result.TeamID, divisions[result.DivisionID], result.Name, result.PreviousStatus, result.Status, result.Changed)
+	}
+	return a.render(t)
+}
+
+// statusBoard lists every team of the exercises running today
+func statusBoard(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	var divisions, statuses stringList
+	fs.Var(&divisions, "division", "only this division; repeat for several")
+	fs.Var(&statuses, "status", "only teams with this status; repeat for several")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	board, err := a.client.GetStatusBoard(ctx, client.StatusBoardFilter{Divisions: divisions, Statuses: statuses})
+	if err != nil {
+		return err
+	}
+	t := newTable(board, "EXERCISE", "DIVISION", "TEAM", "STATUS", "HELD", "UNTIL", "POC", "COMMENTS")
+	for _, ex := range board.Exercises {
+		for _, division := range ex.Divisions {
+			for _, team := range division.Teams {
+				var until *time.Time
+				if !team.StatusEnd.IsZero() {
+					until = &team.StatusEnd
+				}
+				t.add(ex.Name, division.Name, team.Name, team.Status, team.HeldFor, until, team.POC, team.Comments)
+			}
+		}
+	}
+	return a.render(t)
+}
+
+// findActiveTeam finds a team by division and team name, in the given
+// exercise or else in the one active exercise that has it
+func (a *app) findActiveTeam(ctx context.Context, exerciseRef, divisionName, teamName string) (client.Exercise, client.Team, error) {
+	if exerciseRef != "" {
+		ex, err := a.findExercise(ctx, exerciseRef)
+		if err != nil {
+			return ex, client.Team{}, err
+		}
+		division, err := findDivision(ex, divisionName)
+		if err != nil {
+			return ex, client.Team{}, err
+		}
+		team, err := findTeam(division, teamName)
+		return ex, team, err
+	}
+
+	exercises, err := a.client.ListExercises(ctx, client.ExerciseFilter{TeamName: teamName})
+	if err != nil {
+		return client.Exercise{}, client.Team{}, err
+	}
+	var matches []client.Exercise
+	var teams []client.Team
+	for _, ex := range exercises {
+		if !active(ex, today()) {
+			continue
+		}
+		if division, err := findDivision(ex, divisionName); err == nil {
+			if team, err := findTeam(division, teamName); err == nil {
+				matches = append(matches, ex)
+				teams = append(teams, team)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return client.Exercise{}, client.Team{}, fmt.Errorf("no active exercise has team %q in division %q; pass --exercise", teamName, divisionName)
+	case 1:
+		return matches[0], teams[0], nil
+	}
+	return client.Exercise{}, client.Team{}, fmt.Errorf("%d active exercises have team %q in division %q; pass --exercise (%s)",
+		len(matches), teamName, divisionName, exerciseIDs(matches))
+}
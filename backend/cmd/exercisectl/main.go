@@ -0,0 +1,262 @@
+// Command exercisectl manages exercises, divisions, teams, events and tasks
+// from a terminal through the API. It reads the server URL and API key from
+// a profile file; see "exercisectl help".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"srd-calendar-project/backend/client"
+	"strings"
+)
+
+const usage = `Usage: exercisectl <command> [arguments] [flags]
+
+Exercises:
+  list [--active] [--division NAME] [--team NAME]   list exercises
+  exercises show EXERCISE                           show an exercise with its divisions and teams
+  exercises readiness EXERCISE [--date DATE]        readiness of the exercise and each division
+  exercises stale EXERCISE                          teams whose status has gone unconfirmed too long
+  exercises analytics EXERCISE [--from DATE] [--to DATE] [--daily]   hours in each status and time to recover from red
+  exercises trend [--from DATE] [--to DATE]         compare the exercises that have started, oldest first
+  exercises create --name NAME --start DATE --end DATE [--priority P] [--description TEXT] [--poc NAME]
+  exercises delete EXERCISE
+
+Divisions and teams:
+  divisions list EXERCISE
+  divisions create EXERCISE NAME [--objectives TEXT] [--poc NAME]
+  divisions delete EXERCISE DIVISION
+  teams list EXERCISE [DIVISION]
+  teams create EXERCISE DIVISION NAME [--poc NAME] [--weight N]
+  teams delete EXERCISE DIVISION TEAM
+  teams move EXERCISE DIVISION TEAM NEW_DIVISION [--position N]   keeps the team's tasks and status
+  teams status EXERCISE green|yellow|red [--division NAME] [--team DIVISION/TEAM]... [--from DATE] [--until DATE] [--comment TEXT]
+                                                    set many teams at once; defaults to every team
+  team status DIVISION TEAM green|yellow|red [--from DATE] [--until DATE] [--comment TEXT] [--exercise EXERCISE]
+  team confirm DIVISION TEAM [--exercise EXERCISE]  confirm the team's status is still accurate
+  board [--division NAME]... [--status S]...         every team of the exercises running today
+
+Escalation:
+  escalation show EXERCISE                          the exercise's escalation steps
+  escalation set EXERCISE [--step STATUS:AFTER:NOTIFY]...   replace the steps, e.g. --step red:2h:division_poc;
+                                                    NOTIFY is team_poc, division_poc or exercise_poc; no steps turns it off
+  escalation list EXERCISE [--open]                 escalation history, newest first
+  escalation ack ESCALATION_ID [--note TEXT]        stop further escalation until the team's status changes
+
+SITREPs:
+  sitrep show EXERCISE [--date DATE] [--html]       the day's situation report, in Markdown unless --html; -o json for the data
+  sitrep template EXERCISE [--html]                 the Markdown (or HTML) template SITREPs are rendered with
+  sitrep set-template EXERCISE [--markdown FILE] [--html FILE] [--reset]
+                                                    replace the templates from files, or go back to the built-in ones
+
+Events:
+  events list EXERCISE [--upcoming]
+  events create EXERCISE NAME --start DATE [--end DATE] [--type T] [--priority P] [--poc NAME] [--location TEXT]
+  events delete EVENT_ID
+
+Tasks:
+  tasks list EXERCISE [--status S]
+  tasks overdue [--exercise EXERCISE]                overdue tasks in one or all active exercises
+  tasks create EXERCISE NAME [--due DATE] [--description TEXT] [--assigned-to NAME] [--team DIVISION/TEAM]...
+  tasks complete TASK_ID [--exercise EXERCISE]
+  tasks delete TASK_ID
+
+Other:
+  chat MESSAGE                                      ask the chatbot
+  whoami                                            show the user the API key acts as
+  profile set NAME --server URL --api-key KEY [--output FORMAT] [--default]
+  profile list
+
+EXERCISE is an exercise ID or name; DIVISION and TEAM are names. DATE is
+YYYY-MM-DD or an RFC 3339 timestamp.
+
+Flags accepted by every command:
+  --profile NAME     profile to use (env EXERCISECTL_PROFILE; default: the file's default)
+  --server URL       server URL, overriding the profile
+  --api-key KEY      API key, overriding the profile (env AOC_API_KEY)
+  -o, --output FMT   table, json or csv (default: the profile's, else table)
+
+Profiles are read from $EXERCISECTL_CONFIG, or exercisectl/profiles.json in
+the user config directory.
+`
+
+// command runs one subcommand with its flag set and positional arguments
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"list":       {"": listExercises},
+	"exercises":  {"list": listExercises, "show": showExercise, "readiness": exerciseReadiness, "stale": staleStatuses, "analytics": exerciseAnalytics, "trend": exerciseTrend, "create": createExercise, "delete": deleteExercise},
+	"divisions":  {"list": listDivisions, "create": createDivision, "delete": deleteDivision},
+	"teams":      {"list": listTeams, "create": createTeam, "delete": deleteTeam, "move": moveTeam, "status": setTeamStatuses},
+	"team":       {"status": setTeamStatus, "confirm": confirmTeamStatus},
+	"board":      {"": statusBoard},
+	"escalation": {"show": showEscalationPolicy, "set": setEscalationPolicy, "list": listEscalations, "ack": acknowledgeEscalation},
+	"sitrep":     {"show": showSitrep, "template": showSitrepTemplate, "set-template": setSitrepTemplate},
+	"events":     {"list": listEvents, "create": createEvent, "delete": deleteEvent},
+	"tasks":      {"list": listTasks, "overdue": overdueTasks, "create": createTask, "complete": completeTask, "delete": deleteTask},
+	"chat":       {"": chat},
+	"whoami":     {"": whoami},
+	"profile":    {"set": setProfile, "list": listProfiles},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		stop()
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "exercisectl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(out, usage)
+		return nil
+	}
+
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q; run exercisectl help", args[0])
+	}
+	name, args := args[0], args[1:]
+	cmd, ok := subcommands[""]
+	if !ok {
+		if len(args) == 0 {
+			return fmt.Errorf("%s needs a subcommand; run exercisectl help", name)
+		}
+		if cmd, ok = subcommands[args[0]]; !ok {
+			return fmt.Errorf("unknown command %q; run exercisectl help", name+" "+args[0])
+		}
+		name, args = name+" "+args[0], args[1:]
+	}
+
+	return cmd(ctx, &app{name: name, out: out}, args)
+}
+
+// app holds what every command shares: the global flags and, once parsed,
+// the API client and output format
+type app struct {
+	name    string
+	out     io.Writer
+	profile string
+	server  string
+	apiKey  string
+	output  string
+	client  *client.Client
+}
+
+// flags returns a flag set for the command with the global flags registered
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("exercisectl "+a.name, flag.ContinueOnError)
+	fs.StringVar(&a.profile, "profile", os.Getenv("EXERCISECTL_PROFILE"), "profile to use")
+	fs.StringVar(&a.server, "server", "", "server URL")
+	fs.StringVar(&a.apiKey, "api-key", os.Getenv("AOC_API_KEY"), "API key")
+	fs.StringVar(&a.output, "output", "", "output format: table, json or csv")
+	fs.StringVar(&a.output, "o", "", "output format (shorthand)")
+	fs.Usage = func() { fmt.Fprintf(fs.Output(), "Run exercisectl help for usage.\n") }
+	return fs
+}
+
+// parse parses flags, which may come before, between or after positional
+// arguments, checks the number of positional arguments, and connects to the
+// server named by the profile and flags
+func (a *app) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s; run exercisectl help", a.name)
+	}
+	if err := a.connect(); err != nil {
+		return nil, err
+	}
+	return positional, nil
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (a *app) connect() error {
+	profiles, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	p, err := profiles.find(a.profile)
+	if err != nil {
+		return err
+	}
+
+	if a.server == "" {
+		a.server = p.Server
+	}
+	if a.apiKey == "" {
+		a.apiKey = p.APIKey
+	}
+	if a.output == "" {
+		a.output = p.Output
+	}
+	if a.output == "" {
+		a.output = "table"
+	}
+	if a.output != "table" && a.output != "json" && a.output != "csv" {
+		return fmt.Errorf("unknown output format %q; use table, json or csv", a.output)
+	}
+	if a.server == "" {
+		return fmt.Errorf("no server configured; run exercisectl profile set NAME --server URL --api-key KEY")
+	}
+
+	a.client, err = client.New(a.server, client.WithAPIKey(a.apiKey), client.WithUserAgent("exercisectl"))
+	return err
+}
+
+func chat(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, -1)
+	if err != nil {
+		return err
+	}
+	reply, err := a.client.Chat(ctx, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, reply)
+	return nil
+}
+
+func whoami(ctx context.Context, a *app, args []string) error {
+	if _, err := a.parse(a.flags(), args, 0, 0); err != nil {
+		return err
+	}
+	user, err := a.client.Me(ctx)
+	if err != nil {
+		return err
+	}
+	t := newTable(user, "ID", "USERNAME", "DISPLAY NAME", "EMAIL", "SERVICE")
+	t.add(user.ID, user.Username, user.DisplayName, user.Email, user.Service)
+	return a.render(t)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table is a command's result: rows for table and CSV output, and the API
+// values themselves for JSON output
+type table struct {
+	data    interface{}
+	headers []string
+	rows    [][]string
+}
+
+func newTable(data interface{}, headers ...string) *table {
+	return &table{data: data, headers: headers}
+}
+
+func (t *table) add(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = cellText(cell)
+	}
+	t.rows = append(t.rows, row)
+}
+
+func cellText(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return formatDate(v)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatDate(*v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// formatDate shows midnight UTC, which is how the API stores whole days, as
+// a plain date
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.UTC().Format("15:04:05") == "00:00:00" {
+		return t.UTC().Format("2006-01-02")
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func (a *app) render(t *table) error {
+	switch a.output {
+	case "json":
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t.data)
+	case "csv":
+		w := csv.NewWriter(a.out)
+		w.Write(t.headers)
+		w.WriteAll(t.rows)
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		for i, cell := range row {
+			// Keep multi-line comments on one row
+			row[i] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// message prints a confirmation for table output and the affected value for
+// JSON output, so scripts get the result and people get a sentence
+func (a *app) message(data interface{}, format string, args ...interface{}) error {
+	if a.output == "json" {
+		return a.render(newTable(data))
+	}
+	if a.output == "csv" {
+		return nil
+	}
+	_, err := fmt.Fprintf(a.out, format+"\n", args...)
+	return err
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// profile is one server to talk to
+type profile struct {
+	Server string `json:"server"`
+	APIKey string `json:"api_key"`
+	Output string `json:"output,omitempty"`
+}
+
+// profileFile is the JSON profile file:
+//
+//	{
+//	  "default": "prod",
+//	  "profiles": {
+//	    "prod": {"server": "https://tracker.example.mil", "api_key": "aoc_..."},
+//	    "local": {"server": "http://localhost:8081", "api_key": "aoc_...", "output": "json"}
+//	  }
+//	}
+type profileFile struct {
+	Default  string             `json:"default"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+func profilePath() (string, error) {
+	if path := os.Getenv("EXERCISECTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding the profile file: %w", err)
+	}
+	return filepath.Join(dir, "exercisectl", "profiles.json"), nil
+}
+
+// loadProfiles reads the profile file. A missing file is an empty one.
+func loadProfiles() (profileFile, error) {
+	pf := profileFile{Profiles: map[string]profile{}}
+	path, err := profilePath()
+	if err != nil {
+		return pf, err
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pf, nil
+	}
+	if err != nil {
+		return pf, err
+	}
+	if err := json.Unmarshal(b, &pf); err != nil {
+		return pf, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if pf.Profiles == nil {
+		pf.Profiles = map[string]profile{}
+	}
+	return pf, nil
+}
+
+// save writes the profile file readable only by the user, since it holds
+// API keys
+func (pf profileFile) save() error {
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(pf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o600)
+}
+
+// find returns the named profile, or the default one when name is empty. No
+// profiles at all is not an error, so flags alone can configure a command.
+func (pf profileFile) find(name string) (profile, error) {
+	if name == "" {
+		name = pf.Default
+	}
+	if name == "" && len(pf.Profiles) == 1 {
+		for _, p := range pf.Profiles {
+			return p, nil
+		}
+	}
+	if name == "" {
+		return profile{}, nil
+	}
+	p, ok := pf.Profiles[name]
+	if !ok {
+		return p, fmt.Errorf("no profile named %q", name)
+	}
+	return p, nil
+}
+
+func setProfile(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	makeDefault := fs.Bool("default", false, "make this the default profile")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: exercisectl profile set NAME --server URL --api-key KEY")
+	}
+
+	pf, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	p := pf.Profiles[args[0]]
+	if a.server != "" {
+		p.Server = a.server
+	}
+	if a.apiKey != "" {
+		p.APIKey = a.apiKey
+	}
+	if a.output != "" {
+		p.Output = a.output
+	}
+	if p.Server == "" {
+		return fmt.Errorf("--server is required for a new profile")
+	}
+	pf.Profiles[args[0]] = p
+	if *makeDefault || len(pf.Profiles) == 1 {
+		pf.Default = args[0]
+	}
+	return pf.save()
+}
+
+func listProfiles(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if a.output == "" {
+		a.output = "table"
+	}
+
+	pf, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(pf.Profiles))
+	for name := range pf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type listed struct {
+		Name    string `json:"name"`
+		Server  string `json:"server"`
+		Default bool   `json:"default"`
+	}
+	var data []listed
+	t := newTable(&data, "NAME", "SERVER", "DEFAULT")
+	for _, name := range names {
+		data = append(data, listed{name, pf.Profiles[name].Server, name == pf.Default})
+		t.add(name, pf.Profiles[name].Server, name == pf.Default)
+	}
+	return a.render(t)
+}
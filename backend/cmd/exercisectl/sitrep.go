@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"srd-calendar-project/backend/client"
+	"time"
+)
+
+// showSitrep prints an exercise's situation report rendered with its
+// template, or the report itself with -o json
+func showSitrep(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	date := fs.String("date", "", "day to report on; defaults to today")
+	html := fs.Bool("html", false, "render as HTML instead of Markdown")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	var day time.Time
+	if *date != "" {
+		if day, err = parseDate(*date); err != nil {
+			return fmt.Errorf("--date: %w", err)
+		}
+	}
+
+	if a.output == "json" {
+		report, err := a.client.GetSitrep(ctx, ex.ID, day)
+		if err != nil {
+			return err
+		}
+		return a.render(newTable(report))
+	}
+	format := "markdown"
+	if *html {
+		format = "html"
+	}
+	out, err := a.client.RenderSitrep(ctx, ex.ID, day, format)
+	if err != nil {
+		return err
+	}
+	_, err = a.out.Write(out)
+	return err
+}
+
+// showSitrepTemplate prints the template an exercise's situation reports are
+// rendered with
+func showSitrepTemplate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	html := fs.Bool("html", false, "show the HTML template instead of the Markdown one")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := a.client.GetSitrepTemplate(ctx, ex.ID)
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.render(newTable(tmpl))
+	}
+	text := tmpl.Markdown
+	if *html {
+		text = tmpl.HTML
+	}
+	_, err = fmt.Fprint(a.out, text)
+	return err
+}
+
+// readTemplate returns the contents of file, or current when no file is given
+func readTemplate(file, current string) (string, error) {
+	if file == "" {
+		return current, nil
+	}
+	b, err := os.ReadFile(file)
+	return string(b), err
+}
+
+// setSitrepTemplate replaces an exercise's situation report templates with
+// the contents of files. A template not given is kept; --reset goes back to
+// the built-in ones.
+func setSitrepTemplate(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	markdownFile := fs.String("markdown", "", "file holding the Markdown template")
+	htmlFile := fs.String("html", "", "file holding the HTML template")
+	reset := fs.Bool("reset", false, "go back to the built-in templates")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *reset == (*markdownFile != "" || *htmlFile != "") {
+		return fmt.Errorf("give --markdown, --html or both, or --reset")
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	tmpl := client.SitrepTemplate{ExerciseID: ex.ID}
+	if !*reset {
+		if tmpl, err = a.client.GetSitrepTemplate(ctx, ex.ID); err != nil {
+			return err
+		}
+		if tmpl.Markdown, err = readTemplate(*markdownFile, tmpl.Markdown); err != nil {
+			return err
+		}
+		if tmpl.HTML, err = readTemplate(*htmlFile, tmpl.HTML); err != nil {
+			return err
+		}
+	}
+
+	saved, err := a.client.SetSitrepTemplate(ctx, tmpl)
+	if err != nil {
+		return err
+	}
+	return a.message(saved, "Saved the SITREP templates of %s", ex.Name)
+}
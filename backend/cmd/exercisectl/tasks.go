@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"srd-calendar-project/backend/client"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringList collects a flag given more than once
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func parseID(s, what string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s ID %q", what, s)
+	}
+	return id, nil
+}
+
+func listEvents(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	upcoming := fs.Bool("upcoming", false, "only events that have not ended")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	events, err := a.client.ListEvents(ctx, ex.ID)
+	if err != nil {
+		return err
+	}
+	shown := []client.Event{}
+	for _, e := range events {
+		if !*upcoming || !e.EndDate.Before(today()) {
+			shown = append(shown, e)
+		}
+	}
+
+	t := newTable(shown, "ID", "NAME", "TYPE", "START", "END", "STATUS", "PRIORITY", "POC", "LOCATION")
+	for _, e := range shown {
+		t.add(e.ID, e.Name, e.Type, e.StartDate, e.EndDate, e.Status, e.Priority, e.POC, e.Location)
+	}
+	return a.render(t)
+}
+
+func createEvent(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	start := fs.String("start", "", "start date")
+	end := fs.String("end", "", "end date; defaults to the start date")
+	eventType := fs.String("type", "other", "milestone, phase, meeting, training, deployment or other")
+	priority := fs.String("priority", "medium", "high, medium or low")
+	poc := fs.String("poc", "", "POC")
+	location := fs.String("location", "", "location")
+	description := fs.String("description", "", "description")
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	event := client.Event{
+		ExerciseID:  ex.ID,
+		Name:        args[1],
+		Type:        *eventType,
+		Priority:    *priority,
+		POC:         *poc,
+		Location:    *location,
+		Description: *description,
+		Status:      "planned",
+	}
+	if event.StartDate, err = parseDate(*start); err != nil {
+		return fmt.Errorf("--start: %w", err)
+	}
+	event.EndDate = event.StartDate
+	if *end != "" {
+		if event.EndDate, err = parseDate(*end); err != nil {
+			return fmt.Errorf("--end: %w", err)
+		}
+	}
+
+	created, err := a.client.CreateEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	return a.message(created, "Created event %d %q in %s", created.ID, created.Name, ex.Name)
+}
+
+func deleteEvent(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(args[0], "event")
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteEvent(ctx, id); err != nil {
+		return err
+	}
+	return a.message(map[string]int{"id": id}, "Deleted event %d", id)
+}
+
+// taskTable lists tasks with the exercise each belongs to
+func taskTable(tasks []client.Task, exerciseNames map[int]string) *table {
+	t := newTable(tasks, "ID", "EXERCISE", "TASK", "STATUS", "DUE", "TEAMS", "ASSIGNED TO")
+	for _, task := range tasks {
+		var teams []string
+		for _, team := range task.Teams {
+			teams = append(teams, team.Name)
+		}
+		t.add(task.ID, exerciseNames[task.ExerciseID], task.Name, task.Status, task.DueDate, teams, task.AssignedTo)
+	}
+	return t
+}
+
+func listTasks(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	status := fs.String("status", "", "only tasks with this status: pending, in-progress or completed")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	tasks, err := a.client.ListTasks(ctx, ex.ID)
+	if err != nil {
+		return err
+	}
+	shown := []client.Task{}
+	for _, task := range tasks {
+		if *status == "" || task.Status == *status {
+			shown = append(shown, task)
+		}
+	}
+	return a.render(taskTable(shown, map[int]string{ex.ID: ex.Name}))
+}
+
+// overdueTasks lists incomplete tasks past their due date, in one exercise
+// or in every exercise running today
+func overdueTasks(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	exerciseRef := fs.String("exercise", "", "exercise ID or name; defaults to every active exercise")
+	if _, err := a.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var exercises []client.Exercise
+	if *exerciseRef != "" {
+		ex, err := a.findExercise(ctx, *exerciseRef)
+		if err != nil {
+			return err
+		}
+		exercises = []client.Exercise{ex}
+	} else {
+		all, err := a.client.ListExercises(ctx, client.ExerciseFilter{})
+		if err != nil {
+			return err
+		}
+		for _, ex := range all {
+			if active(ex, today()) {
+				exercises = append(exercises, ex)
+			}
+		}
+	}
+
+	now := time.Now()
+	overdue := []client.Task{}
+	names := map[int]string{}
+	for _, ex := range exercises {
+		names[ex.ID] = ex.Name
+		tasks, err := a.client.ListTasks(ctx, ex.ID)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if task.Status != "completed" && task.DueDate != nil && task.DueDate.Before(now) {
+				overdue = append(overdue, task)
+			}
+		}
+	}
+	return a.render(taskTable(overdue, names))
+}
+
+func createTask(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	due := fs.String("due", "", "due date")
+	description := fs.String("description", "", "description")
+	assignedTo := fs.String("assigned-to", "", "person responsible")
+	var teamRefs stringList
+	fs.Var(&teamRefs, "team", "DIVISION/TEAM to assign; repeat for several teams")
+	args, err := a.parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := a.findExercise(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	task := client.Task{
+		ExerciseID:  ex.ID,
+		Name:        args[1],
+		Description: *description,
+		AssignedTo:  *assignedTo,
+		Status:      "pending",
+	}
+	if *due != "" {
+		dueDate, err := parseDate(*due)
+		if err != nil {
+			return fmt.Errorf("--due: %w", err)
+		}
+		task.DueDate = &dueDate
+	}
+	for _, ref := range teamRefs {
+		divisionName, teamName, ok := strings.Cut(ref, "/")
+		if !ok {
+			return fmt.Errorf("--team %q: use DIVISION/TEAM", ref)
+		}
+		division, err := findDivision(ex, divisionName)
+		if err != nil {
+			return err
+		}
+		team, err := findTeam(division, teamName)
+		if err != nil {
+			return err
+		}
+		task.TeamIDs = append(task.TeamIDs, team.ID)
+	}
+
+	created, err := a.client.CreateTask(ctx, task)
+	if err != nil {
+		return err
+	}
+	return a.message(created, "Created task %d %q in %s", created.ID, created.Name, ex.Name)
+}
+
+func completeTask(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	exerciseRef := fs.String("exercise", "", "exercise ID or name, to skip searching every exercise")
+	args, err := a.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(args[0], "task")
+	if err != nil {
+		return err
+	}
+
+	task, err := a.findTask(ctx, *exerciseRef, id)
+	if err != nil {
+		return err
+	}
+	updated, err := a.client.PatchTask(ctx, task.ID, client.Patch{"status": "completed"})
+	if err != nil {
+		return err
+	}
+	return a.message(updated, "Completed task %d %q", updated.ID, updated.Name)
+}
+
+// findTask finds a task by ID. The API lists tasks per exercise, so without
+// an exercise every exercise is searched.
+func (a *app) findTask(ctx context.Context, exerciseRef string, id int) (client.Task, error) {
+	var exercises []client.Exercise
+	if exerciseRef != "" {
+		ex, err := a.findExercise(ctx, exerciseRef)
+		if err != nil {
+			return client.Task{}, err
+		}
+		exercises = []client.Exercise{ex}
+	} else {
+		var err error
+		if exercises, err = a.client.ListExercises(ctx, client.ExerciseFilter{}); err != nil {
+			return client.Task{}, err
+		}
+	}
+
+	for _, ex := range exercises {
+		tasks, err := a.client.ListTasks(ctx, ex.ID)
+		if err != nil {
+			return client.Task{}, err
+		}
+		for _, task := range tasks {
+			if task.ID == id {
+				return task, nil
+			}
+		}
+	}
+	return client.Task{}, fmt.Errorf("no task with ID %d", id)
+}
+
+func deleteTask(ctx context.Context, a *app, args []string) error {
+	args, err := a.parse(a.flags(), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(args[0], "task")
+	if err != nil {
+		return err
+	}
+	if err := a.client.DeleteTask(ctx, id); err != nil {
+		return err
+	}
+	return a.message(map[string]int{"id": id}, "Deleted task %d", id)
+}
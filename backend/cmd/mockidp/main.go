@@ -0,0 +1,284 @@
+// Command mockidp is a minimal OpenID Connect provider for exercising the
+// single sign-on flow locally. It serves a discovery document and signing
+// keys, shows a form to choose who to sign in as, and issues RS256 ID tokens
+// after checking the PKCE verifier. It keeps everything in memory and must
+// never be used outside development.
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyID = "mock-key"
+
+type authorization struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	claims      map[string]interface{}
+	expiresAt   time.Time
+}
+
+var (
+	issuer       string
+	clientID     string
+	clientSecret string
+	signingKey   *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes = map[string]authorization{}
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Mock identity provider</title></head>
+<body style="font-family: sans-serif; max-width: 420px; margin: 40px auto">
+<h2>Mock identity provider</h2>
+<p>Choose who to sign in as.</p>
+<form method="post">
+{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{end}}
+<p><label>Subject<br><input name="sub" value="{{.Username}}"></label></p>
+<p><label>Username<br><input name="preferred_username" value="{{.Username}}"></label></p>
+<p><label>Name<br><input name="name" value="{{.Name}}"></label></p>
+<p><label>Email<br><input name="email" value="{{.Email}}"></label></p>
+<p><label>Groups (comma-separated)<br><input name="groups" value="{{.Groups}}"></label></p>
+<p><button type="submit">Sign in</button></p>
+</form>
+</body>
+</html>`))
+
+func main() {
+	addr := flag.String("addr", ":9998", "listen address")
+	flag.StringVar(&issuer, "issuer", "http://localhost:9998", "issuer URL advertised in the discovery document")
+	flag.StringVar(&clientID, "client-id", "aoc-event-tracker", "client ID the backend is configured with")
+	flag.StringVar(&clientSecret, "client-secret", "", "client secret; empty accepts public clients")
+	username := flag.String("user", "jdoe", "username pre-filled on the sign-in form")
+	groups := flag.String("groups", "aoc-planners", "groups pre-filled on the sign-in form")
+	flag.Parse()
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	var err error
+	if signingKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		log.Fatalf("generating signing key: %v", err)
+	}
+
+	http.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/authorize",
+			"token_endpoint":                        issuer + "/token",
+			"jwks_uri":                              issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	})
+	http.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := signingKey.PublicKey
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": keyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			}},
+		})
+	})
+	http.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		handleAuthorize(w, r, *username, *groups)
+	})
+	http.HandleFunc("/token", handleToken)
+
+	log.Printf("Mock identity provider %s listening on %s (client ID %q)", issuer, *addr, clientID)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func handleAuthorize(w http.ResponseWriter, r *http.Request, username, groups string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	params := map[string]string{}
+	for _, name := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce",
+		"code_challenge", "code_challenge_method"} {
+		params[name] = r.Form.Get(name)
+	}
+
+	if params["client_id"] != clientID || params["response_type"] != "code" {
+		http.Error(w, "unknown client or unsupported response type", http.StatusBadRequest)
+		return
+	}
+	if params["code_challenge_method"] != "S256" || params["code_challenge"] == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(params["redirect_uri"])
+	if err != nil || redirect.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		name := username
+		if name != "" {
+			name = strings.ToUpper(name[:1]) + name[1:]
+		}
+		loginPage.Execute(w, map[string]interface{}{
+			"Params":   params,
+			"Username": username,
+			"Name":     name,
+			"Email":    username + "@example.mil",
+			"Groups":   groups,
+		})
+		return
+	}
+
+	claims := map[string]interface{}{
+		"sub":                r.Form.Get("sub"),
+		"preferred_username": r.Form.Get("preferred_username"),
+		"name":               r.Form.Get("name"),
+		"email":              r.Form.Get("email"),
+	}
+	groupList := []string{}
+	for _, g := range strings.Split(r.Form.Get("groups"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groupList = append(groupList, g)
+		}
+	}
+	claims["groups"] = groupList
+
+	code := randomString()
+	mu.Lock()
+	codes[code] = authorization{
+		clientID:    params["client_id"],
+		redirectURI: params["redirect_uri"],
+		challenge:   params["code_challenge"],
+		nonce:       params["nonce"],
+		claims:      claims,
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	mu.Unlock()
+
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", params["state"])
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	id, secret, hasBasic := r.BasicAuth()
+	if hasBasic {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+	}
+	if id != clientID || (clientSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(clientSecret)) != 1) {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	mu.Lock()
+	auth, found := codes[r.Form.Get("code")]
+	delete(codes, r.Form.Get("code"))
+	mu.Unlock()
+
+	if r.Form.Get("grant_type") != "authorization_code" || !found || time.Now().After(auth.expiresAt) ||
+		auth.clientID != id || auth.redirectURI != r.Form.Get("redirect_uri") {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   issuer,
+		"aud":   clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+
+	idToken, err := sign(claims)
+	if err != nil {
+		http.Error(w, "signing failed", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func sign(claims map[string]interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, signingKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -4,4 +4,4 @@ go 1.24.0
 
 require github.com/go-chi/chi/v5 v5.2.3
 
-require github.com/lib/pq v1.10.9 // indirect
+require github.com/lib/pq v1.10.9
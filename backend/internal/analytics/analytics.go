@@ -0,0 +1,319 @@
+// Package analytics measures how statuses held up over time, from the status
+// history recorded each time a team's status changes.
+//
+// A team's time in each status is taken straight from its history. A
+// division's or exercise's time is that of its readiness rollup (see package
+// readiness) over the same history, using each team's current readiness
+// weight and counting a team only from when its history starts. A status
+// counts only inside the status window it was reported with, from the
+// window's start to the end of the day of its end; outside it the team
+// counts as green, as in the rollup.
+//
+// A red episode starts when a team turns red and ends when it next turns
+// green, so yellow on the way back counts toward the recovery. An episode
+// belongs to the range it starts in, though its recovery may end after it.
+// Division and exercise recovery pool the episodes of their teams.
+//
+// Daily readiness is the share of the day's team time, weighted by readiness
+// weight, spent in each status.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
+	"time"
+)
+
+// segment is a stretch of time spent in one status
+type segment struct {
+	start, end time.Time
+	status     string
+}
+
+// timeline turns a team's periods into segments, ending the open period at
+// now. Time outside the status window a period was recorded with counts as
+// green, as in the readiness rollup.
+func timeline(periods []models.StatusPeriod, now time.Time) []segment {
+	segments := []segment{}
+	add := func(start, end time.Time, status string) {
+		if !end.After(start) {
+			return
+		}
+		if last := len(segments) - 1; last >= 0 && segments[last].status == status && segments[last].end.Equal(start) {
+			segments[last].end = end
+			return
+		}
+		segments = append(segments, segment{start: start, end: end, status: status})
+	}
+	for _, period := range periods {
+		end := now
+		if period.EndedAt != nil {
+			end = *period.EndedAt
+		}
+		opens, closes := period.StartedAt, end
+		if period.StatusStart.After(opens) {
+			opens = period.StatusStart
+		}
+		if !period.StatusEnd.IsZero() {
+			if windowEnd := readiness.WindowEnd(period.StatusEnd); windowEnd.Before(closes) {
+				closes = windowEnd
+			}
+		}
+		if !closes.After(opens) {
+			add(period.StartedAt, end, "green")
+			continue
+		}
+		add(period.StartedAt, opens, "green")
+		add(opens, closes, period.Status)
+		add(closes, end, "green")
+	}
+	return segments
+}
+
+// statusAt returns the status a timeline has at t, if it covers t
+func statusAt(segments []segment, t time.Time) (string, bool) {
+	for _, s := range segments {
+		if !s.start.After(t) && s.end.After(t) {
+			return s.status, true
+		}
+	}
+	return "", false
+}
+
+// rollup returns the readiness rollup of teams over [from, to) as segments.
+// Stretches where no team has history are left out.
+func rollup(teams []models.Team, timelines map[int][]segment, from, to time.Time) []segment {
+	bounds := []time.Time{from, to}
+	for _, team := range teams {
+		for _, s := range timelines[team.ID] {
+			for _, t := range []time.Time{s.start, s.end} {
+				if t.After(from) && t.Before(to) {
+					bounds = append(bounds, t)
+				}
+			}
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	segments := []segment{}
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		if !end.After(start) {
+			continue
+		}
+		var counted []models.Team
+		for _, team := range teams {
+			if status, ok := statusAt(timelines[team.ID], start); ok {
+				counted = append(counted, models.Team{Status: status, ReadinessWeight: team.ReadinessWeight})
+			}
+		}
+		if len(counted) == 0 {
+			continue
+		}
+		segments = append(segments, segment{start: start, end: end, status: readiness.Teams(counted, start).Status})
+	}
+	return segments
+}
+
+// overlap returns how much of [from, to) a segment covers
+func overlap(s segment, from, to time.Time) time.Duration {
+	start, end := s.start, s.end
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+func percent(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(part*1000/total) / 10
+}
+
+// statusTime totals the time segments spend in each status over [from, to)
+func statusTime(segments []segment, from, to time.Time) models.StatusTime {
+	spent := map[string]time.Duration{}
+	var tracked time.Duration
+	for _, s := range segments {
+		d := overlap(s, from, to)
+		spent[s.status] += d
+		tracked += d
+	}
+
+	return models.StatusTime{
+		TrackedHours:  hours(tracked),
+		GreenHours:    hours(spent["green"]),
+		YellowHours:   hours(spent["yellow"]),
+		RedHours:      hours(spent["red"]),
+		UnknownHours:  hours(spent[readiness.Unknown]),
+		GreenPercent:  percent(float64(spent["green"]), float64(tracked)),
+		YellowPercent: percent(float64(spent["yellow"]), float64(tracked)),
+		RedPercent:    percent(float64(spent["red"]), float64(tracked)),
+	}
+}
+
+// recovery measures the red episodes of the given team timelines that start
+// in [from, to)
+func recovery(timelines [][]segment, from, to time.Time) models.Recovery {
+	var result models.Recovery
+	var total time.Duration
+	for _, segments := range timelines {
+		// A team that goes red again before turning green is still in the
+		// same episode
+		inEpisode := false
+		for i, s := range segments {
+			if s.status == "green" {
+				inEpisode = false
+			}
+			if s.status != "red" || inEpisode {
+				continue
+			}
+			inEpisode = true
+			if s.start.Before(from) || !s.start.Before(to) {
+				continue
+			}
+			result.RedEpisodes++
+			for _, later := range segments[i+1:] {
+				if later.status == "green" {
+					result.Recovered++
+					total += later.start.Sub(s.start)
+					break
+				}
+			}
+		}
+	}
+	if result.Recovered > 0 {
+		mttr := hours(total / time.Duration(result.Recovered))
+		result.MTTRHours = &mttr
+	}
+	return result
+}
+
+// dailyShares returns the weighted share of team time in each status over
+// [from, to) as green, yellow and red percentages. Teams with weight 0 are
+// left out.
+func dailyShares(teams []models.Team, timelines map[int][]segment, from, to time.Time) (float64, float64, float64) {
+	spent := map[string]float64{}
+	total := 0.0
+	for _, team := range teams {
+		if team.ReadinessWeight <= 0 {
+			continue
+		}
+		for _, s := range timelines[team.ID] {
+			d := float64(overlap(s, from, to)) * float64(team.ReadinessWeight)
+			spent[s.status] += d
+			total += d
+		}
+	}
+	return percent(spent["green"], total), percent(spent["yellow"], total), percent(spent["red"], total)
+}
+
+// Range returns the days an exercise's analytics cover by default: from its
+// first day through its last
+func Range(exercise models.Exercise) (time.Time, time.Time) {
+	return readiness.Day(exercise.StartDate), readiness.Day(exercise.EndDate).AddDate(0, 0, 1)
+}
+
+// Exercise reports how an exercise's statuses held up over [from, to), which
+// are midnights. Time after now is not counted.
+func Exercise(exercise models.Exercise, periods []models.StatusPeriod, from, to, now time.Time) models.ExerciseAnalytics {
+	result := models.ExerciseAnalytics{
+		ExerciseID:  exercise.ID,
+		Name:        exercise.Name,
+		From:        from.Format("2006-01-02"),
+		To:          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		GeneratedAt: now,
+		Divisions:   []models.DivisionAnalytics{},
+		Daily:       []models.DailyReadiness{},
+	}
+	end := to
+	if end.After(now) {
+		end = now
+	}
+	if end.Before(from) {
+		end = from
+	}
+
+	byTeam := map[int][]models.StatusPeriod{}
+	for _, period := range periods {
+		byTeam[period.TeamID] = append(byTeam[period.TeamID], period)
+	}
+	timelines := map[int][]segment{}
+	var teams []models.Team
+	var all [][]segment
+	for _, division := range exercise.Divisions {
+		for _, team := range division.Teams {
+			timelines[team.ID] = timeline(byTeam[team.ID], now)
+			teams = append(teams, team)
+			all = append(all, timelines[team.ID])
+		}
+	}
+
+	result.Time = statusTime(rollup(teams, timelines, from, end), from, end)
+	result.Recovery = recovery(all, from, end)
+
+	for _, division := range exercise.Divisions {
+		var divisionTimelines [][]segment
+		analytics := models.DivisionAnalytics{
+			DivisionID: division.ID,
+			Name:       division.Name,
+			Time:       statusTime(rollup(division.Teams, timelines, from, end), from, end),
+			Teams:      []models.TeamAnalytics{},
+		}
+		for _, team := range division.Teams {
+			divisionTimelines = append(divisionTimelines, timelines[team.ID])
+			analytics.Teams = append(analytics.Teams, models.TeamAnalytics{
+				TeamID:   team.ID,
+				Name:     team.Name,
+				Weight:   team.ReadinessWeight,
+				Time:     statusTime(timelines[team.ID], from, end),
+				Recovery: recovery([][]segment{timelines[team.ID]}, from, end),
+			})
+		}
+		analytics.Recovery = recovery(divisionTimelines, from, end)
+		result.Divisions = append(result.Divisions, analytics)
+	}
+
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		daily := models.DailyReadiness{Date: day.Format("2006-01-02"), Divisions: []models.DivisionDailyReadiness{}}
+		daily.GreenPercent, daily.YellowPercent, daily.RedPercent = dailyShares(teams, timelines, day, dayEnd)
+		for _, division := range exercise.Divisions {
+			share := models.DivisionDailyReadiness{DivisionID: division.ID, Name: division.Name}
+			share.GreenPercent, share.YellowPercent, share.RedPercent = dailyShares(division.Teams, timelines, day, dayEnd)
+			daily.Divisions = append(daily.Divisions, share)
+		}
+		result.Daily = append(result.Daily, daily)
+	}
+	return result
+}
+
+// Trend summarizes an exercise over its whole run, up to now
+func Trend(exercise models.Exercise, periods []models.StatusPeriod, now time.Time) models.ExerciseTrend {
+	from, to := Range(exercise)
+	analytics := Exercise(exercise, periods, from, to, now)
+	return models.ExerciseTrend{
+		ExerciseID: exercise.ID,
+		Name:       exercise.Name,
+		StartDate:  exercise.StartDate,
+		EndDate:    exercise.EndDate,
+		Time:       analytics.Time,
+		Recovery:   analytics.Recovery,
+	}
+}
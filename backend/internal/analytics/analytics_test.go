@@ -0,0 +1,219 @@
+package analytics
+
+import (
+	"reflect"
+	"srd-calendar-project/backend/internal/models"
+	"testing"
+	"time"
+)
+
+var day1 = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns the time h hours into day1
+func at(h int) time.Time {
+	return day1.Add(time.Duration(h) * time.Hour)
+}
+
+func closed(teamID int, status string, start, end int) models.StatusPeriod {
+	ended := at(end)
+	return models.StatusPeriod{TeamID: teamID, Status: status, StartedAt: at(start), EndedAt: &ended}
+}
+
+func open(teamID int, status string, start int) models.StatusPeriod {
+	return models.StatusPeriod{TeamID: teamID, Status: status, StartedAt: at(start)}
+}
+
+// fixture is two teams over a day and a half, up to now at 36h:
+//
+//	team 1, weight 1: green 0-6h, red 6-10h, yellow 10-12h, then green
+//	team 2, weight 2: history from 12h; green until 30h, then red
+func fixture() ([]models.Team, map[int][]segment, time.Time) {
+	now := at(36)
+	teams := []models.Team{{ID: 1, ReadinessWeight: 1}, {ID: 2, ReadinessWeight: 2}}
+	timelines := map[int][]segment{
+		1: timeline([]models.StatusPeriod{
+			closed(1, "green", 0, 6), closed(1, "red", 6, 10), closed(1, "yellow", 10, 12), open(1, "green", 12),
+		}, now),
+		2: timeline([]models.StatusPeriod{closed(2, "green", 12, 30), open(2, "red", 30)}, now),
+	}
+	return teams, timelines, now
+}
+
+func windowed(p models.StatusPeriod, start, end time.Time) models.StatusPeriod {
+	p.StatusStart, p.StatusEnd = start, end
+	return p
+}
+
+func TestTimeline(t *testing.T) {
+	now := at(30)
+	tests := []struct {
+		name    string
+		periods []models.StatusPeriod
+		want    []segment
+	}{
+		{"open period ends at now, empty period dropped",
+			[]models.StatusPeriod{closed(1, "green", 0, 6), closed(1, "red", 6, 6), open(1, "yellow", 6)},
+			[]segment{{at(0), at(6), "green"}, {at(6), at(30), "yellow"}}},
+		{"future status_start: green until the window opens",
+			[]models.StatusPeriod{windowed(open(1, "red", 0), at(6), time.Time{})},
+			[]segment{{at(0), at(6), "green"}, {at(6), at(30), "red"}}},
+		{"window never opened",
+			[]models.StatusPeriod{windowed(closed(1, "red", 0, 10), at(12), time.Time{}), open(1, "yellow", 10)},
+			[]segment{{at(0), at(10), "green"}, {at(10), at(30), "yellow"}}},
+		{"date-only status_end lasts through its day",
+			[]models.StatusPeriod{windowed(open(1, "red", 2), time.Time{}, day1)},
+			[]segment{{at(2), at(24), "red"}, {at(24), at(30), "green"}}},
+		{"timestamped status_end, lapsed green merges with the next green",
+			[]models.StatusPeriod{windowed(closed(1, "red", 0, 8), time.Time{}, at(4)), open(1, "green", 8)},
+			[]segment{{at(0), at(4), "red"}, {at(4), at(30), "green"}}},
+		{"window changed, same status merges",
+			[]models.StatusPeriod{closed(1, "red", 0, 5), windowed(open(1, "red", 5), at(2), time.Time{})},
+			[]segment{{at(0), at(30), "red"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeline(tt.periods, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("timeline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedHoursFollowTheWindow(t *testing.T) {
+	// Reported red at 0h for a window starting at 20h: only 4h count as red
+	segments := timeline([]models.StatusPeriod{windowed(open(1, "red", 0), at(20), time.Time{})}, at(24))
+	got := statusTime(segments, at(0), at(24))
+	if got.RedHours != 4 || got.GreenHours != 20 {
+		t.Errorf("statusTime() = %+v, want 4 red hours and 20 green", got)
+	}
+	if rec := recovery([][]segment{segments}, at(0), at(24)); rec.RedEpisodes != 1 || rec.Recovered != 0 {
+		t.Errorf("recovery() = %+v, want one open episode", rec)
+	}
+}
+
+func TestStatusTime(t *testing.T) {
+	_, timelines, _ := fixture()
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     models.StatusTime
+	}{
+		{"whole history, open period up to now", at(0), at(36), models.StatusTime{
+			TrackedHours: 36, GreenHours: 30, YellowHours: 2, RedHours: 4,
+			GreenPercent: 83.3, YellowPercent: 5.6, RedPercent: 11.1,
+		}},
+		{"range ends halfway through red", at(0), at(8), models.StatusTime{
+			TrackedHours: 8, GreenHours: 6, RedHours: 2, GreenPercent: 75, RedPercent: 25,
+		}},
+		{"range starts halfway through red", at(8), at(12), models.StatusTime{
+			TrackedHours: 4, YellowHours: 2, RedHours: 2, YellowPercent: 50, RedPercent: 50,
+		}},
+		{"empty range", at(5), at(5), models.StatusTime{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusTime(timelines[1], tt.from, tt.to); got != tt.want {
+				t.Errorf("statusTime() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollup(t *testing.T) {
+	teams, timelines, now := fixture()
+
+	got := rollup(teams, timelines, at(-6), now)
+	want := []segment{
+		{at(0), at(6), "green"},
+		{at(6), at(10), "red"},
+		{at(10), at(12), "yellow"},
+		{at(12), at(30), "green"},
+		{at(30), at(36), "red"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollup() = %v, want %v", got, want)
+	}
+
+	// A range cutting periods in half starts and ends the rollup with them
+	got = rollup(teams, timelines, at(8), at(33))
+	want = []segment{
+		{at(8), at(10), "red"},
+		{at(10), at(12), "yellow"},
+		{at(12), at(30), "green"},
+		{at(30), at(33), "red"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollup() cut at 8h and 33h = %v, want %v", got, want)
+	}
+
+	// Weight 0 teams are left out: the stretch only they cover is unknown
+	teams[0].ReadinessWeight = 0
+	got = rollup(teams, timelines, at(24), at(36))
+	want = []segment{{at(24), at(30), "green"}, {at(30), at(36), "red"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollup() with team 1 at weight 0 = %v, want %v", got, want)
+	}
+	got = rollup(teams, timelines, at(0), at(6))
+	want = []segment{{at(0), at(6), "unknown"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rollup() covered only by weight 0 = %v, want %v", got, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	_, timelines, _ := fixture()
+	all := [][]segment{timelines[1], timelines[2]}
+	relapse := timeline([]models.StatusPeriod{
+		closed(3, "red", 0, 2), closed(3, "yellow", 2, 3), closed(3, "red", 3, 5), open(3, "green", 5),
+	}, at(36))
+	mttr := func(h float64) *float64 { return &h }
+
+	tests := []struct {
+		name      string
+		timelines [][]segment
+		from, to  time.Time
+		want      models.Recovery
+	}{
+		{"one recovered, one still red", all, at(0), at(36),
+			models.Recovery{RedEpisodes: 2, Recovered: 1, MTTRHours: mttr(6)}},
+		{"episode started before the range is left out", all, at(8), at(36),
+			models.Recovery{RedEpisodes: 1}},
+		{"episode counts in the range it starts in", all, at(0), at(8),
+			models.Recovery{RedEpisodes: 1, Recovered: 1, MTTRHours: mttr(6)}},
+		{"red again before green is the same episode", [][]segment{relapse}, at(0), at(36),
+			models.Recovery{RedEpisodes: 1, Recovered: 1, MTTRHours: mttr(5)}},
+		{"no red", [][]segment{timelines[1][:1]}, at(0), at(36), models.Recovery{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recovery(tt.timelines, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recovery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyShares(t *testing.T) {
+	teams, timelines, _ := fixture()
+	tests := []struct {
+		name               string
+		teams              []models.Team
+		from, to           time.Time
+		green, yellow, red float64
+	}{
+		// team 1: 18h green, 2h yellow, 4h red; team 2: 12h green at weight 2
+		{"first day", teams, at(0), at(24), 87.5, 4.2, 8.3},
+		// team 1: 12h green; team 2: 6h green and 6h red at weight 2, cut at now
+		{"day cut at now", teams, at(24), at(36), 66.7, 0, 33.3},
+		{"weight 0 left out", []models.Team{teams[0], {ID: 2}}, at(0), at(24), 75, 8.3, 16.7},
+		{"no history", teams, at(-24), at(0), 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, y, r := dailyShares(tt.teams, timelines, tt.from, tt.to)
+			if g != tt.green || y != tt.yellow || r != tt.red {
+				t.Errorf("dailyShares() = %v/%v/%v, want %v/%v/%v", g, y, r, tt.green, tt.yellow, tt.red)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+)
+
+// SystemActor is recorded for changes made without a signed-in user
+const SystemActor = "system"
+
+// Record is a changes.Sink that writes every change to the audit log along
+// with the user who made it
+func Record(ctx context.Context, q changes.Execer, change changes.Change) error {
+	data, err := json.Marshal(change.Data)
+	if err != nil {
+		return err
+	}
+
+	var actorID interface{}
+	actorUsername := SystemActor
+	if change.Actor != nil {
+		actorID = change.Actor.ID
+		actorUsername = change.Actor.Username
+	}
+
+	var exerciseID interface{}
+	if change.ExerciseID != 0 {
+		exerciseID = change.ExerciseID
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, actor_username, action, exercise_id, payload)
+		VALUES ($1, $2, $3, $4, $5)`,
+		actorID, actorUsername, change.Type, exerciseID, string(data))
+	return err
+}
+
+// Filter narrows the entries returned by GetEntries. Zero values match all.
+type Filter struct {
+	ExerciseID int
+	ActorID    int
+	Action     string
+	BeforeID   int // Only entries older than this one, for paging
+	Limit      int
+}
+
+// GetEntries returns audit log entries, newest first
+func GetEntries(ctx context.Context, filter Filter) []models.AuditEntry {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := `
+		SELECT id, actor_id, actor_username, action, exercise_id, COALESCE(payload, 'null'), created_at
+		FROM audit_log
+		WHERE ($1 = 0 OR exercise_id = $1)
+		  AND ($2 = 0 OR actor_id = $2)
+		  AND ($3 = '' OR action = $3)
+		  AND ($5 = 0 OR id < $5)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, filter.ExerciseID, filter.ActorID, filter.Action, filter.Limit, filter.BeforeID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching audit log", "error", err)
+		return []models.AuditEntry{}
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorID, exerciseID *int
+		var payload []byte
+
+		err := rows.Scan(&entry.ID, &actorID, &entry.ActorUsername, &entry.Action, &exerciseID, &payload, &entry.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning audit entry", "error", err)
+			continue
+		}
+
+		entry.ActorID = actorID
+		entry.ExerciseID = exerciseID
+		entry.Data = json.RawMessage(payload)
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
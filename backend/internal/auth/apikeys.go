@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"log/slog"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// APIKeyPrefix starts every API key so the middleware can tell keys from
+	// session tokens, and so leaked keys are easy to search for
+	APIKeyPrefix = "aoc_"
+	// apiKeyPrefixLength is how much of the key is kept in clear text to
+	// identify it in listings
+	apiKeyPrefixLength = 12
+	// lastUsedResolution limits how often last_used_at is written for a busy key
+	lastUsedResolution = time.Minute
+)
+
+// API key types
+const (
+	KeyPersonal = "personal"
+	KeyService  = "service"
+)
+
+// API key scopes. A key can never do more than the grants of the user it
+// acts as; scopes narrow that further.
+const (
+	ScopeRead       = "read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeAdmin      = "admin"
+)
+
+// APIKeyScopes lists every scope
+var APIKeyScopes = []string{ScopeRead, ScopeTasksWrite, ScopeAdmin}
+
+// IsAPIKeyScope reports whether scope is a known scope
+func IsAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const apiKeyColumns = `k.id, k.name, k.key_type, k.prefix, k.user_id, u.username, k.scopes, k.expires_at,
+	k.last_used_at, k.created_by, k.created_at, k.revoked_at`
+
+// CreateAPIKey stores a new key and returns it along with the raw key. Only
+// a SHA-256 hash of the key is stored, so the raw key cannot be shown again.
+func CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return key, "", err
+	}
+	raw := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	key.Prefix = raw[:apiKeyPrefixLength]
+
+	query := `
+		INSERT INTO api_keys (name, key_type, prefix, key_hash, user_id, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := database.DB.QueryRowContext(ctx, query, key.Name, key.Type, key.Prefix, hashToken(raw), key.UserID,
+		pq.Array(key.Scopes), key.ExpiresAt, key.CreatedBy).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating API key", "error", err)
+		return key, "", err
+	}
+
+	return key, raw, nil
+}
+
+// GetAPIKeys returns the keys acting as a user, or every key when userID is 0
+func GetAPIKeys(ctx context.Context, userID int) []models.APIKey {
+	query := "SELECT " + apiKeyColumns + ` FROM api_keys k JOIN users u ON k.user_id = u.id
+		WHERE ($1 = 0 OR k.user_id = $1 OR k.created_by = $1)
+		ORDER BY k.created_at DESC`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching API keys", "error", err)
+		return []models.APIKey{}
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning API key", "error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// GetAPIKey returns a single key by ID
+func GetAPIKey(ctx context.Context, id int) (models.APIKey, bool) {
+	key, err := scanAPIKey(database.DB.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys k JOIN users u ON k.user_id = u.id WHERE k.id = $1", id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching API key", "api_key_id", id, "error", err)
+		}
+		return key, false
+	}
+	return key, true
+}
+
+// RevokeAPIKey stops a key from working. The key is kept so its history
+// remains visible.
+func RevokeAPIKey(ctx context.Context, id int) bool {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error revoking API key", "api_key_id", id, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// LookupAPIKey returns the key matching raw and the active user it acts as.
+// Revoked and expired keys are rejected.
+func LookupAPIKey(ctx context.Context, raw string) (models.User, models.APIKey, bool) {
+	if !strings.HasPrefix(raw, APIKeyPrefix) {
+		return models.User{}, models.APIKey{}, false
+	}
+
+	key, err := scanAPIKey(database.DB.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+` FROM api_keys k JOIN users u ON k.user_id = u.id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND k.expires_at > CURRENT_TIMESTAMP`,
+		hashToken(raw)))
+	if err != nil {
+		return models.User{}, models.APIKey{}, false
+	}
+
+	user, found := GetUserByID(ctx, key.UserID)
+	if !found || !user.Active {
+		return models.User{}, models.APIKey{}, false
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > lastUsedResolution {
+		if _, err := database.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1", key.ID); err != nil {
+			slog.ErrorContext(ctx, "Error recording use of API key", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	return user, key, true
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	var createdBy sql.NullInt64
+
+	err := row.Scan(&key.ID, &key.Name, &key.Type, &key.Prefix, &key.UserID, &key.Username,
+		pq.Array(&key.Scopes), &key.ExpiresAt, &lastUsedAt, &createdBy, &key.CreatedAt, &revokedAt)
+	if err != nil {
+		return key, err
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.CreatedBy = &id
+	}
+	return key, nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+)
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	tokenKey
+	apiKeyKey
+)
+
+// WithUser returns a copy of ctx carrying the signed-in user
+func WithUser(ctx context.Context, user models.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the signed-in user attached by Middleware
+func UserFromContext(ctx context.Context) (models.User, bool) {
+	user, ok := ctx.Value(userKey).(models.User)
+	return user, ok
+}
+
+// TokenFromContext returns the session token used to authenticate the request
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey).(string)
+	return token
+}
+
+// APIKeyFromContext returns the API key used to authenticate the request,
+// if the request was not made with a session
+func APIKeyFromContext(ctx context.Context) (models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey).(models.APIKey)
+	return key, ok
+}
+
+// Middleware attaches the user identified by the session cookie or an
+// "Authorization: Bearer" session token or API key to the request context.
+// Requests without valid credentials continue anonymously; use RequireUser
+// to reject them.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(token, APIKeyPrefix) {
+			user, key, ok := LookupAPIKey(r.Context(), token)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := WithUser(r.Context(), user)
+			ctx = context.WithValue(ctx, apiKeyKey, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		user, ok := LookupSession(r.Context(), token)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := WithUser(r.Context(), user)
+		ctx = context.WithValue(ctx, tokenKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireUser rejects requests that have no signed-in user
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UserFromContext(r.Context()); !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestToken returns the bearer token or session cookie sent with r
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// passwordIterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256
+	passwordIterations = 600000
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+	passwordScheme     = "pbkdf2-sha256"
+
+	// MinPasswordLength is the shortest password accepted for local accounts
+	MinPasswordLength = 12
+)
+
+// dummyHash is compared against when a login names an unknown user so that
+// failed logins take the same time whether or not the user exists
+var dummyHash, _ = HashPassword("not-a-real-password")
+
+// HashPassword derives a salted hash of password in the form
+// "pbkdf2-sha256$<iterations>$<salt>$<key>"
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches a hash produced by HashPassword
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// ValidatePassword checks a new password against the local account policy
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errors.New("password must be at least " + strconv.Itoa(MinPasswordLength) + " characters")
+	}
+	return nil
+}
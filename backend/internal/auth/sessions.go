@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/metrics"
+	"srd-calendar-project/backend/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionCookie is the name of the cookie holding the session token
+	SessionCookie = "session"
+	// SessionIdleTimeout is how long a session survives without being used
+	SessionIdleTimeout = 12 * time.Hour
+)
+
+// CreateSession starts a new session for a user and returns the raw token.
+// Only a SHA-256 hash of the token is stored.
+func CreateSession(ctx context.Context, userID int, userAgent string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(SessionIdleTimeout)
+
+	_, err := database.DB.ExecContext(ctx, `
+		INSERT INTO sessions (user_id, token_hash, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		userID, hashToken(token), userAgent, expiresAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating session", "user_id", userID, "error", err)
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// LookupSession returns the active user owning a session token and extends
+// the session's idle timeout
+func LookupSession(ctx context.Context, token string) (models.User, bool) {
+	var userID int
+	err := database.DB.QueryRowContext(ctx, `
+		UPDATE sessions
+		SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $2
+		WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id`,
+		hashToken(token), time.Now().Add(SessionIdleTimeout)).Scan(&userID)
+	if err != nil {
+		return models.User{}, false
+	}
+
+	user, found := GetUserByID(ctx, userID)
+	if !found || !user.Active {
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// DeleteSession ends the session identified by token
+func DeleteSession(ctx context.Context, token string) {
+	if _, err := database.DB.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = $1", hashToken(token)); err != nil {
+		slog.ErrorContext(ctx, "Error deleting session", "error", err)
+	}
+}
+
+// DeleteUserSessions ends every session belonging to a user, except the one
+// identified by keepToken when it is non-empty
+func DeleteUserSessions(ctx context.Context, userID int, keepToken ...string) {
+	keep := ""
+	if len(keepToken) > 0 && keepToken[0] != "" {
+		keep = hashToken(keepToken[0])
+	}
+
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = $1 AND token_hash <> $2", userID, keep)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting sessions", "user_id", userID, "error", err)
+	}
+}
+
+// StartSessionPruner periodically removes expired sessions until ctx is
+// cancelled
+func StartSessionPruner(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			_, err := database.DB.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= CURRENT_TIMESTAMP")
+			switch {
+			case err == nil:
+				metrics.Jobs.Inc("session_prune", "success")
+			case ctx.Err() == nil:
+				slog.ErrorContext(ctx, "Error pruning sessions", "error", err)
+				metrics.Jobs.Inc("session_prune", "error")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+)
+
+// ErrUsernameTaken is returned when creating a user whose username exists
+var ErrUsernameTaken = errors.New("username already exists")
+
+const userColumns = `id, username, COALESCE(display_name, ''), COALESCE(email, ''), COALESCE(password_hash, ''),
+	active, service, last_login_at, created_at, updated_at`
+
+// GetUsers returns all users
+func GetUsers(ctx context.Context) []models.User {
+	rows, err := database.DB.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY username")
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching users", "error", err)
+		return []models.User{}
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning user", "error", err)
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// GetUserByID returns a single user by ID
+func GetUserByID(ctx context.Context, id int) (models.User, bool) {
+	user, err := scanUser(database.DB.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching user", "user_id", id, "error", err)
+		}
+		return user, false
+	}
+	return user, true
+}
+
+// GetUserByUsername returns a single user by username, ignoring case
+func GetUserByUsername(ctx context.Context, username string) (models.User, bool) {
+	user, err := scanUser(database.DB.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE LOWER(username) = LOWER($1)", username))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching user", "username", username, "error", err)
+		}
+		return user, false
+	}
+	return user, true
+}
+
+// CreateUser stores a new user. When password is non-empty it is hashed and
+// stored; users without a password cannot log in locally.
+func CreateUser(ctx context.Context, user models.User, password string) (models.User, error) {
+	if password != "" {
+		hash, err := HashPassword(password)
+		if err != nil {
+			return user, err
+		}
+		user.PasswordHash = hash
+	}
+
+	query := `
+		INSERT INTO users (username, display_name, email, password_hash, active, service)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`
+
+	err := database.DB.QueryRowContext(ctx, query, user.Username, user.DisplayName, user.Email, user.PasswordHash, user.Active,
+		user.Service).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrUsernameTaken
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating user", "error", err)
+		return user, err
+	}
+
+	return user, nil
+}
+
+// UpdateUser updates a user's profile and active flag
+func UpdateUser(ctx context.Context, user models.User) bool {
+	query := `
+		UPDATE users
+		SET display_name = $2, email = $3, active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	result, err := database.DB.ExecContext(ctx, query, user.ID, user.DisplayName, user.Email, user.Active)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user", "user_id", user.ID, "error", err)
+		return false
+	}
+
+	if !user.Active {
+		DeleteUserSessions(ctx, user.ID)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// SetPassword replaces a user's password
+func SetPassword(ctx context.Context, userID int, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.ExecContext(ctx, "UPDATE users SET password_hash = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		userID, hash)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error setting password", "user_id", userID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser removes a user and their sessions
+func DeleteUser(ctx context.Context, id int) bool {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting user", "user_id", id, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// Authenticate checks a username and password, returning the user on success
+func Authenticate(ctx context.Context, username, password string) (models.User, bool) {
+	user, found := GetUserByUsername(ctx, strings.TrimSpace(username))
+	if !found || user.PasswordHash == "" {
+		CheckPassword(dummyHash, password)
+		return models.User{}, false
+	}
+
+	if !CheckPassword(user.PasswordHash, password) || !user.Active {
+		return models.User{}, false
+	}
+
+	_, err := database.DB.ExecContext(ctx, "UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1", user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording login", "user_id", user.ID, "error", err)
+	}
+
+	return user, true
+}
+
+// EnsureBootstrapUser creates the first administrator account when the users
+// table is empty. If no password is configured a random one is generated and
+// logged once so the operator can sign in and change it.
+func EnsureBootstrapUser(ctx context.Context, username, password string) {
+	var count int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "Error checking user count", "error", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	generated := password == ""
+	if generated {
+		buf := make([]byte, 18)
+		if _, err := rand.Read(buf); err != nil {
+			slog.ErrorContext(ctx, "Error generating bootstrap password", "error", err)
+			return
+		}
+		password = base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	_, err := CreateUser(ctx, models.User{Username: username, DisplayName: "Administrator", Active: true}, password)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating bootstrap user", "error", err)
+		return
+	}
+
+	if generated {
+		slog.InfoContext(ctx, "Created initial user - sign in and change the password", "username", username, "password", password)
+	} else {
+		slog.InfoContext(ctx, "Created initial user with the configured password", "username", username)
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	var lastLoginAt sql.NullTime
+
+	err := row.Scan(&user.ID, &user.Username, &user.DisplayName, &user.Email, &user.PasswordHash,
+		&user.Active, &user.Service, &lastLoginAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return user, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	return user, nil
+}
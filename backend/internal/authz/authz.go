@@ -0,0 +1,240 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/models"
+)
+
+// Roles that can be granted to a user
+const (
+	RoleAdmin           = "admin"
+	RoleExercisePlanner = "exercise_planner"
+	RoleDivisionLead    = "division_lead"
+	RoleTeamLead        = "team_lead"
+	RoleViewer          = "viewer"
+)
+
+// Roles lists every role, most privileged first
+var Roles = []string{RoleAdmin, RoleExercisePlanner, RoleDivisionLead, RoleTeamLead, RoleViewer}
+
+// Permissions checked by the handlers
+const (
+	Read = "read"
+
+	ExerciseCreate = "exercise:create"
+	ExerciseUpdate = "exercise:update"
+	ExerciseDelete = "exercise:delete"
+
+	DivisionCreate = "division:create"
+	DivisionUpdate = "division:update"
+	DivisionDelete = "division:delete"
+
+	TeamCreate = "team:create"
+	TeamUpdate = "team:update"
+	TeamDelete = "team:delete"
+
+	EventCreate = "event:create"
+	EventUpdate = "event:update"
+	EventDelete = "event:delete"
+
+	TaskCreate = "task:create"
+	TaskUpdate = "task:update"
+	TaskAssign = "task:assign"
+	TaskDelete = "task:delete"
+
+	GrantsManage   = "grants:manage"
+	AuditRead      = "audit:read"
+	UsersManage    = "users:manage"
+	WebhooksManage = "webhooks:manage"
+)
+
+// rolePermissions maps each role to the permissions it grants within the
+// grant's scope
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		Read, ExerciseCreate, ExerciseUpdate, ExerciseDelete,
+		DivisionCreate, DivisionUpdate, DivisionDelete,
+		TeamCreate, TeamUpdate, TeamDelete,
+		EventCreate, EventUpdate, EventDelete,
+		TaskCreate, TaskUpdate, TaskAssign, TaskDelete,
+		GrantsManage, AuditRead, UsersManage, WebhooksManage,
+	},
+	RoleExercisePlanner: {
+		Read, ExerciseCreate, ExerciseUpdate,
+		DivisionCreate, DivisionUpdate, DivisionDelete,
+		TeamCreate, TeamUpdate, TeamDelete,
+		EventCreate, EventUpdate, EventDelete,
+		TaskCreate, TaskUpdate, TaskAssign, TaskDelete,
+		GrantsManage, AuditRead,
+	},
+	RoleDivisionLead: {
+		Read, DivisionUpdate,
+		TeamCreate, TeamUpdate, TeamDelete,
+		TaskCreate, TaskUpdate, TaskAssign,
+	},
+	RoleTeamLead: {
+		Read, TeamUpdate, TaskUpdate,
+	},
+	RoleViewer: {
+		Read,
+	},
+}
+
+// IsRole reports whether role is a known role
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsFor returns the permissions granted by a role
+func PermissionsFor(role string) []string {
+	return rolePermissions[role]
+}
+
+// Scope identifies the resource a permission is checked against. A zero
+// field means the resource is not inside that kind of container, so the
+// zero Scope stands for application-wide actions such as creating an
+// exercise.
+type Scope struct {
+	ExerciseID int
+	DivisionID int
+	TeamID     int
+}
+
+// covers reports whether a grant's scope contains s
+func covers(grant models.RoleGrant, s Scope) bool {
+	switch {
+	case grant.TeamID != nil:
+		return *grant.TeamID == s.TeamID
+	case grant.DivisionID != nil:
+		return *grant.DivisionID == s.DivisionID
+	case grant.ExerciseID != nil:
+		return *grant.ExerciseID == s.ExerciseID
+	default:
+		return true
+	}
+}
+
+// Allowed reports whether any of the grants permits perm on s. Read access
+// to an exercise is given by any grant inside it, so a team lead can see the
+// rest of the exercise their team belongs to.
+func Allowed(grants []models.RoleGrant, perm string, s Scope) bool {
+	for _, grant := range grants {
+		if !hasPermission(grant.Role, perm) {
+			continue
+		}
+		if perm == Read {
+			if grant.ExerciseID == nil || *grant.ExerciseID == s.ExerciseID {
+				return true
+			}
+			continue
+		}
+		if covers(grant, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const grantsKey contextKey = 0
+
+// WithGrants returns a copy of ctx carrying the signed-in user's grants
+func WithGrants(ctx context.Context, grants []models.RoleGrant) context.Context {
+	return context.WithValue(ctx, grantsKey, grants)
+}
+
+// GrantsFromContext returns the grants attached by Middleware
+func GrantsFromContext(ctx context.Context) []models.RoleGrant {
+	grants, _ := ctx.Value(grantsKey).([]models.RoleGrant)
+	return grants
+}
+
+// scopePermissions maps each API key scope to the permissions it allows.
+// The admin scope places no limit beyond the user's own grants.
+var scopePermissions = map[string][]string{
+	auth.ScopeRead:       {Read},
+	auth.ScopeTasksWrite: {Read, TaskCreate, TaskUpdate, TaskAssign, TaskDelete},
+}
+
+// scopesAllow reports whether an API key with scopes may use perm
+func scopesAllow(scopes []string, perm string) bool {
+	for _, scope := range scopes {
+		if scope == auth.ScopeAdmin {
+			return true
+		}
+		for _, p := range scopePermissions[scope] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Can reports whether the user signed in on ctx may perform perm on s. A
+// request made with an API key is further limited to the key's scopes.
+func Can(ctx context.Context, perm string, s Scope) bool {
+	if key, ok := auth.APIKeyFromContext(ctx); ok && !scopesAllow(key.Scopes, perm) {
+		return false
+	}
+	return Allowed(GrantsFromContext(ctx), perm, s)
+}
+
+// CanGrant reports whether the user signed in on ctx may give role to
+// someone else within s. Besides managing grants there, they must already
+// hold every permission the role carries, so nobody can hand out more access
+// than they have.
+func CanGrant(ctx context.Context, role string, s Scope) bool {
+	if !Can(ctx, GrantsManage, s) {
+		return false
+	}
+	for _, perm := range rolePermissions[role] {
+		if !Can(ctx, perm, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware loads the signed-in user's grants once per request. It must run
+// after auth.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := WithGrants(r.Context(), GetGrantsForUser(r.Context(), user.ID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require returns middleware that rejects requests from users who do not
+// hold perm application-wide. It is used for routes that are not tied to an
+// exercise, such as user and webhook management.
+func Require(perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Can(r.Context(), perm, Scope{}) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+)
+
+// ErrInvalidScope is returned when a grant names a division or team that does
+// not exist, or that belongs to a different exercise than the one given
+var ErrInvalidScope = errors.New("grant scope does not exist or is inconsistent")
+
+// Grant sources
+const (
+	SourceManual = "manual"
+	SourceOIDC   = "oidc"
+)
+
+const grantColumns = `g.id, g.user_id, u.username, g.role, g.exercise_id, g.division_id, g.team_id, g.granted_by,
+	g.source, g.created_at`
+
+// GetGrantsForUser returns every grant held by a user
+func GetGrantsForUser(ctx context.Context, userID int) []models.RoleGrant {
+	return queryGrants(ctx, "WHERE g.user_id = $1", userID)
+}
+
+// GetGrants returns grants, optionally restricted to one user and/or one
+// exercise. Zero values match all.
+func GetGrants(ctx context.Context, userID, exerciseID int) []models.RoleGrant {
+	return queryGrants(ctx, "WHERE ($1 = 0 OR g.user_id = $1) AND ($2 = 0 OR g.exercise_id = $2)", userID, exerciseID)
+}
+
+// GetGrant returns a single grant by ID
+func GetGrant(ctx context.Context, id int) (models.RoleGrant, bool) {
+	grants := queryGrants(ctx, "WHERE g.id = $1", id)
+	if len(grants) == 0 {
+		return models.RoleGrant{}, false
+	}
+	return grants[0], true
+}
+
+// CreateGrant stores a grant. The exercise and division of a team grant, and
+// the exercise of a division grant, are filled in from the database so that
+// every grant records the full chain of containers it applies to.
+func CreateGrant(ctx context.Context, grant models.RoleGrant) (models.RoleGrant, error) {
+	grant, err := completeScope(ctx, grant)
+	if err != nil {
+		return grant, err
+	}
+
+	if err := insertGrant(ctx, database.DB, &grant); err != nil {
+		slog.ErrorContext(ctx, "Error creating role grant", "error", err)
+		return grant, err
+	}
+
+	return grant, nil
+}
+
+// ReplaceGrants swaps every grant a user holds from source for grants, in
+// one transaction. Grants from other sources are left alone, so mapping
+// identity provider claims at login never removes a grant made by hand.
+// Grants whose scope no longer exists are skipped.
+func ReplaceGrants(ctx context.Context, userID int, source string, grants []models.RoleGrant) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_grants WHERE user_id = $1 AND source = $2", userID, source); err != nil {
+		slog.ErrorContext(ctx, "Error clearing grants", "source", source, "user_id", userID, "error", err)
+		return err
+	}
+
+	for _, grant := range grants {
+		grant.UserID = userID
+		grant.Source = source
+		grant, err := completeScope(ctx, grant)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping grant", "source", source, "role", grant.Role, "user_id", userID, "error", err)
+			continue
+		}
+		if err := insertGrant(ctx, tx, &grant); err != nil {
+			slog.ErrorContext(ctx, "Error creating grant", "source", source, "user_id", userID, "error", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// completeScope fills in the exercise and division containing a team or
+// division grant and checks they match any the caller gave
+func completeScope(ctx context.Context, grant models.RoleGrant) (models.RoleGrant, error) {
+	switch {
+	case grant.TeamID != nil:
+		s, found := TeamScope(ctx, *grant.TeamID)
+		if !found || (grant.DivisionID != nil && *grant.DivisionID != s.DivisionID) ||
+			(grant.ExerciseID != nil && *grant.ExerciseID != s.ExerciseID) {
+			return grant, ErrInvalidScope
+		}
+		grant.ExerciseID, grant.DivisionID = &s.ExerciseID, &s.DivisionID
+	case grant.DivisionID != nil:
+		s, found := DivisionScope(ctx, *grant.DivisionID)
+		if !found || (grant.ExerciseID != nil && *grant.ExerciseID != s.ExerciseID) {
+			return grant, ErrInvalidScope
+		}
+		grant.ExerciseID = &s.ExerciseID
+	case grant.ExerciseID != nil:
+		var exists bool
+		err := database.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM exercises WHERE id = $1)", *grant.ExerciseID).Scan(&exists)
+		if err != nil || !exists {
+			return grant, ErrInvalidScope
+		}
+	}
+	return grant, nil
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func insertGrant(ctx context.Context, q queryRower, grant *models.RoleGrant) error {
+	if grant.Source == "" {
+		grant.Source = SourceManual
+	}
+
+	query := `
+		INSERT INTO role_grants (user_id, role, exercise_id, division_id, team_id, granted_by, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	return q.QueryRowContext(ctx, query, grant.UserID, grant.Role, grant.ExerciseID, grant.DivisionID,
+		grant.TeamID, grant.GrantedBy, grant.Source).Scan(&grant.ID, &grant.CreatedAt)
+}
+
+// DeleteGrant removes a grant
+func DeleteGrant(ctx context.Context, id int) bool {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM role_grants WHERE id = $1", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting role grant", "grant_id", id, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// EnsureAdmin grants the admin role to the oldest user when nobody holds it,
+// so a fresh install or an upgrade from before roles existed is never left
+// without an administrator
+func EnsureAdmin(ctx context.Context) {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM role_grants WHERE role = $1 AND exercise_id IS NULL)", RoleAdmin).
+		Scan(&exists)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking for administrators", "error", err)
+		return
+	}
+	if exists {
+		return
+	}
+
+	var userID int
+	var username string
+	err = database.DB.QueryRowContext(ctx, "SELECT id, username FROM users WHERE active AND NOT service ORDER BY id LIMIT 1").Scan(&userID, &username)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error finding user to make administrator", "error", err)
+		}
+		return
+	}
+
+	if _, err := CreateGrant(ctx, models.RoleGrant{UserID: userID, Role: RoleAdmin}); err == nil {
+		slog.InfoContext(ctx, "Granted admin role", "username", username)
+	}
+}
+
+// TeamScope returns the scope of a team
+func TeamScope(ctx context.Context, teamID int) (Scope, bool) {
+	s := Scope{TeamID: teamID}
+	err := database.DB.QueryRowContext(ctx, "SELECT exercise_id, division_id FROM teams WHERE id = $1", teamID).
+		Scan(&s.ExerciseID, &s.DivisionID)
+	return s, err == nil
+}
+
+// DivisionScope returns the scope of a division
+func DivisionScope(ctx context.Context, divisionID int) (Scope, bool) {
+	s := Scope{DivisionID: divisionID}
+	err := database.DB.QueryRowContext(ctx, "SELECT exercise_id FROM divisions WHERE id = $1", divisionID).Scan(&s.ExerciseID)
+	return s, err == nil
+}
+
+// EventScope returns the scope of an event
+func EventScope(ctx context.Context, eventID int) (Scope, bool) {
+	var s Scope
+	err := database.DB.QueryRowContext(ctx, "SELECT exercise_id FROM events WHERE id = $1", eventID).Scan(&s.ExerciseID)
+	return s, err == nil
+}
+
+// TaskScope returns the scope of a task. A task assigned to a team is scoped
+// to that team so its division and team leads can work on it.
+func TaskScope(ctx context.Context, taskID int) (Scope, bool) {
+	var s Scope
+	var divisionID, teamID sql.NullInt64
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT t.exercise_id, tm.division_id, tm.id
+		FROM tasks t
+		LEFT JOIN teams tm ON t.team_id = tm.id
+		WHERE t.id = $1`, taskID).Scan(&s.ExerciseID, &divisionID, &teamID)
+	if err != nil {
+		return s, false
+	}
+	s.DivisionID = int(divisionID.Int64)
+	s.TeamID = int(teamID.Int64)
+	return s, true
+}
+
+// GrantScope returns the scope a grant applies to
+func GrantScope(grant models.RoleGrant) Scope {
+	var s Scope
+	if grant.ExerciseID != nil {
+		s.ExerciseID = *grant.ExerciseID
+	}
+	if grant.DivisionID != nil {
+		s.DivisionID = *grant.DivisionID
+	}
+	if grant.TeamID != nil {
+		s.TeamID = *grant.TeamID
+	}
+	return s
+}
+
+func queryGrants(ctx context.Context, where string, args ...interface{}) []models.RoleGrant {
+	query := "SELECT " + grantColumns + " FROM role_grants g JOIN users u ON g.user_id = u.id " + where +
+		" ORDER BY g.user_id, g.id"
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching role grants", "error", err)
+		return []models.RoleGrant{}
+	}
+	defer rows.Close()
+
+	grants := []models.RoleGrant{}
+	for rows.Next() {
+		var grant models.RoleGrant
+		err := rows.Scan(&grant.ID, &grant.UserID, &grant.Username, &grant.Role, &grant.ExerciseID,
+			&grant.DivisionID, &grant.TeamID, &grant.GrantedBy, &grant.Source, &grant.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning role grant", "error", err)
+			continue
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants
+}
@@ -0,0 +1,138 @@
+package changes
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/auth"
+	"strings"
+	"time"
+)
+
+// Change types emitted by the repository and the task handlers
+const (
+	ExerciseCreated = "exercise.created"
+	ExerciseUpdated = "exercise.updated"
+	ExerciseDeleted = "exercise.deleted"
+
+	EscalationPolicyUpdated = "exercise.escalation_policy_updated"
+	SitrepTemplateUpdated   = "exercise.sitrep_template_updated"
+
+	DivisionCreated    = "division.created"
+	DivisionUpdated    = "division.updated"
+	DivisionDeleted    = "division.deleted"
+	DivisionsReordered = "division.reordered"
+
+	TeamCreated         = "team.created"
+	TeamUpdated         = "team.updated"
+	TeamDeleted         = "team.deleted"
+	TeamStatusChanged   = "team.status_changed"
+	TeamMoved           = "team.moved"
+	TeamsReordered      = "team.reordered"
+	TeamStatusExpired   = "team.status_expired"
+	TeamStatusConfirmed = "team.status_confirmed"
+	TeamStatusStale     = "team.status_stale"
+
+	TeamEscalated          = "team.escalated"
+	EscalationAcknowledged = "team.escalation_acknowledged"
+
+	EventCreated     = "event.created"
+	EventUpdated     = "event.updated"
+	EventRescheduled = "event.rescheduled"
+	EventDeleted     = "event.deleted"
+
+	TaskCreated  = "task.created"
+	TaskUpdated  = "task.updated"
+	TaskAssigned = "task.assigned"
+	TaskDeleted  = "task.deleted"
+)
+
+// Types lists every change type that can be emitted
+var Types = []string{
+	ExerciseCreated, ExerciseUpdated, ExerciseDeleted, EscalationPolicyUpdated, SitrepTemplateUpdated,
+	DivisionCreated, DivisionUpdated, DivisionDeleted, DivisionsReordered,
+	TeamCreated, TeamUpdated, TeamDeleted, TeamStatusChanged, TeamMoved, TeamsReordered,
+	TeamStatusExpired, TeamStatusConfirmed, TeamStatusStale, TeamEscalated, EscalationAcknowledged,
+	EventCreated, EventUpdated, EventRescheduled, EventDeleted,
+	TaskCreated, TaskUpdated, TaskAssigned, TaskDeleted,
+}
+
+// Execer is satisfied by both *sql.DB and *sql.Tx so a change can be recorded
+// inside the same transaction as the mutation that caused it
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Change describes a single mutation to an exercise, division, team, event or task
+type Change struct {
+	ID         int64       `json:"id,omitempty"` // Position in the change log, set once the change is recorded
+	Type       string      `json:"type"`
+	ExerciseID int         `json:"exercise_id,omitempty"`
+	Data       interface{} `json:"data"`
+	Actor      *Actor      `json:"actor,omitempty"` // Nil for changes made by the system, e.g. seeding
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// Actor identifies the user who made a change
+type Actor struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// Sink receives every emitted change
+type Sink func(ctx context.Context, q Execer, change Change) error
+
+var sinks []Sink
+
+// RegisterSink adds a sink that will receive all future changes
+func RegisterSink(sink Sink) {
+	sinks = append(sinks, sink)
+}
+
+// Emit passes a change to every registered sink, attributing it to the user
+// signed in on ctx. Sink failures are logged; when q is a transaction a failed
+// sink also aborts it, so a mutation and its change notifications are committed
+// together or not at all.
+func Emit(ctx context.Context, q Execer, changeType string, exerciseID int, data interface{}) {
+	change := Change{
+		Type:       changeType,
+		ExerciseID: exerciseID,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+	}
+	if user, ok := auth.UserFromContext(ctx); ok {
+		change.Actor = &Actor{ID: user.ID, Username: user.Username}
+	}
+
+	for _, sink := range sinks {
+		if err := sink(ctx, q, change); err != nil {
+			slog.ErrorContext(ctx, "Error emitting change", "type", changeType, "error", err)
+		}
+	}
+}
+
+// IsKnownType reports whether t is a change type or a wildcard filter such as
+// "*" or "team.*"
+func IsKnownType(t string) bool {
+	if t == "*" {
+		return true
+	}
+	for _, known := range Types {
+		if known == t {
+			return true
+		}
+		if strings.HasSuffix(t, ".*") && strings.HasPrefix(known, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Category returns the wildcard filter that matches a change type, e.g.
+// "team.*" for "team.status_changed"
+func Category(changeType string) string {
+	if i := strings.Index(changeType, "."); i >= 0 {
+		return changeType[:i] + ".*"
+	}
+	return changeType
+}
@@ -0,0 +1,612 @@
+// Package config loads the server configuration. Values come from, in
+// increasing order of precedence: built-in defaults, a JSON config file,
+// environment variables and command-line flags.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the complete server configuration
+type Config struct {
+	Server     ServerConfig     `json:"server"`
+	Database   DatabaseConfig   `json:"database"`
+	Auth       AuthConfig       `json:"auth"`
+	CORS       CORSConfig       `json:"cors"`
+	Features   FeatureConfig    `json:"features"`
+	Logging    LoggingConfig    `json:"logging"`
+	Readiness  ReadinessConfig  `json:"readiness"`
+	Status     StatusConfig     `json:"status"`
+	Escalation EscalationConfig `json:"escalation"`
+	Sitrep     SitrepConfig     `json:"sitrep"`
+}
+
+type ServerConfig struct {
+	Addr            string   `json:"addr"`
+	TLSCertFile     string   `json:"tls_cert_file"` // Serve HTTPS when both the certificate and key are set
+	TLSKeyFile      string   `json:"tls_key_file"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"` // How long to wait for requests and background work to finish on shutdown
+}
+
+type DatabaseConfig struct {
+	DSN             string   `json:"dsn"` // Connection URL or key=value string; overrides the individual fields below
+	Host            string   `json:"host"`
+	Port            int      `json:"port"`
+	User            string   `json:"user"`
+	Password        string   `json:"password"`
+	Name            string   `json:"name"`
+	SSLMode         string   `json:"sslmode"`
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+	QueryTimeout    Duration `json:"query_timeout"`   // Limit on the database work done for one API request; 0 disables it
+	CreateDatabase  bool     `json:"create_database"` // Create the database on startup if it does not exist; ignored with a DSN
+	Seed            bool     `json:"seed"`            // Load sample exercises into an empty database
+}
+
+type AuthConfig struct {
+	AdminUsername string     `json:"admin_username"`
+	AdminPassword string     `json:"admin_password"`
+	OIDC          OIDCConfig `json:"oidc"`
+}
+
+type OIDCConfig struct {
+	Issuer        string          `json:"issuer"` // Single sign-on is disabled when empty
+	ClientID      string          `json:"client_id"`
+	ClientSecret  string          `json:"client_secret"`
+	RedirectURL   string          `json:"redirect_url"`
+	PostLoginURL  string          `json:"post_login_url"`
+	ProviderName  string          `json:"provider_name"`
+	Scopes        []string        `json:"scopes"`
+	UsernameClaim string          `json:"username_claim"`
+	GroupsClaim   string          `json:"groups_claim"`
+	RoleMappings  json.RawMessage `json:"role_mappings,omitempty"` // Validated by the oidc package
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"`   // "*" allows any origin, but not with credentials
+	AllowCredentials bool     `json:"allow_credentials"` // Let browsers send the session cookie cross-origin
+	MaxAge           Duration `json:"max_age"`           // How long browsers may cache a preflight response
+}
+
+type LoggingConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // json or text
+	Redact bool   `json:"redact"` // Mask points of contact, comments and chatbot messages in log lines
+}
+
+// ReadinessConfig chooses how team statuses roll up into division and
+// exercise readiness
+type ReadinessConfig struct {
+	Rule         string  `json:"rule"`          // worst_of: the worst counted team decides; percentage: weighted shares against the thresholds below
+	GreenPercent float64 `json:"green_percent"` // percentage rule: green when at least this share of the weight is green
+	RedPercent   float64 `json:"red_percent"`   // percentage rule: red when at least this share of the weight is red
+}
+
+// StatusConfig controls the periodic check that expires status windows and
+// flags stale statuses
+type StatusConfig struct {
+	CheckInterval Duration `json:"check_interval"` // How often the check runs
+	RevertTo      string   `json:"revert_to"`      // Status a team takes when its status window ends
+	StaleAfter    Duration `json:"stale_after"`    // A status not set or confirmed for this long is stale
+}
+
+// EscalationConfig controls the periodic run of the exercises' escalation
+// policies
+type EscalationConfig struct {
+	CheckInterval Duration `json:"check_interval"` // How often due escalation steps are looked for
+}
+
+// SitrepConfig controls what situation reports include
+type SitrepConfig struct {
+	DueSoon Duration `json:"due_soon"` // Open tasks due within this long are listed as due soon
+}
+
+type FeatureConfig struct {
+	Chatbot    bool `json:"chatbot"`
+	Webhooks   bool `json:"webhooks"`
+	LiveStream bool `json:"live_stream"`
+}
+
+// Duration is a time.Duration written as a string such as "30m" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the configuration used when nothing is overridden. It
+// matches how the server behaved before it was configurable.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8081",
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "test_db",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			QueryTimeout:    Duration(15 * time.Second),
+			CreateDatabase:  true,
+			Seed:            true,
+		},
+		Auth: AuthConfig{
+			AdminUsername: "admin",
+			OIDC: OIDCConfig{
+				PostLoginURL:  "/",
+				ProviderName:  "Single Sign-On",
+				Scopes:        []string{"openid", "profile", "email"},
+				UsernameClaim: "preferred_username",
+				GroupsClaim:   "groups",
+			},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"http://localhost:3000"},
+			AllowCredentials: true,
+			MaxAge:           Duration(10 * time.Minute),
+		},
+		Features: FeatureConfig{
+			Chatbot:    true,
+			Webhooks:   true,
+			LiveStream: true,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+			Redact: true,
+		},
+		Readiness: ReadinessConfig{
+			Rule:         "worst_of",
+			GreenPercent: 90,
+			RedPercent:   25,
+		},
+		Status: StatusConfig{
+			CheckInterval: Duration(5 * time.Minute),
+			RevertTo:      "green",
+			StaleAfter:    Duration(48 * time.Hour),
+		},
+		Escalation: EscalationConfig{
+			CheckInterval: Duration(time.Minute),
+		},
+		Sitrep: SitrepConfig{
+			DueSoon: Duration(48 * time.Hour),
+		},
+	}
+}
+
+// Load builds the configuration from the config file, environment and the
+// command-line arguments (without the program name). printConfig reports
+// whether --print-config was given.
+func Load(args []string) (cfg Config, printConfig bool, err error) {
+	cfg = Default()
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&printConfig, "print-config", false, "print the effective configuration with secrets redacted, then exit")
+
+	// Flags are registered against a scratch copy so that only the flags
+	// actually given override the file and environment
+	var f Config
+	fs.StringVar(&f.Server.Addr, "listen", "", "listen address, e.g. :8081 (env LISTEN_ADDR)")
+	fs.StringVar(&f.Server.TLSCertFile, "tls-cert", "", "TLS certificate file (env TLS_CERT_FILE)")
+	fs.StringVar(&f.Server.TLSKeyFile, "tls-key", "", "TLS private key file (env TLS_KEY_FILE)")
+	fs.StringVar(&f.Database.DSN, "db-dsn", "", "database connection string (env DATABASE_URL)")
+	fs.StringVar(&f.Database.Host, "db-host", "", "database host (env DB_HOST)")
+	fs.IntVar(&f.Database.Port, "db-port", 0, "database port (env DB_PORT)")
+	fs.StringVar(&f.Database.User, "db-user", "", "database user (env DB_USER)")
+	fs.StringVar(&f.Database.Name, "db-name", "", "database name (env DB_NAME)")
+	fs.StringVar(&f.Database.SSLMode, "db-sslmode", "", "database sslmode (env DB_SSLMODE)")
+	fs.IntVar(&f.Database.MaxOpenConns, "db-max-open-conns", 0, "maximum open database connections (env DB_MAX_OPEN_CONNS)")
+	fs.IntVar(&f.Database.MaxIdleConns, "db-max-idle-conns", 0, "maximum idle database connections (env DB_MAX_IDLE_CONNS)")
+	fs.BoolVar(&f.Database.Seed, "seed", false, "load sample exercises into an empty database (env SEED_DATA)")
+	fs.StringVar(&f.Logging.Level, "log-level", "", "log level: debug, info, warn or error (env LOG_LEVEL)")
+	corsOrigins := fs.String("cors-origins", "", "comma-separated allowed CORS origins (env CORS_ALLOWED_ORIGINS)")
+	if err := fs.Parse(args); err != nil {
+		return cfg, false, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, false, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, false, err
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "listen":
+			cfg.Server.Addr = f.Server.Addr
+		case "tls-cert":
+			cfg.Server.TLSCertFile = f.Server.TLSCertFile
+		case "tls-key":
+			cfg.Server.TLSKeyFile = f.Server.TLSKeyFile
+		case "db-dsn":
+			cfg.Database.DSN = f.Database.DSN
+		case "db-host":
+			cfg.Database.Host = f.Database.Host
+		case "db-port":
+			cfg.Database.Port = f.Database.Port
+		case "db-user":
+			cfg.Database.User = f.Database.User
+		case "db-name":
+			cfg.Database.Name = f.Database.Name
+		case "db-sslmode":
+			cfg.Database.SSLMode = f.Database.SSLMode
+		case "db-max-open-conns":
+			cfg.Database.MaxOpenConns = f.Database.MaxOpenConns
+		case "db-max-idle-conns":
+			cfg.Database.MaxIdleConns = f.Database.MaxIdleConns
+		case "seed":
+			cfg.Database.Seed = f.Database.Seed
+		case "log-level":
+			cfg.Logging.Level = f.Logging.Level
+		case "cors-origins":
+			cfg.CORS.AllowedOrigins = splitList(*corsOrigins)
+		}
+	})
+
+	return cfg, printConfig, cfg.Validate()
+}
+
+// loadFile overlays the settings in a JSON config file onto cfg. Unknown
+// keys are rejected so a typo does not silently fall back to a default.
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables onto cfg. Variables that are unset
+// or empty leave the current value alone.
+func applyEnv(cfg *Config) error {
+	var errs []error
+	str := func(key string, dest *string) {
+		if v := os.Getenv(key); v != "" {
+			*dest = v
+		}
+	}
+	num := func(key string, dest *int) {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a number", key, v))
+				return
+			}
+			*dest = n
+		}
+	}
+	boolean := func(key string, dest *bool) {
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not true or false", key, v))
+				return
+			}
+			*dest = b
+		}
+	}
+	percent := func(key string, dest *float64) {
+		if v := os.Getenv(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a number", key, v))
+				return
+			}
+			*dest = f
+		}
+	}
+	duration := func(key string, dest *Duration) {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a duration", key, v))
+				return
+			}
+			*dest = Duration(d)
+		}
+	}
+	list := func(key string, dest *[]string) {
+		if v := os.Getenv(key); v != "" {
+			*dest = splitList(v)
+		}
+	}
+
+	str("LISTEN_ADDR", &cfg.Server.Addr)
+	str("TLS_CERT_FILE", &cfg.Server.TLSCertFile)
+	str("TLS_KEY_FILE", &cfg.Server.TLSKeyFile)
+	duration("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	str("DATABASE_URL", &cfg.Database.DSN)
+	str("DB_HOST", &cfg.Database.Host)
+	num("DB_PORT", &cfg.Database.Port)
+	str("DB_USER", &cfg.Database.User)
+	str("DB_PASSWORD", &cfg.Database.Password)
+	str("DB_NAME", &cfg.Database.Name)
+	str("DB_SSLMODE", &cfg.Database.SSLMode)
+	num("DB_MAX_OPEN_CONNS", &cfg.Database.MaxOpenConns)
+	num("DB_MAX_IDLE_CONNS", &cfg.Database.MaxIdleConns)
+	duration("DB_CONN_MAX_LIFETIME", &cfg.Database.ConnMaxLifetime)
+	duration("DB_QUERY_TIMEOUT", &cfg.Database.QueryTimeout)
+	boolean("DB_CREATE_DATABASE", &cfg.Database.CreateDatabase)
+	boolean("SEED_DATA", &cfg.Database.Seed)
+
+	str("ADMIN_USERNAME", &cfg.Auth.AdminUsername)
+	str("ADMIN_PASSWORD", &cfg.Auth.AdminPassword)
+
+	oidc := &cfg.Auth.OIDC
+	str("OIDC_ISSUER", &oidc.Issuer)
+	str("OIDC_CLIENT_ID", &oidc.ClientID)
+	str("OIDC_CLIENT_SECRET", &oidc.ClientSecret)
+	str("OIDC_REDIRECT_URL", &oidc.RedirectURL)
+	str("OIDC_POST_LOGIN_URL", &oidc.PostLoginURL)
+	str("OIDC_PROVIDER_NAME", &oidc.ProviderName)
+	if v := os.Getenv("OIDC_SCOPES"); v != "" {
+		oidc.Scopes = strings.Fields(v)
+	}
+	str("OIDC_USERNAME_CLAIM", &oidc.UsernameClaim)
+	str("OIDC_GROUPS_CLAIM", &oidc.GroupsClaim)
+	if v := os.Getenv("OIDC_ROLE_MAPPINGS"); v != "" {
+		oidc.RoleMappings = json.RawMessage(v)
+	}
+
+	list("CORS_ALLOWED_ORIGINS", &cfg.CORS.AllowedOrigins)
+	boolean("CORS_ALLOW_CREDENTIALS", &cfg.CORS.AllowCredentials)
+	duration("CORS_MAX_AGE", &cfg.CORS.MaxAge)
+
+	boolean("FEATURE_CHATBOT", &cfg.Features.Chatbot)
+	boolean("FEATURE_WEBHOOKS", &cfg.Features.Webhooks)
+	boolean("FEATURE_LIVE_STREAM", &cfg.Features.LiveStream)
+
+	str("LOG_LEVEL", &cfg.Logging.Level)
+	str("LOG_FORMAT", &cfg.Logging.Format)
+	boolean("LOG_REDACT", &cfg.Logging.Redact)
+
+	str("READINESS_RULE", &cfg.Readiness.Rule)
+	percent("READINESS_GREEN_PERCENT", &cfg.Readiness.GreenPercent)
+	percent("READINESS_RED_PERCENT", &cfg.Readiness.RedPercent)
+
+	duration("STATUS_CHECK_INTERVAL", &cfg.Status.CheckInterval)
+	str("STATUS_REVERT_TO", &cfg.Status.RevertTo)
+	duration("STATUS_STALE_AFTER", &cfg.Status.StaleAfter)
+	duration("ESCALATION_CHECK_INTERVAL", &cfg.Escalation.CheckInterval)
+	duration("SITREP_DUE_SOON", &cfg.Sitrep.DueSoon)
+
+	return errors.Join(errs...)
+}
+
+var (
+	sslModes   = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{"json", "text"}
+	rollups    = []string{"worst_of", "percentage"}
+	statuses   = []string{"green", "yellow", "red"}
+)
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the configuration and reports every problem at once
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		add("server.addr: %q is not a host:port address", c.Server.Addr)
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		add("server: tls_cert_file and tls_key_file must be set together")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout must be positive")
+	}
+	for _, file := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			add("server: cannot read TLS file %s", file)
+		}
+	}
+
+	db := c.Database
+	if db.DSN == "" {
+		if db.Host == "" || db.User == "" || db.Name == "" {
+			add("database: host, user and name are required when no dsn is given")
+		}
+		if db.Port < 1 || db.Port > 65535 {
+			add("database.port: %d is out of range", db.Port)
+		}
+		if !oneOf(db.SSLMode, sslModes) {
+			add("database.sslmode: %q must be one of %s", db.SSLMode, strings.Join(sslModes, ", "))
+		}
+	}
+	if db.MaxOpenConns < 0 || db.MaxIdleConns < 0 {
+		add("database: pool sizes cannot be negative")
+	}
+	if db.MaxOpenConns > 0 && db.MaxIdleConns > db.MaxOpenConns {
+		add("database.max_idle_conns: %d is more than max_open_conns %d", db.MaxIdleConns, db.MaxOpenConns)
+	}
+	if db.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime cannot be negative")
+	}
+	if db.QueryTimeout < 0 {
+		add("database.query_timeout cannot be negative")
+	}
+
+	if c.Auth.AdminUsername == "" {
+		add("auth.admin_username is required")
+	}
+	if c.Auth.OIDC.Issuer != "" {
+		if _, err := url.ParseRequestURI(c.Auth.OIDC.Issuer); err != nil {
+			add("auth.oidc.issuer: %q is not a URL", c.Auth.OIDC.Issuer)
+		}
+		if c.Auth.OIDC.ClientID == "" || c.Auth.OIDC.RedirectURL == "" {
+			add("auth.oidc: client_id and redirect_url are required when issuer is set")
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				add("cors: allowed_origins cannot contain * when allow_credentials is true")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			add("cors.allowed_origins: %q must be a scheme://host[:port] origin or *", origin)
+		}
+	}
+
+	if c.CORS.MaxAge < 0 {
+		add("cors.max_age cannot be negative")
+	}
+
+	if !oneOf(c.Logging.Level, logLevels) {
+		add("logging.level: %q must be one of %s", c.Logging.Level, strings.Join(logLevels, ", "))
+	}
+	if !oneOf(c.Logging.Format, logFormats) {
+		add("logging.format: %q must be one of %s", c.Logging.Format, strings.Join(logFormats, ", "))
+	}
+
+	if !oneOf(c.Readiness.Rule, rollups) {
+		add("readiness.rule: %q must be one of %s", c.Readiness.Rule, strings.Join(rollups, ", "))
+	}
+	if p := c.Readiness.GreenPercent; p <= 0 || p > 100 {
+		add("readiness.green_percent: %g must be more than 0 and at most 100", p)
+	}
+	if p := c.Readiness.RedPercent; p <= 0 || p > 100 {
+		add("readiness.red_percent: %g must be more than 0 and at most 100", p)
+	}
+
+	if c.Status.CheckInterval <= 0 {
+		add("status.check_interval must be positive")
+	}
+	if !oneOf(c.Status.RevertTo, statuses) {
+		add("status.revert_to: %q must be one of %s", c.Status.RevertTo, strings.Join(statuses, ", "))
+	}
+	if c.Status.StaleAfter <= 0 {
+		add("status.stale_after must be positive")
+	}
+	if c.Escalation.CheckInterval <= 0 {
+		add("escalation.check_interval must be positive")
+	}
+	if c.Sitrep.DueSoon <= 0 {
+		add("sitrep.due_soon must be positive")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with passwords, secrets and
+// the TLS private key location masked, suitable for printing or logging
+func (c Config) Redacted() Config {
+	const mask = "REDACTED"
+	if c.Server.TLSKeyFile != "" {
+		c.Server.TLSKeyFile = mask
+	}
+	if c.Database.Password != "" {
+		c.Database.Password = mask
+	}
+	if u, err := url.Parse(c.Database.DSN); err == nil && u.User != nil {
+		if _, set := u.User.Password(); set {
+			u.User = url.UserPassword(u.User.Username(), mask)
+			c.Database.DSN = u.String()
+		}
+	} else if c.Database.DSN != "" {
+		c.Database.DSN = dsnPassword.ReplaceAllString(c.Database.DSN, "${1}"+mask)
+	}
+	if c.Auth.AdminPassword != "" {
+		c.Auth.AdminPassword = mask
+	}
+	if c.Auth.OIDC.ClientSecret != "" {
+		c.Auth.OIDC.ClientSecret = mask
+	}
+	return c
+}
+
+// dsnPassword matches the password in a key=value connection string
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// ConnString returns the lib/pq connection string for the configured
+// database, or for the named database on the same server when dbname is
+// non-empty
+func (d DatabaseConfig) ConnString(dbname string) string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	if dbname == "" {
+		dbname = d.Name
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quote(d.Host), d.Port, quote(d.User), quote(d.Password), quote(dbname), d.SSLMode)
+}
+
+// quote escapes a value for a key=value connection string
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
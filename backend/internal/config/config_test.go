@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"server":{"addr":":9001"},"database":{"port":6001}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		file     bool
+		env      map[string]string
+		args     []string
+		wantAddr string
+		wantPort int
+	}{
+		{"defaults", false, nil, nil, ":8081", 5432},
+		{"file over defaults", true, nil, nil, ":9001", 6001},
+		{"env over file", true, map[string]string{"LISTEN_ADDR": ":9002", "DB_PORT": "6002"}, nil, ":9002", 6002},
+		{"flags over env", true, map[string]string{"LISTEN_ADDR": ":9002", "DB_PORT": "6002"},
+			[]string{"--listen", ":9003", "--db-port", "6003"}, ":9003", 6003},
+		{"only the flags given override", true, map[string]string{"LISTEN_ADDR": ":9002"},
+			[]string{"--db-port", "6003"}, ":9002", 6003},
+		{"empty env leaves the file value", true, map[string]string{"LISTEN_ADDR": ""}, nil, ":9001", 6001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("LISTEN_ADDR", "")
+			t.Setenv("DB_PORT", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file {
+				args = append([]string{"--config", file}, args...)
+			}
+
+			cfg, _, err := Load(args)
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if cfg.Server.Addr != tt.wantAddr || cfg.Database.Port != tt.wantPort {
+				t.Errorf("Load() addr %q port %d, want %q and %d", cfg.Server.Addr, cfg.Database.Port, tt.wantAddr, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsWildcardWithCredentials(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+
+	_, _, err := Load(nil)
+	if err == nil || !strings.Contains(err.Error(), "allowed_origins cannot contain * when allow_credentials is true") {
+		t.Errorf("Load() error = %v, want the * with credentials error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"* without credentials", func(c *Config) {
+			c.CORS.AllowedOrigins, c.CORS.AllowCredentials = []string{"*"}, false
+		}, ""},
+		{"* with credentials", func(c *Config) { c.CORS.AllowedOrigins = []string{"*"} },
+			"cors: allowed_origins cannot contain * when allow_credentials is true"},
+		{"origin with a path", func(c *Config) { c.CORS.AllowedOrigins = []string{"https://example.mil/app"} },
+			`cors.allowed_origins: "https://example.mil/app" must be a scheme://host[:port] origin or *`},
+		{"TLS certificate without a key", func(c *Config) { c.Server.TLSCertFile = "cert.pem" },
+			"server: tls_cert_file and tls_key_file must be set together"},
+		{"more idle than open connections", func(c *Config) { c.Database.MaxIdleConns = 30 },
+			"database.max_idle_conns: 30 is more than max_open_conns 25"},
+		{"dsn skips the individual fields", func(c *Config) {
+			c.Database.DSN, c.Database.Host, c.Database.SSLMode = "postgres://db/srd", "", "bogus"
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		wantDSN string
+	}{
+		{"URL DSN", "postgres://app:s3cret@db:5432/srd?sslmode=require",
+			"postgres://app:REDACTED@db:5432/srd?sslmode=require"},
+		{"key=value DSN", "host=db user=app password=s3cret dbname=srd",
+			"host=db user=app password=REDACTED dbname=srd"},
+		{"quoted key=value password", `host=db password='s3 cr\'et' dbname=srd`,
+			"host=db password=REDACTED dbname=srd"},
+		{"no password", "postgres://app@db/srd", "postgres://app@db/srd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.DSN = tt.dsn
+			cfg.Database.Password = "s3cret"
+			cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile = "/etc/tls/cert.pem", "/etc/tls/s3cret.key"
+			cfg.Auth.AdminPassword = "s3cret"
+			cfg.Auth.OIDC.ClientSecret = "s3cret"
+
+			// As printed by --print-config
+			out, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(out), "s3") {
+				t.Errorf("printed config leaks a secret:\n%s", out)
+			}
+
+			var printed Config
+			if err := json.Unmarshal(out, &printed); err != nil {
+				t.Fatal(err)
+			}
+			if printed.Database.DSN != tt.wantDSN {
+				t.Errorf("dsn = %q, want %q", printed.Database.DSN, tt.wantDSN)
+			}
+			if printed.Server.TLSKeyFile != "REDACTED" || printed.Database.Password != "REDACTED" {
+				t.Errorf("tls_key_file = %q, password = %q, want both REDACTED", printed.Server.TLSKeyFile, printed.Database.Password)
+			}
+			if printed.Server.TLSCertFile != "/etc/tls/cert.pem" {
+				t.Errorf("tls_cert_file = %q, want it printed as is", printed.Server.TLSCertFile)
+			}
+			if cfg.Database.Password != "s3cret" || cfg.Server.TLSKeyFile != "/etc/tls/s3cret.key" {
+				t.Error("Redacted() changed the original configuration")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package cors lets browsers on the configured origins call the API. It
+// answers preflight requests itself, before authentication, so every route
+// works cross-origin without registering OPTIONS handlers.
+package cors
+
+import (
+	"net/http"
+	"srd-calendar-project/backend/internal/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	allowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	allowedHeaders = "Authorization, Content-Type, Last-Event-ID, X-Request-Id"
+	exposedHeaders = "X-Request-Id"
+)
+
+// Middleware returns CORS middleware for cfg
+func Middleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := false
+	origins := map[string]bool{}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	maxAge := strconv.Itoa(int(time.Duration(cfg.MaxAge) / time.Second))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// Responses differ by origin, so caches must not share them
+			w.Header().Add("Vary", "Origin")
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !anyOrigin && !origins[strings.ToLower(origin)] {
+				if preflight {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				// Serve the request without CORS headers; the browser
+				// withholds the response from the page
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				h.Set("Access-Control-Expose-Headers", exposedHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", allowedMethods)
+			h.Set("Access-Control-Allow-Headers", allowedHeaders)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -1,71 +1,63 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"srd-calendar-project/backend/internal/config"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
 
+// SchemaVersion identifies the schema built by createTables. Bump it whenever
+// a table, column or index is added so readiness checks can tell whether the
+// database has caught up.
+const SchemaVersion = 9
+
+// connInfo is the connection string used for DB, kept for components such as
+// LISTEN/NOTIFY listeners that need their own dedicated connection
+var connInfo string
+
 // InitDB initializes the database connection
-func InitDB() error {
-	// Get database configuration from environment variables with defaults
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "postgres")
-	dbname := getEnv("DB_NAME", "test_db")
-
-	// First, connect to the default postgres database to create our database if needed
-	defaultPsql := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=postgres sslmode=disable",
-		host, port, user, password)
-	
-	defaultDB, err := sql.Open("postgres", defaultPsql)
-	if err != nil {
-		return fmt.Errorf("failed to connect to postgres database: %w", err)
-	}
-	
-	// Check if database exists, create if not
-	var exists bool
-	err = defaultDB.QueryRow("SELECT EXISTS(SELECT datname FROM pg_catalog.pg_database WHERE datname = $1)", dbname).Scan(&exists)
-	if err != nil {
-		defaultDB.Close()
-		return fmt.Errorf("failed to check if database exists: %w", err)
-	}
-	
-	if !exists {
-		_, err = defaultDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbname))
-		if err != nil {
-			defaultDB.Close()
-			return fmt.Errorf("failed to create database: %w", err)
+func InitDB(ctx context.Context, cfg config.DatabaseConfig) error {
+	// Without a DSN, first connect to the default postgres database to create our database if needed
+	if cfg.DSN == "" && cfg.CreateDatabase {
+		if err := createDatabase(ctx, cfg); err != nil {
+			return err
 		}
-		log.Printf("Created database %s", dbname)
 	}
-	defaultDB.Close()
 
 	// Now connect to our specific database
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	psqlInfo := cfg.ConnString("")
 
+	connInfo = psqlInfo
+	var err error
 	DB, err = sql.Open("postgres", psqlInfo)
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
 	}
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
 
 	// Test the connection
-	err = DB.Ping()
+	err = DB.PingContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Printf("Successfully connected to database %s on %s:%s", dbname, host, port)
+	if cfg.DSN != "" {
+		slog.InfoContext(ctx, "Successfully connected to database from DSN")
+	} else {
+		slog.InfoContext(ctx, "Successfully connected to database", "database", cfg.Name, "host", cfg.Host, "port", cfg.Port)
+	}
 	
 	// Create tables if they don't exist
-	err = createTables()
+	err = createTables(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
@@ -73,16 +65,40 @@ func InitDB() error {
 	return nil
 }
 
-// getEnv gets an environment variable with a fallback default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// createDatabase creates the configured database if it does not exist yet
+func createDatabase(ctx context.Context, cfg config.DatabaseConfig) error {
+	defaultDB, err := sql.Open("postgres", cfg.ConnString("postgres"))
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres database: %w", err)
 	}
-	return defaultValue
+	defer defaultDB.Close()
+	
+	// Check if database exists, create if not
+	var exists bool
+	err = defaultDB.QueryRowContext(ctx, "SELECT EXISTS(SELECT datname FROM pg_catalog.pg_database WHERE datname = $1)", cfg.Name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check if database exists: %w", err)
+	}
+	
+	if !exists {
+		_, err = defaultDB.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", pq.QuoteIdentifier(cfg.Name)))
+		if err != nil {
+			return fmt.Errorf("failed to create database: %w", err)
+		}
+		slog.InfoContext(ctx, "Created database", "database", cfg.Name)
+	}
+	return nil
+}
+
+// ConnInfo returns the connection string of the application database
+func ConnInfo() string {
+	return connInfo
 }
 
 // createTables creates the database schema
-func createTables() error {
+func createTables(ctx context.Context) error {
+	warnings := 0
+
 	// Create each table separately to better handle errors
 	tables := []string{
 		`CREATE TABLE IF NOT EXISTS exercises (
@@ -109,6 +125,8 @@ func createTables() error {
 			exercise_id INTEGER REFERENCES exercises(id) ON DELETE CASCADE,
 			name VARCHAR(255) NOT NULL,
 			learning_objectives TEXT,
+			poc VARCHAR(255),
+			sort_order INTEGER NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE TABLE IF NOT EXISTS teams (
@@ -121,6 +139,11 @@ func createTables() error {
 			status_start TIMESTAMP,
 			status_end TIMESTAMP,
 			comments TEXT,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			readiness_weight INTEGER NOT NULL DEFAULT 1,
+			status_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			status_confirmed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			stale_flagged_at TIMESTAMP,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
@@ -159,6 +182,147 @@ func createTables() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(task_id, team_id)
 		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id SERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret VARCHAR(255) NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			description TEXT,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			subscription_id INTEGER REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+			event_type VARCHAR(100) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_attempt_at TIMESTAMP,
+			response_status INTEGER,
+			last_error TEXT,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+			id SERIAL PRIMARY KEY,
+			delivery_id INTEGER REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+			attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			response_status INTEGER,
+			error TEXT,
+			duration_ms INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS change_log (
+			id BIGSERIAL PRIMARY KEY,
+			type VARCHAR(100) NOT NULL,
+			exercise_id INTEGER,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id SERIAL PRIMARY KEY,
+			username VARCHAR(255) NOT NULL UNIQUE,
+			display_name VARCHAR(255),
+			email VARCHAR(255),
+			password_hash TEXT,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			last_login_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) NOT NULL UNIQUE,
+			user_agent TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id SERIAL PRIMARY KEY,
+			actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			actor_username VARCHAR(255) NOT NULL,
+			action VARCHAR(100) NOT NULL,
+			exercise_id INTEGER,
+			payload JSONB,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS role_grants (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			role VARCHAR(50) NOT NULL,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE CASCADE,
+			division_id INTEGER REFERENCES divisions(id) ON DELETE CASCADE,
+			team_id INTEGER REFERENCES teams(id) ON DELETE CASCADE,
+			granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_type VARCHAR(20) NOT NULL,
+			prefix VARCHAR(20) NOT NULL,
+			key_hash VARCHAR(64) NOT NULL UNIQUE,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			scopes TEXT[] NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			last_used_at TIMESTAMP,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS oidc_logins (
+			state_hash VARCHAR(64) PRIMARY KEY,
+			code_verifier TEXT NOT NULL,
+			nonce TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS escalation_steps (
+			id SERIAL PRIMARY KEY,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE CASCADE,
+			status VARCHAR(50) NOT NULL,
+			after_minutes INTEGER NOT NULL,
+			notify VARCHAR(50) NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS escalations (
+			id SERIAL PRIMARY KEY,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE CASCADE,
+			team_id INTEGER REFERENCES teams(id) ON DELETE CASCADE,
+			status VARCHAR(50) NOT NULL,
+			status_since TIMESTAMP NOT NULL,
+			after_minutes INTEGER NOT NULL,
+			notify VARCHAR(50) NOT NULL,
+			recipients TEXT[] NOT NULL DEFAULT '{}',
+			triggered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			acknowledged_at TIMESTAMP,
+			acknowledged_by VARCHAR(255),
+			note TEXT,
+			UNIQUE(team_id, status_since, status, after_minutes, notify)
+		)`,
+		`CREATE TABLE IF NOT EXISTS team_status_periods (
+			id SERIAL PRIMARY KEY,
+			team_id INTEGER REFERENCES teams(id) ON DELETE CASCADE,
+			exercise_id INTEGER REFERENCES exercises(id) ON DELETE CASCADE,
+			status VARCHAR(50) NOT NULL,
+			status_start TIMESTAMP,
+			status_end TIMESTAMP,
+			started_at TIMESTAMP NOT NULL,
+			ended_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS sitrep_templates (
+			exercise_id INTEGER PRIMARY KEY REFERENCES exercises(id) ON DELETE CASCADE,
+			markdown TEXT NOT NULL DEFAULT '',
+			html TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
 	}
 	
 	// Create indexes
@@ -172,11 +336,25 @@ func createTables() error {
 		`CREATE INDEX IF NOT EXISTS idx_tasks_exercise ON tasks(exercise_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_task_teams_task ON task_teams(task_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_task_teams_team ON task_teams(team_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_change_log_created ON change_log(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_exercise ON audit_log(exercise_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_role_grants_user ON role_grants(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_escalation_steps_exercise ON escalation_steps(exercise_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_escalations_exercise ON escalations(exercise_id, triggered_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_team_status_periods_team ON team_status_periods(team_id, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_team_status_periods_exercise ON team_status_periods(exercise_id, started_at)`,
 	}
 	
 	// Execute table creation
 	for _, table := range tables {
-		_, err := DB.Exec(table)
+		_, err := DB.ExecContext(ctx, table)
 		if err != nil {
 			return fmt.Errorf("failed to create table: %w", err)
 		}
@@ -184,15 +362,16 @@ func createTables() error {
 	
 	// Execute index creation
 	for _, index := range indexes {
-		_, err := DB.Exec(index)
+		_, err := DB.ExecContext(ctx, index)
 		if err != nil {
 			// Log index creation errors but don't fail
-			log.Printf("Warning: failed to create index: %v", err)
+			slog.WarnContext(ctx, "Failed to create index", "error", err)
+			warnings++
 		}
 	}
 
 	// Add exercise_event_poc column if it doesn't exist
-	_, err := DB.Exec(`
+	_, err := DB.ExecContext(ctx, `
 		DO $$ 
 		BEGIN
 			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
@@ -202,11 +381,12 @@ func createTables() error {
 		END $$;
 	`)
 	if err != nil {
-		log.Printf("Warning: failed to add exercise_event_poc column: %v", err)
+		slog.WarnContext(ctx, "Failed to add exercise_event_poc column", "error", err)
+		warnings++
 	}
 
 	// Add learning_objectives column to divisions if it doesn't exist
-	_, err = DB.Exec(`
+	_, err = DB.ExecContext(ctx, `
 		DO $$ 
 		BEGIN
 			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
@@ -216,11 +396,12 @@ func createTables() error {
 		END $$;
 	`)
 	if err != nil {
-		log.Printf("Warning: failed to add learning_objectives column: %v", err)
+		slog.WarnContext(ctx, "Failed to add learning_objectives column", "error", err)
+		warnings++
 	}
 
 	// Add priority column to exercises if it doesn't exist
-	_, err = DB.Exec(`
+	_, err = DB.ExecContext(ctx, `
 		DO $$ 
 		BEGIN
 			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
@@ -230,11 +411,12 @@ func createTables() error {
 		END $$;
 	`)
 	if err != nil {
-		log.Printf("Warning: failed to add priority column: %v", err)
+		slog.WarnContext(ctx, "Failed to add priority column", "error", err)
+		warnings++
 	}
 
 	// Add team_id column to tasks if it doesn't exist
-	_, err = DB.Exec(`
+	_, err = DB.ExecContext(ctx, `
 		DO $$ 
 		BEGIN
 			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
@@ -244,13 +426,235 @@ func createTables() error {
 		END $$;
 	`)
 	if err != nil {
-		log.Printf("Warning: failed to add team_id column to tasks: %v", err)
+		slog.WarnContext(ctx, "Failed to add team_id column to tasks", "error", err)
+		warnings++
+	}
+
+	// Link users to their single sign-on identity
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'users' AND column_name = 'oidc_subject') THEN
+				ALTER TABLE users ADD COLUMN oidc_issuer VARCHAR(255);
+				ALTER TABLE users ADD COLUMN oidc_subject VARCHAR(255);
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add oidc columns to users", "error", err)
+		warnings++
+	}
+	_, err = DB.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc ON users(oidc_issuer, oidc_subject)`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to create index", "error", err)
+		warnings++
+	}
+
+	// Service accounts own service API keys and cannot sign in
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'users' AND column_name = 'service') THEN
+				ALTER TABLE users ADD COLUMN service BOOLEAN NOT NULL DEFAULT FALSE;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add service column to users", "error", err)
+		warnings++
+	}
+
+	// Record whether a grant was made by hand or mapped from identity provider claims
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'role_grants' AND column_name = 'source') THEN
+				ALTER TABLE role_grants ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'manual';
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add source column to role_grants", "error", err)
+		warnings++
+	}
+
+	// Divisions and teams are listed in an explicit order; existing rows keep
+	// their ID order until they are reordered
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'divisions' AND column_name = 'sort_order') THEN
+				ALTER TABLE divisions ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'teams' AND column_name = 'sort_order') THEN
+				ALTER TABLE teams ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add sort_order columns", "error", err)
+		warnings++
+	}
+
+	// Teams count equally toward division and exercise readiness until weighted
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'teams' AND column_name = 'readiness_weight') THEN
+				ALTER TABLE teams ADD COLUMN readiness_weight INTEGER NOT NULL DEFAULT 1;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add readiness_weight column", "error", err)
+		warnings++
+	}
+
+	// Existing teams have held their status since their last recorded status
+	// change, or since they were created
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'teams' AND column_name = 'status_changed_at') THEN
+				ALTER TABLE teams ADD COLUMN status_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+				UPDATE teams t SET status_changed_at = COALESCE(
+					(SELECT MAX(a.created_at) FROM audit_log a
+					 WHERE a.action = 'team.status_changed' AND (a.payload->'team'->>'id')::int = t.id),
+					t.created_at, CURRENT_TIMESTAMP);
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add status_changed_at column", "error", err)
+		warnings++
+	}
+
+	// A status counts as confirmed when it was last written; the stale status
+	// check flags teams whose confirmation is too old
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'teams' AND column_name = 'status_confirmed_at') THEN
+				ALTER TABLE teams ADD COLUMN status_confirmed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+				UPDATE teams SET status_confirmed_at = COALESCE(updated_at, created_at, CURRENT_TIMESTAMP);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'teams' AND column_name = 'stale_flagged_at') THEN
+				ALTER TABLE teams ADD COLUMN stale_flagged_at TIMESTAMP;
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add status confirmation columns", "error", err)
+		warnings++
+	}
+
+	// Escalation policies notify the division lead
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'divisions' AND column_name = 'poc') THEN
+				ALTER TABLE divisions ADD COLUMN poc VARCHAR(255);
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add poc column to divisions", "error", err)
+		warnings++
 	}
 
-	log.Println("Database schema created/verified successfully")
+	// Teams without status history get it rebuilt from the audit log: the
+	// status before their first recorded change from when they were created,
+	// then each recorded change or expiry
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF EXISTS (SELECT 1 FROM teams t
+				WHERE NOT EXISTS (SELECT 1 FROM team_status_periods p WHERE p.team_id = t.id)) THEN
+				INSERT INTO team_status_periods (team_id, exercise_id, status, started_at, ended_at)
+				SELECT h.team_id, h.exercise_id, h.status, h.started_at,
+				       LEAD(h.started_at) OVER (PARTITION BY h.team_id ORDER BY h.started_at, h.seq)
+				FROM (
+					SELECT t.id AS team_id, t.exercise_id, COALESCE(
+						(SELECT a.payload->>'previous_status' FROM audit_log a
+						 WHERE a.action IN ('team.status_changed', 'team.status_expired')
+						   AND (a.payload->'team'->>'id')::int = t.id
+						 ORDER BY a.created_at, a.id LIMIT 1),
+						t.status, 'green') AS status,
+						COALESCE(t.created_at, CURRENT_TIMESTAMP) AS started_at, 0 AS seq
+					FROM teams t
+					UNION ALL
+					SELECT t.id, t.exercise_id, COALESCE(a.payload->'team'->>'status', 'green'), a.created_at, a.id
+					FROM audit_log a
+					JOIN teams t ON t.id = (a.payload->'team'->>'id')::int
+					WHERE a.action IN ('team.status_changed', 'team.status_expired')
+				) h
+				WHERE NOT EXISTS (SELECT 1 FROM team_status_periods p WHERE p.team_id = h.team_id);
+			END IF;
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to build team status history", "error", err)
+		warnings++
+	}
+
+	// Status periods record the window their status applied in. Open periods
+	// recorded before that take their team's current window; closed ones are
+	// left without one.
+	_, err = DB.ExecContext(ctx, `
+		DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+				WHERE table_name = 'team_status_periods' AND column_name = 'status_start') THEN
+				ALTER TABLE team_status_periods ADD COLUMN status_start TIMESTAMP, ADD COLUMN status_end TIMESTAMP;
+			END IF;
+			UPDATE team_status_periods p
+			SET status_start = t.status_start, status_end = t.status_end
+			FROM teams t
+			WHERE p.team_id = t.id AND p.ended_at IS NULL
+			  AND p.status_start IS NULL AND p.status_end IS NULL
+			  AND (t.status_start IS NOT NULL OR t.status_end IS NOT NULL);
+		END $$;
+	`)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to add status window to team status history", "error", err)
+		warnings++
+	}
+
+	// Only a schema built without warnings counts as current
+	if warnings > 0 {
+		slog.WarnContext(ctx, "Database schema has problems; version not recorded", "problems", warnings, "version", SchemaVersion)
+		return nil
+	}
+	_, err = DB.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, SchemaVersion)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record schema version", "error", err)
+	}
+
+	slog.InfoContext(ctx, "Database schema created/verified successfully")
 	return nil
 }
 
+// SchemaCurrent reports whether the database schema has been brought up to
+// SchemaVersion
+func SchemaCurrent(ctx context.Context) (bool, error) {
+	var version int
+	err := DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return false, err
+	}
+	return version >= SchemaVersion, nil
+}
+
 // CloseDB closes the database connection
 func CloseDB() {
 	if DB != nil {
@@ -0,0 +1,27 @@
+// Package escalation runs the exercises' escalation policies. A policy is a
+// list of steps such as "red for 2 hours notifies the division POC". Each run
+// triggers the steps that have come due for the teams of running exercises;
+// a triggered step is recorded and emitted as a team.escalated change, which
+// reaches its recipients through webhooks and the live stream. A step
+// triggers once per status held, and acknowledging an escalation stops the
+// remaining steps until the team's status changes.
+package escalation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"srd-calendar-project/backend/internal/repository"
+)
+
+// Run triggers the escalation steps that are due
+func Run(ctx context.Context) error {
+	triggered, ok := repository.RunEscalations(ctx)
+	if !ok {
+		return errors.New("running escalation policies failed")
+	}
+	if triggered > 0 {
+		slog.InfoContext(ctx, "Escalations triggered", "count", triggered)
+	}
+	return nil
+}
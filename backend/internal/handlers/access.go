@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
+)
+
+// authorize reports whether the signed-in user may perform perm on s, and
+// writes a 403 response when they may not
+func authorize(w http.ResponseWriter, r *http.Request, perm string, s authz.Scope) bool {
+	if !authz.Can(r.Context(), perm, s) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// readableExercises drops the exercises the signed-in user cannot see
+func readableExercises(ctx context.Context, exercises []models.Exercise) []models.Exercise {
+	visible := []models.Exercise{}
+	for _, ex := range exercises {
+		if authz.Can(ctx, authz.Read, authz.Scope{ExerciseID: ex.ID}) {
+			visible = append(visible, ex)
+		}
+	}
+	return visible
+}
+
+// authorizeTeams checks perm against each team a task is being assigned to.
+// Teams must belong to exerciseID.
+func authorizeTeams(w http.ResponseWriter, r *http.Request, perm string, exerciseID int, teamIDs []int) bool {
+	for _, teamID := range teamIDs {
+		scope, found := authz.TeamScope(r.Context(), teamID)
+		if !found || scope.ExerciseID != exerciseID {
+			http.Error(w, "Team not found", http.StatusBadRequest)
+			return false
+		}
+		if !authorize(w, r, perm, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// teamEdited reports whether saving team over stored would change it
+func teamEdited(stored, team models.Team) bool {
+	return stored.POC != team.POC || stored.Status != team.Status || stored.Comments != team.Comments ||
+		!stored.StatusStart.Equal(team.StatusStart) || !stored.StatusEnd.Equal(team.StatusEnd)
+}
+
+// authorizeExerciseTeams checks the teams nested in an exercise update. Each
+// must belong to the exercise (422 otherwise), and each one that the update
+// changes needs team:update.
+func authorizeExerciseTeams(w http.ResponseWriter, r *http.Request, exercise models.Exercise) bool {
+	if len(exercise.Divisions) == 0 {
+		return true
+	}
+	teams, ok := repository.GetTeamsForExercise(r.Context(), exercise.ID)
+	if !ok {
+		http.Error(w, "Failed to load teams", http.StatusInternalServerError)
+		return false
+	}
+	stored := make(map[int]models.Team, len(teams))
+	for _, team := range teams {
+		stored[team.ID] = team
+	}
+
+	var errs validation.Errors
+	var edited []models.Team
+	for i, div := range exercise.Divisions {
+		for j, team := range div.Teams {
+			current, found := stored[team.ID]
+			if !found {
+				errs.Add(fmt.Sprintf("divisions[%d].teams[%d].id", i, j), validation.CodeNotFound, "team is not in this exercise")
+				continue
+			}
+			if teamEdited(current, team) {
+				edited = append(edited, current)
+			}
+		}
+	}
+	if !validErrors(w, errs) {
+		return false
+	}
+	for _, team := range edited {
+		if !authorize(w, r, authz.TeamUpdate, authz.Scope{ExerciseID: exercise.ID, DivisionID: team.DivisionID, TeamID: team.ID}) {
+			return false
+		}
+	}
+	return true
+}
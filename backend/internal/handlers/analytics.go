@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"srd-calendar-project/backend/internal/analytics"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
+	"srd-calendar-project/backend/internal/repository"
+	"strconv"
+	"time"
+)
+
+// analyticsRange parses ?from= and ?to= as the first and last days to cover,
+// either defaulting to the given ones. It returns the range as midnights,
+// with the end exclusive.
+func analyticsRange(w http.ResponseWriter, r *http.Request, from, to time.Time) (time.Time, time.Time, bool) {
+	first, err := models.ParseDate("from", r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid from; use YYYY-MM-DD", http.StatusBadRequest)
+		return from, to, false
+	}
+	last, err := models.ParseDate("to", r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid to; use YYYY-MM-DD", http.StatusBadRequest)
+		return from, to, false
+	}
+	if !first.IsZero() {
+		from = readiness.Day(first)
+	}
+	if !last.IsZero() {
+		to = readiness.Day(last).AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// analyticsFormat returns the requested ?format=, json or csv
+func analyticsFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		return "json", true
+	case "csv":
+		return format, true
+	default:
+		http.Error(w, "Invalid format; use json or csv", http.StatusBadRequest)
+		return "", false
+	}
+}
+
+// writeCSV writes rows as a CSV download
+func writeCSV(w http.ResponseWriter, r *http.Request, filename string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	out := csv.NewWriter(w)
+	if err := out.WriteAll(rows); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing CSV", "error", err)
+	}
+}
+
+var statusTimeHeader = []string{
+	"tracked_hours", "green_hours", "yellow_hours", "red_hours", "unknown_hours",
+	"green_percent", "yellow_percent", "red_percent", "red_episodes", "recovered", "mttr_hours",
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// statusTimeRow formats time in status and recovery for CSV, in the order of
+// statusTimeHeader
+func statusTimeRow(t models.StatusTime, rec models.Recovery) []string {
+	mttr := ""
+	if rec.MTTRHours != nil {
+		mttr = number(*rec.MTTRHours)
+	}
+	return []string{
+		number(t.TrackedHours), number(t.GreenHours), number(t.YellowHours), number(t.RedHours), number(t.UnknownHours),
+		number(t.GreenPercent), number(t.YellowPercent), number(t.RedPercent),
+		strconv.Itoa(rec.RedEpisodes), strconv.Itoa(rec.Recovered), mttr,
+	}
+}
+
+// GetExerciseAnalytics reports how an exercise's statuses held up: time in
+// each status per team, division and exercise, mean time to recover from red
+// and daily readiness. The range defaults to the exercise's days. format=csv
+// returns the status table, or the daily table with view=daily.
+func GetExerciseAnalytics(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+	format, ok := analyticsFormat(w, r)
+	if !ok {
+		return
+	}
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "status" && view != "daily" {
+		http.Error(w, "Invalid view; use status or daily", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+	from, to := analytics.Range(exercise)
+	from, to, ok = analyticsRange(w, r, from, to)
+	if !ok {
+		return
+	}
+
+	periods, ok := repository.GetStatusPeriods(r.Context(), id)
+	if !ok {
+		http.Error(w, "Failed to load status history", http.StatusInternalServerError)
+		return
+	}
+	report := analytics.Exercise(exercise, periods, from, to, time.Now().UTC())
+	if format == "json" {
+		writeJSON(w, report)
+		return
+	}
+
+	if view == "daily" {
+		rows := [][]string{{"date", "division", "green_percent", "yellow_percent", "red_percent"}}
+		for _, day := range report.Daily {
+			rows = append(rows, []string{day.Date, "", number(day.GreenPercent), number(day.YellowPercent), number(day.RedPercent)})
+			for _, div := range day.Divisions {
+				rows = append(rows, []string{day.Date, div.Name, number(div.GreenPercent), number(div.YellowPercent), number(div.RedPercent)})
+			}
+		}
+		writeCSV(w, r, fmt.Sprintf("exercise-%d-daily.csv", id), rows)
+		return
+	}
+
+	rows := [][]string{append([]string{"level", "division", "team"}, statusTimeHeader...)}
+	rows = append(rows, append([]string{"exercise", "", ""}, statusTimeRow(report.Time, report.Recovery)...))
+	for _, div := range report.Divisions {
+		rows = append(rows, append([]string{"division", div.Name, ""}, statusTimeRow(div.Time, div.Recovery)...))
+		for _, team := range div.Teams {
+			rows = append(rows, append([]string{"team", div.Name, team.Name}, statusTimeRow(team.Time, team.Recovery)...))
+		}
+	}
+	writeCSV(w, r, fmt.Sprintf("exercise-%d-analytics.csv", id), rows)
+}
+
+// GetAnalyticsTrend compares the readable exercises that have started, in
+// start order, each over its whole run. from and to keep the exercises that
+// overlap those days.
+func GetAnalyticsTrend(w http.ResponseWriter, r *http.Request) {
+	format, ok := analyticsFormat(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := analyticsRange(w, r, time.Time{}, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	exercises := readableExercises(r.Context(), repository.GetAllExercises(r.Context()))
+	sort.SliceStable(exercises, func(i, j int) bool { return exercises[i].StartDate.Before(exercises[j].StartDate) })
+
+	trend := []models.ExerciseTrend{}
+	for _, ex := range exercises {
+		start, end := analytics.Range(ex)
+		if start.After(now) || !start.Before(to) || !end.After(from) {
+			continue
+		}
+		periods, ok := repository.GetStatusPeriods(r.Context(), ex.ID)
+		if !ok {
+			http.Error(w, "Failed to load status history", http.StatusInternalServerError)
+			return
+		}
+		trend = append(trend, analytics.Trend(ex, periods, now))
+	}
+	if format == "json" {
+		writeJSON(w, trend)
+		return
+	}
+
+	rows := [][]string{append([]string{"exercise", "start_date", "end_date"}, statusTimeHeader...)}
+	for _, ex := range trend {
+		rows = append(rows, append([]string{ex.Name, ex.StartDate.Format("2006-01-02"), ex.EndDate.Format("2006-01-02")},
+			statusTimeRow(ex.Time, ex.Recovery)...))
+	}
+	writeCSV(w, r, "exercise-trend.csv", rows)
+}
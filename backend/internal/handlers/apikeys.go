@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultAPIKeyLifetime = 90 * 24 * time.Hour
+	maxAPIKeyLifetime     = 365 * 24 * time.Hour
+)
+
+var serviceNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// rejectAPIKey stops a request authenticated with an API key from managing
+// API keys, so a leaked key cannot be used to mint more
+func rejectAPIKey(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := auth.APIKeyFromContext(r.Context()); ok {
+		http.Error(w, "API keys cannot be managed with an API key", http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// GetAPIKeys returns the signed-in user's keys and the service keys they
+// created. User administrators can pass all=true to see every key.
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if rejectAPIKey(w, r) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	userID := user.ID
+	if r.URL.Query().Get("all") == "true" {
+		if !authorize(w, r, authz.UsersManage, authz.Scope{}) {
+			return
+		}
+		userID = 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth.GetAPIKeys(r.Context(), userID))
+}
+
+// CreateAPIKey issues a key. Personal keys act as the signed-in user.
+// Service keys act as a service account, which is created from the key name
+// unless user_id names an existing one; only user administrators can issue
+// them, and the account gets its access through grants like any user. The
+// response is the only time the key is returned.
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if rejectAPIKey(w, r) {
+		return
+	}
+
+	var body struct {
+		Name          string     `json:"name" validate:"required,max=255"`
+		Type          string     `json:"type" validate:"oneof=personal service"`
+		Scopes        []string   `json:"scopes" validate:"required"`
+		ExpiresAt     *time.Time `json:"expires_at"`
+		ExpiresInDays int        `json:"expires_in_days"`
+		UserID        int        `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Type == "" {
+		body.Type = auth.KeyPersonal
+	}
+	var errs validation.Errors
+	for i, scope := range body.Scopes {
+		if !auth.IsAPIKeyScope(scope) {
+			errs.Add(fmt.Sprintf("scopes[%d]", i), validation.CodeOneOf, fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(defaultAPIKeyLifetime)
+	switch {
+	case body.ExpiresAt != nil:
+		expiresAt = *body.ExpiresAt
+	case body.ExpiresInDays != 0:
+		expiresAt = now.AddDate(0, 0, body.ExpiresInDays)
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(maxAPIKeyLifetime)) {
+		errs.Add("expires_at", validation.CodeInvalid, "must be in the future and at most 365 days away")
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+
+	current, _ := auth.UserFromContext(r.Context())
+	key := models.APIKey{
+		Name:      body.Name,
+		Type:      body.Type,
+		UserID:    current.ID,
+		Username:  current.Username,
+		Scopes:    body.Scopes,
+		ExpiresAt: expiresAt,
+		CreatedBy: &current.ID,
+	}
+
+	if body.Type == auth.KeyService {
+		if !authorize(w, r, authz.UsersManage, authz.Scope{}) {
+			return
+		}
+
+		account, status, err := serviceAccount(r.Context(), body.UserID, body.Name)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		key.UserID, key.Username = account.ID, account.Username
+	}
+
+	created, raw, err := auth.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		models.APIKey
+		Key string `json:"key"`
+	}{created, raw})
+}
+
+// RevokeAPIKey revokes a key. Users can revoke their own keys and the
+// service keys they created; user administrators can revoke any key.
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if rejectAPIKey(w, r) {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	key, found := auth.GetAPIKey(r.Context(), id)
+	if !found {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	current, _ := auth.UserFromContext(r.Context())
+	owned := (key.Type == auth.KeyPersonal && key.UserID == current.ID) ||
+		(key.CreatedBy != nil && *key.CreatedBy == current.ID)
+	if !owned && !authorize(w, r, authz.UsersManage, authz.Scope{}) {
+		return
+	}
+
+	if !auth.RevokeAPIKey(r.Context(), id) {
+		http.Error(w, "API key is already revoked", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serviceAccount returns the service account a new service key acts as:
+// the existing account userID, or a new one named after the key
+func serviceAccount(ctx context.Context, userID int, keyName string) (models.User, int, error) {
+	if userID != 0 {
+		account, found := auth.GetUserByID(ctx, userID)
+		if !found || !account.Service {
+			return account, http.StatusBadRequest, fmt.Errorf("user %d is not a service account", userID)
+		}
+		return account, 0, nil
+	}
+
+	slug := strings.Trim(serviceNameCleaner.ReplaceAllString(strings.ToLower(keyName), "-"), "-")
+	if slug == "" {
+		return models.User{}, http.StatusBadRequest, fmt.Errorf("name must contain letters or digits")
+	}
+	username := "svc-" + slug
+	account, err := auth.CreateUser(ctx, models.User{
+		Username:    username,
+		DisplayName: keyName,
+		Active:      true,
+		Service:     true,
+	}, "")
+	if err == auth.ErrUsernameTaken {
+		return account, http.StatusConflict, fmt.Errorf("service account %q already exists; pass its user_id", username)
+	}
+	if err != nil {
+		return account, http.StatusInternalServerError, fmt.Errorf("failed to create service account")
+	}
+	return account, 0, nil
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"srd-calendar-project/backend/internal/audit"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/oidc"
+	"srd-calendar-project/backend/internal/validation"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Login checks a username and password and starts a session. The session
+// token is set as an HttpOnly cookie for browsers and also returned in the
+// body for clients that send it as a bearer token.
+func Login(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := auth.Authenticate(r.Context(), credentials.Username, credentials.Password)
+	if !ok {
+		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	token, expiresAt, err := auth.CreateSession(r.Context(), user.ID, r.UserAgent())
+	if err != nil {
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	setCookie(w, r, auth.SessionCookie, token)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":       user,
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// Logout ends the current session and clears the session cookie
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if token := auth.TokenFromContext(r.Context()); token != "" {
+		auth.DeleteSession(r.Context(), token)
+	}
+
+	clearCookie(w, r, auth.SessionCookie)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAuthConfig tells the sign-in page which login methods are available
+func GetAuthConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"local": true,
+		"oidc": map[string]interface{}{
+			"enabled": oidc.Enabled(),
+			"name":    oidc.ProviderName(),
+		},
+	})
+}
+
+// StartOIDCLogin begins a single sign-on login. It returns the identity
+// provider URL for the browser to navigate to, rather than redirecting, so
+// the sign-in page can call it through a development proxy.
+func StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if !oidc.Enabled() {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	authURL, state, err := oidc.Begin(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error starting single sign-on", "error", err)
+		http.Error(w, "Identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+	setCookie(w, r, oidc.StateCookie, state)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"authorization_url": authURL})
+}
+
+// OIDCCallback handles the identity provider's redirect back after sign-in,
+// starts a session and sends the browser on to the application
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if !oidc.Enabled() {
+		http.Error(w, "Single sign-on is not configured", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidc.StateCookie)
+	clearCookie(w, r, oidc.StateCookie)
+
+	if providerError := query.Get("error"); providerError != "" {
+		slog.ErrorContext(r.Context(), "Identity provider returned error", "error", providerError, "description", query.Get("error_description"))
+		http.Redirect(w, r, oidc.PostLoginURL("provider_error"), http.StatusFound)
+		return
+	}
+	if err != nil || state == "" || cookie.Value != state {
+		http.Redirect(w, r, oidc.PostLoginURL("invalid_state"), http.StatusFound)
+		return
+	}
+
+	user, err := oidc.Finish(r.Context(), state, query.Get("code"))
+	switch {
+	case err == oidc.ErrLoginExpired:
+		http.Redirect(w, r, oidc.PostLoginURL("expired"), http.StatusFound)
+		return
+	case err == oidc.ErrUsernameConflict:
+		http.Redirect(w, r, oidc.PostLoginURL("username_conflict"), http.StatusFound)
+		return
+	case err == oidc.ErrUserInactive:
+		http.Redirect(w, r, oidc.PostLoginURL("inactive"), http.StatusFound)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Error completing single sign-on", "error", err)
+		http.Redirect(w, r, oidc.PostLoginURL("failed"), http.StatusFound)
+		return
+	}
+
+	token, _, err := auth.CreateSession(r.Context(), user.ID, r.UserAgent())
+	if err != nil {
+		http.Redirect(w, r, oidc.PostLoginURL("failed"), http.StatusFound)
+		return
+	}
+	setCookie(w, r, auth.SessionCookie, token)
+
+	http.Redirect(w, r, oidc.PostLoginURL(""), http.StatusFound)
+}
+
+// setCookie sets an HttpOnly cookie for the whole site
+func setCookie(w http.ResponseWriter, r *http.Request, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearCookie tells the browser to drop a cookie set by setCookie
+func clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// GetCurrentUser returns the signed-in user
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword changes the signed-in user's password after checking the
+// current one, and signs out their other sessions
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	if !auth.CheckPassword(user.PasswordHash, body.CurrentPassword) {
+		http.Error(w, "Current password is incorrect", http.StatusForbidden)
+		return
+	}
+	if err := auth.ValidatePassword(body.NewPassword); err != nil {
+		validation.Write(w, validation.Errors{{Field: "new_password", Code: validation.CodeInvalid, Message: err.Error()}})
+		return
+	}
+
+	if err := auth.SetPassword(r.Context(), user.ID, body.NewPassword); err != nil {
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+	auth.DeleteUserSessions(r.Context(), user.ID, auth.TokenFromContext(r.Context()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUsers returns all user accounts
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth.GetUsers(r.Context()))
+}
+
+// CreateUser creates a local user account
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		models.User
+		Password string `json:"password"`
+	}
+	body.Active = true
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	body.Service = false
+	body.Username = strings.TrimSpace(body.Username)
+	var errs validation.Errors
+	if body.Username == "" {
+		errs.Add("username", validation.CodeRequired, "is required")
+	}
+	if err := auth.ValidatePassword(body.Password); err != nil {
+		errs.Add("password", validation.CodeInvalid, err.Error())
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+
+	user, err := auth.CreateUser(r.Context(), body.User, body.Password)
+	if err == auth.ErrUsernameTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateUser updates a user's display name, email and active flag.
+// Deactivating a user ends all of their sessions.
+func UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	user.Active = true
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user.ID = id
+	if !validPayload(w, r, &user) {
+		return
+	}
+
+	if !auth.UpdateUser(r.Context(), user) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	updated, _ := auth.GetUserByID(r.Context(), id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteUser deletes a user account. Users cannot delete themselves.
+func DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if current, _ := auth.UserFromContext(r.Context()); current.ID == id {
+		http.Error(w, "You cannot delete your own account", http.StatusBadRequest)
+		return
+	}
+
+	if !auth.DeleteUser(r.Context(), id) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAuditLog returns audit log entries, newest first, filterable by
+// exercise_id, actor_id and action. Pass the last ID of a page as before_id
+// for the next.
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	var filter audit.Filter
+	var err error
+
+	query := r.URL.Query()
+	if v := query.Get("exercise_id"); v != "" {
+		if filter.ExerciseID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid exercise_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("actor_id"); v != "" {
+		if filter.ActorID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid actor_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("before_id"); v != "" {
+		if filter.BeforeID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 || filter.Limit > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+	}
+	filter.Action = query.Get("action")
+
+	if !authorize(w, r, authz.AuditRead, authz.Scope{ExerciseID: filter.ExerciseID}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audit.GetEntries(r.Context(), filter))
+}
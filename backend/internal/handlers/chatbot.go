@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/logging"
+	"srd-calendar-project/backend/internal/metrics"
 	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
 	"srd-calendar-project/backend/internal/repository"
 	"strconv"
 	"strings"
@@ -25,55 +31,65 @@ func EnhancedChatbotHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userMessage := strings.TrimSpace(requestBody.Message)
-	reply := processCommand(userMessage)
+	slog.DebugContext(r.Context(), "Chatbot message received", "message", logging.Sensitive(userMessage))
+	reply := processCommand(r.Context(), userMessage)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"reply": reply})
 }
 
-func processCommand(message string) string {
+func processCommand(ctx context.Context, message string) string {
 	lowerMessage := strings.ToLower(message)
 
 	// Help command
 	if containsAny(lowerMessage, []string{"help", "what can you do", "commands", "?"}) {
+		metrics.ChatbotIntents.Inc("help")
 		return getHelpMessage()
 	}
 
 	// List exercises
 	if containsAny(lowerMessage, []string{"list exercise", "show exercise", "get exercise", "all exercise", "view exercise"}) {
-		return listExercises()
+		metrics.ChatbotIntents.Inc("list_exercises")
+		return listExercises(ctx)
 	}
 
 	// Add exercise with more flexible parsing
 	if containsAny(lowerMessage, []string{"add exercise", "create exercise", "new exercise", "schedule exercise"}) {
-		return addExercise(message)
+		metrics.ChatbotIntents.Inc("add_exercise")
+		return addExercise(ctx, message)
 	}
 
 	// Update exercise
 	if containsAny(lowerMessage, []string{"update exercise", "modify exercise", "change exercise", "edit exercise"}) {
-		return updateExercise(message)
+		metrics.ChatbotIntents.Inc("update_exercise")
+		return updateExercise(ctx, message)
 	}
 
 	// Delete exercise
 	if containsAny(lowerMessage, []string{"delete exercise", "remove exercise", "cancel exercise"}) {
-		return deleteExercise(message)
+		metrics.ChatbotIntents.Inc("delete_exercise")
+		return deleteExercise(ctx, message)
 	}
 
 	// Get specific exercise details
 	if containsAny(lowerMessage, []string{"show exercise", "get exercise", "details of exercise", "info about exercise"}) && containsNumber(lowerMessage) {
-		return getExerciseDetails(message)
+		metrics.ChatbotIntents.Inc("exercise_details")
+		return getExerciseDetails(ctx, message)
 	}
 
 	// Division/team related queries
 	if containsAny(lowerMessage, []string{"division", "team"}) {
-		return handleDivisionQuery(message)
+		metrics.ChatbotIntents.Inc("division_query")
+		return handleDivisionQuery(ctx, message)
 	}
 
 	// Date-related queries
 	if containsAny(lowerMessage, []string{"today", "this week", "next week", "this month", "upcoming"}) {
-		return getExercisesByTimeframe(message)
+		metrics.ChatbotIntents.Inc("timeframe")
+		return getExercisesByTimeframe(ctx, message)
 	}
 
+	metrics.ChatbotIntents.Inc("unknown")
 	return "I'm not sure what you're asking. Type 'help' to see what I can do, or try commands like 'list exercises', 'add exercise', or 'show exercise details'."
 }
 
@@ -113,8 +129,8 @@ func getHelpMessage() string {
 Try asking: "Add exercise REFORPAC IPC from 2025-10-01 to 2025-10-15"`
 }
 
-func listExercises() string {
-	exercises := repository.GetAllExercises()
+func listExercises(ctx context.Context) string {
+	exercises := readableExercises(ctx, repository.GetAllExercises(ctx))
 	if len(exercises) == 0 {
 		return "There are no exercises currently scheduled. You can add one by saying 'Add exercise [name] from [date] to [date]'."
 	}
@@ -153,7 +169,7 @@ func getExerciseStatus(ex models.Exercise) string {
 	return "🟢" // Active
 }
 
-func addExercise(message string) string {
+func addExercise(ctx context.Context, message string) string {
 	// Try to parse exercise details from the message
 	// Patterns: "add exercise [name] from [date] to [date]"
 	// "create exercise called [name] starting [date] ending [date]"
@@ -192,6 +208,10 @@ func addExercise(message string) string {
 		description = strings.TrimSpace(descMatch[1])
 	}
 
+	if !authz.Can(ctx, authz.ExerciseCreate, authz.Scope{}) {
+		return "🚫 You don't have permission to create exercises."
+	}
+
 	newExercise := models.Exercise{
 		Name:        name,
 		StartDate:   startDate,
@@ -199,7 +219,7 @@ func addExercise(message string) string {
 		Description: description,
 	}
 
-	created := repository.CreateExercise(newExercise)
+	created := repository.CreateExercise(ctx, newExercise)
 	return fmt.Sprintf("✅ Successfully created exercise:\n\n**%s** (ID: %d)\n📅 %s to %s\n\nYou can update it by saying 'Update exercise %d [field] to [value]'",
 		created.Name, created.ID, 
 		created.StartDate.Format("Jan 2, 2006"), 
@@ -229,7 +249,7 @@ func parseDate(dateStr string) (time.Time, error) {
 	return time.Now(), fmt.Errorf("could not parse date: %s", dateStr)
 }
 
-func updateExercise(message string) string {
+func updateExercise(ctx context.Context, message string) string {
 	// Extract exercise ID
 	idPattern := regexp.MustCompile(`(?:exercise|id)\s*(\d+)`)
 	idMatch := idPattern.FindStringSubmatch(message)
@@ -242,10 +262,13 @@ func updateExercise(message string) string {
 		return "Invalid exercise ID. Please use a number."
 	}
 
-	exercise, found := repository.GetExerciseByID(id)
-	if !found {
+	exercise, found := repository.GetExerciseByID(ctx, id)
+	if !found || !authz.Can(ctx, authz.Read, authz.Scope{ExerciseID: id}) {
 		return fmt.Sprintf("Exercise with ID %d not found.", id)
 	}
+	if !authz.Can(ctx, authz.ExerciseUpdate, authz.Scope{ExerciseID: id}) {
+		return fmt.Sprintf("🚫 You don't have permission to update exercise %d.", id)
+	}
 
 	lowerMessage := strings.ToLower(message)
 	updated := false
@@ -317,7 +340,7 @@ func updateExercise(message string) string {
 		return "No updates were made. Try: 'Update exercise 1 name to New Name' or 'Update exercise 1 description to New Description'"
 	}
 
-	if repository.UpdateExercise(exercise) {
+	if repository.UpdateExercise(ctx, exercise) {
 		return fmt.Sprintf("✅ Successfully updated exercise %d:\n\n**%s**\n%s", 
 			id, exercise.Name, getExerciseDetailsString(exercise))
 	}
@@ -325,7 +348,7 @@ func updateExercise(message string) string {
 	return "Failed to update exercise."
 }
 
-func deleteExercise(message string) string {
+func deleteExercise(ctx context.Context, message string) string {
 	// Extract ID
 	idPattern := regexp.MustCompile(`\d+`)
 	idMatch := idPattern.FindString(message)
@@ -339,19 +362,22 @@ func deleteExercise(message string) string {
 	}
 
 	// Get exercise details before deleting
-	exercise, found := repository.GetExerciseByID(id)
-	if !found {
+	exercise, found := repository.GetExerciseByID(ctx, id)
+	if !found || !authz.Can(ctx, authz.Read, authz.Scope{ExerciseID: id}) {
 		return fmt.Sprintf("Exercise with ID %d not found.", id)
 	}
+	if !authz.Can(ctx, authz.ExerciseDelete, authz.Scope{ExerciseID: id}) {
+		return fmt.Sprintf("🚫 You don't have permission to delete exercise %d.", id)
+	}
 
-	if repository.DeleteExercise(id) {
+	if repository.DeleteExercise(ctx, id) {
 		return fmt.Sprintf("✅ Successfully deleted exercise:\n**%s** (ID: %d)", exercise.Name, id)
 	}
 
 	return "Failed to delete exercise."
 }
 
-func getExerciseDetails(message string) string {
+func getExerciseDetails(ctx context.Context, message string) string {
 	// Extract ID
 	idPattern := regexp.MustCompile(`\d+`)
 	idMatch := idPattern.FindString(message)
@@ -364,8 +390,8 @@ func getExerciseDetails(message string) string {
 		return "Invalid exercise ID."
 	}
 
-	exercise, found := repository.GetExerciseByID(id)
-	if !found {
+	exercise, found := repository.GetExerciseByID(ctx, id)
+	if !found || !authz.Can(ctx, authz.Read, authz.Scope{ExerciseID: id}) {
 		return fmt.Sprintf("Exercise with ID %d not found.", id)
 	}
 
@@ -404,24 +430,82 @@ func getExerciseDetailsString(ex models.Exercise) string {
 	return details
 }
 
-func handleDivisionQuery(message string) string {
-	// This would connect to actual division/team data
-	// For now, returning placeholder info
-	return `📊 **Division Status Overview:**
+// handleDivisionQuery reports division readiness and team statuses in the
+// exercises running today, limited to the divisions the message names if any
+func handleDivisionQuery(ctx context.Context, message string) string {
+	today := readiness.Day(time.Now())
+	lowerMessage := strings.ToLower(message)
 
-**Division A** 🟡
-• Team 1: 🟢 Green - All systems operational
-• Team 2: 🟡 Yellow - Minor communications issues
+	// Running today, the last day included, as on the status board
+	var active []models.Exercise
+	for _, ex := range readableExercises(ctx, repository.GetAllExercises(ctx)) {
+		if !today.Before(readiness.Day(ex.StartDate)) && !today.After(readiness.Day(ex.EndDate)) {
+			active = append(active, ex)
+		}
+	}
+	if len(active) == 0 {
+		return "No exercises are running today, so there are no division statuses to show. Say 'show exercise [ID]' to look at a specific exercise."
+	}
 
-**Division B** 🔴
-• Team 3: 🟢 Green - Ready for operations  
-• Team 4: 🔴 Red - Critical system failure, maintenance required
+	// Only the named divisions, when the message names any
+	named := map[string]bool{}
+	for _, ex := range active {
+		for _, division := range ex.Divisions {
+			if containsWord(lowerMessage, strings.ToLower(division.Name)) {
+				named[strings.ToLower(division.Name)] = true
+			}
+		}
+	}
 
-Use the main interface to update team statuses and add comments.`
+	reply := "📊 **Division Status Overview:**\n\n"
+	for _, ex := range active {
+		rollup := readiness.Exercise(ex, today)
+		reply += fmt.Sprintf("📅 **%s** %s %s\n\n", ex.Name, statusEmoji(rollup.Readiness.Status), statusName(rollup.Readiness.Status))
+		for i, division := range rollup.Divisions {
+			if len(named) > 0 && !named[strings.ToLower(division.Name)] {
+				continue
+			}
+			reply += fmt.Sprintf("**%s** %s\n", division.Name, statusEmoji(division.Readiness.Status))
+			for j, team := range division.Teams {
+				reply += fmt.Sprintf("• %s: %s %s", team.Name, statusEmoji(team.Status), statusName(team.Status))
+				if comments := ex.Divisions[i].Teams[j].Comments; comments != "" {
+					reply += " - " + comments
+				}
+				reply += "\n"
+			}
+			reply += "\n"
+		}
+	}
+
+	return reply + "Use the main interface to update team statuses and add comments."
+}
+
+// containsWord reports whether word appears in text on its own
+func containsWord(text, word string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(word) + `\b`).MatchString(text)
+}
+
+func statusEmoji(status string) string {
+	switch status {
+	case "green":
+		return "🟢"
+	case "yellow":
+		return "🟡"
+	case "red":
+		return "🔴"
+	}
+	return "⚪"
+}
+
+func statusName(status string) string {
+	if status == "" {
+		return ""
+	}
+	return strings.ToUpper(status[:1]) + status[1:]
 }
 
-func getExercisesByTimeframe(message string) string {
-	exercises := repository.GetAllExercises()
+func getExercisesByTimeframe(ctx context.Context, message string) string {
+	exercises := readableExercises(ctx, repository.GetAllExercises(ctx))
 	lowerMessage := strings.ToLower(message)
 	now := time.Now()
 	
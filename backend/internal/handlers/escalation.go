@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
+)
+
+// GetEscalationPolicy returns an exercise's escalation steps
+func GetEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	if _, found := repository.GetExerciseByID(r.Context(), id); !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	policy, ok := repository.GetEscalationPolicy(r.Context(), id)
+	if !ok {
+		http.Error(w, "Failed to load escalation policy", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, policy)
+}
+
+// SetEscalationPolicy replaces an exercise's escalation steps. An empty list
+// turns escalation off for the exercise.
+func SetEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	var policy models.EscalationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	policy.ExerciseID = id
+	if policy.Steps == nil {
+		policy.Steps = []models.EscalationStep{}
+	}
+
+	if !authorize(w, r, authz.ExerciseUpdate, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	if _, found := repository.GetExerciseByID(r.Context(), id); !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	// Steps are checked one by one so each problem names its step
+	var errs validation.Errors
+	for i, step := range policy.Steps {
+		prefix := fmt.Sprintf("steps[%d].", i)
+		for _, e := range validation.Struct(r.Context(), step) {
+			errs.Add(prefix+e.Field, e.Code, e.Message)
+		}
+		for _, earlier := range policy.Steps[:i] {
+			if earlier == step {
+				errs.Add(prefix+"notify", validation.CodeInvalid, "repeats an earlier step")
+				break
+			}
+		}
+	}
+	if !validErrors(w, errs) {
+		return
+	}
+
+	if !repository.SetEscalationPolicy(r.Context(), policy) {
+		http.Error(w, "Failed to save escalation policy", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, policy)
+}
+
+// GetEscalations returns an exercise's escalation history, newest first.
+// open=true leaves out acknowledged escalations.
+func GetEscalations(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	if _, found := repository.GetExerciseByID(r.Context(), id); !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	escalations, ok := repository.GetEscalations(r.Context(), id, r.URL.Query().Get("open") == "true")
+	if !ok {
+		http.Error(w, "Failed to load escalations", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, escalations)
+}
+
+// AcknowledgeEscalation acknowledges an escalation, which stops the
+// remaining steps for the team until its status changes
+func AcknowledgeEscalation(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "escalation")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Note string `json:"note" validate:"max=10000"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	escalation, found := repository.GetEscalationByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Escalation not found", http.StatusNotFound)
+		return
+	}
+	scope, found := authz.TeamScope(r.Context(), escalation.TeamID)
+	if !found {
+		http.Error(w, "Escalation not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamUpdate, scope) {
+		return
+	}
+	if escalation.AcknowledgedAt != nil {
+		http.Error(w, "Escalation already acknowledged", http.StatusConflict)
+		return
+	}
+	if !validPayload(w, r, &body) {
+		return
+	}
+
+	user, _ := auth.UserFromContext(r.Context())
+	escalation, ok = repository.AcknowledgeEscalation(r.Context(), id, user.Username, body.Note)
+	if !ok {
+		http.Error(w, "Failed to acknowledge escalation", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, escalation)
+}
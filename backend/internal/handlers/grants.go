@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetGrants returns role grants, filterable by user_id and exercise_id.
+// Without an exercise_id the caller must be able to manage grants
+// application-wide.
+func GetGrants(w http.ResponseWriter, r *http.Request) {
+	var userID, exerciseID int
+	var err error
+
+	query := r.URL.Query()
+	if v := query.Get("user_id"); v != "" {
+		if userID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("exercise_id"); v != "" {
+		if exerciseID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid exercise_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !authorize(w, r, authz.GrantsManage, authz.Scope{ExerciseID: exerciseID}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(authz.GetGrants(r.Context(), userID, exerciseID))
+}
+
+// CreateGrant gives a user a role, either application-wide or within an
+// exercise, division or team. Admin can only be granted application-wide,
+// and callers cannot grant a role with permissions they lack themselves.
+func CreateGrant(w http.ResponseWriter, r *http.Request) {
+	var grant models.RoleGrant
+	if err := json.NewDecoder(r.Body).Decode(&grant); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var errs validation.Errors
+	if grant.Role != "" && !authz.IsRole(grant.Role) {
+		errs.Add("role", validation.CodeOneOf, "unknown role")
+	}
+	if grant.Role == authz.RoleAdmin && (grant.ExerciseID != nil || grant.DivisionID != nil || grant.TeamID != nil) {
+		errs.Add("role", validation.CodeInvalid, "the admin role cannot be scoped")
+	}
+	if !validPayload(w, r, &grant, errs...) {
+		return
+	}
+	user, found := auth.GetUserByID(r.Context(), grant.UserID)
+	if !found {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Resolve the containing exercise and division so the permission check
+	// sees the full scope
+	current, _ := auth.UserFromContext(r.Context())
+	grant.GrantedBy = &current.ID
+	grant.Source = authz.SourceManual
+	scope, err := resolveGrantScope(r.Context(), grant)
+	if err != nil {
+		validation.Write(w, validation.Errors{{Field: "scope", Code: validation.CodeInvalid, Message: err.Error()}})
+		return
+	}
+	if !authz.CanGrant(r.Context(), grant.Role, scope) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	created, err := authz.CreateGrant(r.Context(), grant)
+	if err == authz.ErrInvalidScope {
+		validation.Write(w, validation.Errors{{Field: "scope", Code: validation.CodeInvalid, Message: err.Error()}})
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create grant", http.StatusInternalServerError)
+		return
+	}
+	created.Username = user.Username
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// DeleteGrant revokes a role grant. The last application-wide admin grant
+// cannot be revoked.
+func DeleteGrant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid grant ID", http.StatusBadRequest)
+		return
+	}
+
+	grant, found := authz.GetGrant(r.Context(), id)
+	if !found {
+		http.Error(w, "Grant not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.GrantsManage, authz.GrantScope(grant)) {
+		return
+	}
+	if grant.Role == authz.RoleAdmin && grant.ExerciseID == nil && countAdmins(r.Context()) <= 1 {
+		http.Error(w, "Cannot revoke the last administrator", http.StatusConflict)
+		return
+	}
+
+	if !authz.DeleteGrant(r.Context(), id) {
+		http.Error(w, "Grant not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMyPermissions returns the signed-in user's grants along with the
+// permissions each one gives, so clients can hide actions the user cannot
+// perform
+func GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	type grantPermissions struct {
+		models.RoleGrant
+		Permissions []string `json:"permissions"`
+	}
+
+	result := []grantPermissions{}
+	for _, grant := range authz.GrantsFromContext(r.Context()) {
+		result = append(result, grantPermissions{RoleGrant: grant, Permissions: authz.PermissionsFor(grant.Role)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// resolveGrantScope returns the scope a new grant will cover, looking up the
+// containing exercise and division of a division or team grant
+func resolveGrantScope(ctx context.Context, grant models.RoleGrant) (authz.Scope, error) {
+	switch {
+	case grant.TeamID != nil:
+		scope, found := authz.TeamScope(ctx, *grant.TeamID)
+		if !found {
+			return scope, authz.ErrInvalidScope
+		}
+		return scope, nil
+	case grant.DivisionID != nil:
+		scope, found := authz.DivisionScope(ctx, *grant.DivisionID)
+		if !found {
+			return scope, authz.ErrInvalidScope
+		}
+		return scope, nil
+	default:
+		return authz.GrantScope(grant), nil
+	}
+}
+
+func countAdmins(ctx context.Context) int {
+	count := 0
+	for _, grant := range authz.GetGrants(ctx, 0, 0) {
+		if grant.Role == authz.RoleAdmin && grant.ExerciseID == nil {
+			count++
+		}
+	}
+	return count
+}
@@ -3,10 +3,14 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/logging"
 	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
 	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
 	"strconv"
 	"strings"
 	"time"
@@ -29,20 +33,25 @@ func GetExercises(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid division ID", http.StatusBadRequest)
 			return
 		}
-		exercises = repository.GetExercisesByDivisionID(divisionID)
+		exercises = repository.GetExercisesByDivisionID(r.Context(), divisionID)
 	} else if teamIDStr != "" {
 		teamID, err := strconv.Atoi(teamIDStr)
 		if err != nil {
 			http.Error(w, "Invalid team ID", http.StatusBadRequest)
 			return
 		}
-		exercises = repository.GetExercisesByTeamID(teamID)
+		exercises = repository.GetExercisesByTeamID(r.Context(), teamID)
 	} else if divisionNameStr != "" {
-		exercises = repository.GetExercisesByDivisionName(divisionNameStr)
+		exercises = repository.GetExercisesByDivisionName(r.Context(), divisionNameStr)
 	} else if teamNameStr != "" {
-		exercises = repository.GetExercisesByTeamName(teamNameStr)
+		exercises = repository.GetExercisesByTeamName(r.Context(), teamNameStr)
 	} else {
-		exercises = repository.GetAllExercises()
+		exercises = repository.GetAllExercises(r.Context())
+	}
+	exercises = readableExercises(r.Context(), exercises)
+	today := time.Now()
+	for i := range exercises {
+		readiness.Annotate(&exercises[i], today)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -58,7 +67,14 @@ func CreateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdExercise := repository.CreateExercise(exercise)
+	if !authorize(w, r, authz.ExerciseCreate, authz.Scope{}) {
+		return
+	}
+	if !validPayload(w, r, &exercise, nestedErrors(r.Context(), exercise)...) {
+		return
+	}
+
+	createdExercise := repository.CreateExercise(r.Context(), exercise)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdExercise)
@@ -73,6 +89,10 @@ func UpdateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, r, authz.ExerciseUpdate, authz.Scope{ExerciseID: id}) {
+		return
+	}
+
 	var exercise models.Exercise
 	err = json.NewDecoder(r.Body).Decode(&exercise)
 	if err != nil {
@@ -80,8 +100,14 @@ func UpdateExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	exercise.ID = id // Ensure the ID from the URL is used
+	if !validPayload(w, r, &exercise, nestedErrors(r.Context(), exercise)...) {
+		return
+	}
+	if !authorizeExerciseTeams(w, r, exercise) {
+		return
+	}
 
-	if !repository.UpdateExercise(exercise) {
+	if !repository.UpdateExercise(r.Context(), exercise) {
 		http.Error(w, "Exercise not found", http.StatusNotFound)
 		return
 	}
@@ -100,7 +126,11 @@ func DeleteExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !repository.DeleteExercise(id) {
+	if !authorize(w, r, authz.ExerciseDelete, authz.Scope{ExerciseID: id}) {
+		return
+	}
+
+	if !repository.DeleteExercise(r.Context(), id) {
 		http.Error(w, "Exercise not found", http.StatusNotFound)
 		return
 	}
@@ -124,17 +154,45 @@ func GetDivisionsForExercise(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: exerciseID}) {
+		return
+	}
+
 	// Get the exercise and return its divisions
-	exercise, found := repository.GetExerciseByID(exerciseID)
+	exercise, found := repository.GetExerciseByID(r.Context(), exerciseID)
 	if !found {
 		http.Error(w, "Exercise not found", http.StatusNotFound)
 		return
 	}
+	readiness.Annotate(&exercise, time.Now())
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(exercise.Divisions)
 }
 
+// GetDivisionTeams returns the teams of a division
+func GetDivisionTeams(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "division")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.DivisionScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.Read, scope) {
+		return
+	}
+
+	teams := repository.GetTeamsForDivision(r.Context(), scope.ExerciseID, id)
+	if teams == nil {
+		teams = []models.Team{}
+	}
+	writeJSON(w, teams)
+}
+
 // CreateDivision creates a new division for an exercise
 func CreateDivision(w http.ResponseWriter, r *http.Request) {
 	var division models.Division
@@ -143,8 +201,15 @@ func CreateDivision(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !validPayload(w, r, &division) {
+		return
+	}
+
+	if !authorize(w, r, authz.DivisionCreate, authz.Scope{ExerciseID: division.ExerciseID}) {
+		return
+	}
 
-	createdDivision := repository.CreateDivision(division)
+	createdDivision := repository.CreateDivision(r.Context(), division)
 	if createdDivision.ID == 0 {
 		http.Error(w, "Failed to create division", http.StatusInternalServerError)
 		return
@@ -169,8 +234,21 @@ func UpdateDivision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	scope, found := authz.DivisionScope(r.Context(), division.ID)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	division.ExerciseID = scope.ExerciseID
+	if !validPayload(w, r, &division) {
+		return
+	}
+	if !authorize(w, r, authz.DivisionUpdate, scope) {
+		return
+	}
+
 	// Update the division in the repository
-	if repository.UpdateDivision(division) {
+	if repository.UpdateDivision(r.Context(), division) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(division)
@@ -182,13 +260,31 @@ func UpdateDivision(w http.ResponseWriter, r *http.Request) {
 // CreateTeam creates a new team within a division
 func CreateTeam(w http.ResponseWriter, r *http.Request) {
 	var team models.Team
-	err := json.NewDecoder(r.Body).Decode(&team)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if _, ok := decodeTeam(w, r, &team); !ok {
+		return
+	}
+	if !validPayload(w, r, &team) {
+		return
+	}
+
+	scope, found := authz.DivisionScope(r.Context(), team.DivisionID)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamCreate, scope) {
+		return
+	}
+	// The team belongs to its division's exercise, whatever the body says
+	if team.ExerciseID != 0 && team.ExerciseID != scope.ExerciseID {
+		var errs validation.Errors
+		errs.Add("exercise_id", validation.CodeInvalid, "must be the exercise of the division")
+		validErrors(w, errs)
 		return
 	}
+	team.ExerciseID = scope.ExerciseID
 
-	createdTeam := repository.CreateTeam(team)
+	createdTeam := repository.CreateTeam(r.Context(), team)
 	if createdTeam.ID == 0 {
 		http.Error(w, "Failed to create team", http.StatusInternalServerError)
 		return
@@ -199,109 +295,82 @@ func CreateTeam(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(createdTeam)
 }
 
-// UpdateTeam updates a team within a specific exercise
-func UpdateTeam(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// GetTeam returns a single team
+func GetTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "team")
+	if !ok {
 		return
 	}
 
-	// Use a custom struct to handle date strings
-	var teamUpdate struct {
-		ID          int    `json:"id"`
-		ExerciseID  int    `json:"exercise_id"`
-		Name        string `json:"name"`
-		DivisionID  int    `json:"division_id"`
-		POC         string `json:"poc"`
-		Status      string `json:"status"`
-		StatusStart string `json:"status_start"`
-		StatusEnd   string `json:"status_end"`
-		Comments    string `json:"comments"`
-	}
-	
-	err := json.NewDecoder(r.Body).Decode(&teamUpdate)
-	if err != nil {
-		log.Printf("Error decoding team update: %v", err)
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	scope, found := authz.TeamScope(r.Context(), id)
+	team, loaded := repository.GetTeamByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Team not found", http.StatusNotFound)
 		return
 	}
-	
-	// Convert to models.Team with proper date handling
-	team := models.Team{
-		ID:         teamUpdate.ID,
-		ExerciseID: teamUpdate.ExerciseID,
-		Name:       teamUpdate.Name,
-		DivisionID: teamUpdate.DivisionID,
-		POC:        teamUpdate.POC,
-		Status:     teamUpdate.Status,
-		Comments:   teamUpdate.Comments,
-	}
-	
-	// Parse dates if provided
-	if teamUpdate.StatusStart != "" {
-		if t, err := time.Parse("2006-01-02", teamUpdate.StatusStart); err == nil {
-			team.StatusStart = t
-		} else if t, err := time.Parse(time.RFC3339, teamUpdate.StatusStart); err == nil {
-			team.StatusStart = t
-		}
-	}
-	
-	if teamUpdate.StatusEnd != "" {
-		if t, err := time.Parse("2006-01-02", teamUpdate.StatusEnd); err == nil {
-			team.StatusEnd = t
-		} else if t, err := time.Parse(time.RFC3339, teamUpdate.StatusEnd); err == nil {
-			team.StatusEnd = t
-		}
+	if !authorize(w, r, authz.Read, scope) {
+		return
 	}
 
-	log.Printf("Received update for team: %+v\n", team)
-	log.Printf("Team ExerciseID: %d, DivisionID: %d, TeamID: %d\n", team.ExerciseID, team.DivisionID, team.ID)
+	writeJSON(w, team)
+}
 
-	// Validate required fields
-	if team.ExerciseID == 0 {
-		log.Printf("Missing ExerciseID in team update")
-		http.Error(w, "Exercise ID is required", http.StatusBadRequest)
+// UpdateTeam replaces a team's name, POC, status window and comments. The
+// team stays in its division (MoveTeam changes that); exercise_id and
+// division_id may be left out.
+func UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "team")
+	if !ok {
 		return
 	}
 
-	// Get the exercise
-	exercise, found := repository.GetExerciseByID(team.ExerciseID)
-	if !found {
-		log.Printf("Exercise not found with ID: %d", team.ExerciseID)
-		http.Error(w, "Exercise not found", http.StatusNotFound)
+	scope, found := authz.TeamScope(r.Context(), id)
+	current, loaded := repository.GetTeamByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamUpdate, scope) {
 		return
 	}
 
-	// Update the team within the exercise's divisions
-	updated := false
-	for i, division := range exercise.Divisions {
-		if division.ID == team.DivisionID {
-			for j, t := range division.Teams {
-				if t.ID == team.ID {
-					exercise.Divisions[i].Teams[j] = team
-					updated = true
-					break
-				}
-			}
-		}
-		if updated {
-			break
-		}
+	var team models.Team
+	given, ok := decodeTeam(w, r, &team)
+	if !ok {
+		return
 	}
 
-	if !updated {
-		http.Error(w, "Team not found", http.StatusNotFound)
+	var errs validation.Errors
+	if team.ExerciseID != 0 && team.ExerciseID != current.ExerciseID {
+		errs.Add("exercise_id", validation.CodeInvalid, "cannot be changed")
+	}
+	if team.DivisionID != 0 && team.DivisionID != current.DivisionID {
+		errs.Add("division_id", validation.CodeInvalid, "cannot be changed; move the team instead")
+	}
+	team.ID, team.ExerciseID, team.DivisionID = id, current.ExerciseID, current.DivisionID
+	if team.Status == "" {
+		team.Status = current.Status
+	}
+	// Clients that predate weights leave them out; keep the stored one
+	if _, set := given["readiness_weight"]; !set {
+		team.ReadinessWeight = current.ReadinessWeight
+	}
+
+	slog.DebugContext(r.Context(), "Received team update",
+		"exercise_id", team.ExerciseID, "division_id", team.DivisionID, "team_id", team.ID,
+		"status", team.Status, "poc", logging.Sensitive(team.POC), "comments", logging.Sensitive(team.Comments))
+
+	if !validPayload(w, r, &team, errs...) {
 		return
 	}
 
-	// Save the updated exercise
-	if !repository.UpdateExercise(exercise) {
-		http.Error(w, "Failed to update exercise", http.StatusInternalServerError)
+	if !repository.UpdateTeam(r.Context(), team) {
+		http.Error(w, "Failed to update team", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Team updated successfully"))
+	updated, _ := repository.GetTeamByID(r.Context(), id)
+	writeJSON(w, updated)
 }
 
 // DeleteDivision deletes a division and all its teams
@@ -313,7 +382,16 @@ func DeleteDivision(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !repository.DeleteDivision(id) {
+	scope, found := authz.DivisionScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.DivisionDelete, scope) {
+		return
+	}
+
+	if !repository.DeleteDivision(r.Context(), id) {
 		http.Error(w, "Division not found", http.StatusNotFound)
 		return
 	}
@@ -331,7 +409,16 @@ func DeleteTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !repository.DeleteTeam(id) {
+	scope, found := authz.TeamScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamDelete, scope) {
+		return
+	}
+
+	if !repository.DeleteTeam(r.Context(), id) {
 		http.Error(w, "Team not found", http.StatusNotFound)
 		return
 	}
@@ -356,7 +443,7 @@ func ChatbotHandler(w http.ResponseWriter, r *http.Request) {
 	var reply string
 
 	if strings.Contains(userMessage, "list exercises") {
-		exercises := repository.GetAllExercises()
+		exercises := repository.GetAllExercises(r.Context())
 		if len(exercises) == 0 {
 			reply = "There are no exercises currently."
 		} else {
@@ -372,7 +459,7 @@ func ChatbotHandler(w http.ResponseWriter, r *http.Request) {
 			StartDate: time.Now(),
 			EndDate:   time.Now().AddDate(0, 0, 5),
 		}
-		created := repository.CreateExercise(newExercise)
+		created := repository.CreateExercise(r.Context(), newExercise)
 		reply = fmt.Sprintf("Added new exercise: ID %d, Name: %s.", created.ID, created.Name)
 	} else if strings.Contains(userMessage, "change name of exercise") {
 		// Expecting format like "change name of exercise 1 to New Name"
@@ -394,12 +481,12 @@ func ChatbotHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil || newName == "" {
 			reply = "Please specify the exercise ID and the new name. E.g., 'change name of exercise 1 to My New Exercise'."
 		} else {
-			existingEx, found := repository.GetExerciseByID(id)
+			existingEx, found := repository.GetExerciseByID(r.Context(), id)
 			if !found {
 				reply = fmt.Sprintf("Exercise with ID %d not found.", id)
 			} else {
 				existingEx.Name = newName
-				if repository.UpdateExercise(existingEx) {
+				if repository.UpdateExercise(r.Context(), existingEx) {
 					reply = fmt.Sprintf("Successfully changed name of exercise %d to %s.", id, newName)
 				} else {
 					reply = "Failed to update exercise name."
@@ -421,7 +508,7 @@ func ChatbotHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			reply = "Please specify the exercise ID to delete. E.g., 'delete exercise 1'."
 		} else {
-			if repository.DeleteExercise(id) {
+			if repository.DeleteExercise(r.Context(), id) {
 				reply = fmt.Sprintf("Successfully deleted exercise with ID %d.", id)
 			} else {
 				reply = fmt.Sprintf("Exercise with ID %d not found.", id)
@@ -438,13 +525,6 @@ func ChatbotHandler(w http.ResponseWriter, r *http.Request) {
 // GetEvents returns all events for a specific exercise
 func GetEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
 
 	// Get exercise ID from query parameter
 	exerciseIDStr := r.URL.Query().Get("exercise_id")
@@ -459,8 +539,12 @@ func GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: exerciseID}) {
+		return
+	}
+
 	// Get events for the exercise using the repository
-	events := repository.GetEventsForExercise(exerciseID)
+	events := repository.GetEventsForExercise(r.Context(), exerciseID)
 	
 	if err := json.NewEncoder(w).Encode(events); err != nil {
 		http.Error(w, "Failed to encode events", http.StatusInternalServerError)
@@ -471,13 +555,6 @@ func GetEvents(w http.ResponseWriter, r *http.Request) {
 // CreateEvent creates a new event
 func CreateEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
 
 	var event models.Event
 	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
@@ -495,9 +572,16 @@ func CreateEvent(w http.ResponseWriter, r *http.Request) {
 	if event.Status == "" {
 		event.Status = "planned"
 	}
+	if !validPayload(w, r, &event) {
+		return
+	}
+
+	if !authorize(w, r, authz.EventCreate, authz.Scope{ExerciseID: event.ExerciseID}) {
+		return
+	}
 
 	// Create the event using the repository
-	createdEvent := repository.CreateEvent(event)
+	createdEvent := repository.CreateEvent(r.Context(), event)
 	
 	if err := json.NewEncoder(w).Encode(createdEvent); err != nil {
 		http.Error(w, "Failed to encode event", http.StatusInternalServerError)
@@ -508,13 +592,6 @@ func CreateEvent(w http.ResponseWriter, r *http.Request) {
 // UpdateEvent updates an existing event
 func UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "PUT, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
 
 	var event models.Event
 	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
@@ -522,8 +599,21 @@ func UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	scope, found := authz.EventScope(r.Context(), event.ID)
+	if !found {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	event.ExerciseID = scope.ExerciseID
+	if !validPayload(w, r, &event) {
+		return
+	}
+	if !authorize(w, r, authz.EventUpdate, scope) {
+		return
+	}
+
 	// Update the event using the repository
-	success := repository.UpdateEvent(event)
+	success := repository.UpdateEvent(r.Context(), event)
 	
 	if success {
 		json.NewEncoder(w).Encode(map[string]string{"status": "success"})
@@ -535,13 +625,6 @@ func UpdateEvent(w http.ResponseWriter, r *http.Request) {
 // DeleteEvent deletes an event
 func DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		return
-	}
 
 	// Extract event ID from URL path
 	idStr := r.URL.Path[len("/api/events/"):]
@@ -551,8 +634,17 @@ func DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	scope, found := authz.EventScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.EventDelete, scope) {
+		return
+	}
+
 	// Delete the event using the repository
-	success := repository.DeleteEvent(id)
+	success := repository.DeleteEvent(r.Context(), id)
 	
 	if success {
 		json.NewEncoder(w).Encode(map[string]string{"status": "success"})
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"srd-calendar-project/backend/internal/database"
+	"time"
+)
+
+// readinessTimeout bounds the database checks made by Readyz so a stuck
+// database fails the probe instead of hanging it
+const readinessTimeout = 2 * time.Second
+
+// Healthz reports that the process is up and serving requests. It checks
+// nothing else, so a database outage does not get the process restarted.
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// Readyz reports whether the instance can serve traffic: the database must
+// answer a ping and its schema must be at the version this build expects
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{"database": "ok", "migrations": "ok"}
+	ready := true
+
+	if err := database.DB.PingContext(ctx); err != nil {
+		checks["database"] = err.Error()
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if current, err := database.SchemaCurrent(ctx); err != nil {
+		checks["migrations"] = err.Error()
+		ready = false
+	} else if !current {
+		checks["migrations"] = "schema is behind this build"
+		ready = false
+	}
+
+	status := "ready"
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": checks})
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
+)
+
+// sameIDs reports whether ids lists each of want exactly once, in any order
+func sameIDs(ids, want []int) bool {
+	if len(ids) != len(want) {
+		return false
+	}
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] || !hasInt(want, id) {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+// ReorderDivisions sets the order of an exercise's divisions. The body must
+// list every division of the exercise.
+func ReorderDivisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		DivisionIDs []int `json:"division_ids" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !authorize(w, r, authz.DivisionUpdate, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	var current []int
+	for _, division := range exercise.Divisions {
+		current = append(current, division.ID)
+	}
+	var errs validation.Errors
+	if len(body.DivisionIDs) > 0 && !sameIDs(body.DivisionIDs, current) {
+		errs.Add("division_ids", validation.CodeInvalid, "must list every division of the exercise exactly once")
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+
+	if !repository.ReorderDivisions(r.Context(), id, body.DivisionIDs) {
+		http.Error(w, "Failed to reorder divisions", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetExerciseByID(r.Context(), id)
+	writeJSON(w, updated.Divisions)
+}
+
+// ReorderTeams sets the order of a division's teams. The body must list
+// every team of the division.
+func ReorderTeams(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "division")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		TeamIDs []int `json:"team_ids" validate:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	scope, found := authz.DivisionScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.DivisionUpdate, scope) {
+		return
+	}
+
+	var current []int
+	for _, team := range repository.GetTeamsForDivision(r.Context(), scope.ExerciseID, id) {
+		current = append(current, team.ID)
+	}
+	var errs validation.Errors
+	if len(body.TeamIDs) > 0 && !sameIDs(body.TeamIDs, current) {
+		errs.Add("team_ids", validation.CodeInvalid, "must list every team of the division exactly once")
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+
+	if !repository.ReorderTeams(r.Context(), scope.ExerciseID, id, body.TeamIDs) {
+		http.Error(w, "Failed to reorder teams", http.StatusInternalServerError)
+		return
+	}
+
+	teams := repository.GetTeamsForDivision(r.Context(), scope.ExerciseID, id)
+	if teams == nil {
+		teams = []models.Team{}
+	}
+	writeJSON(w, teams)
+}
+
+// MoveTeam moves a team to another division of the same exercise. Its tasks,
+// status and comments go with it. Moving needs permission to update the
+// team and to create teams in the new division.
+func MoveTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "team")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		DivisionID int `json:"division_id" validate:"required,exists=division"`
+		Position   int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	scope, found := authz.TeamScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamUpdate, scope) {
+		return
+	}
+
+	var errs validation.Errors
+	target, targetFound := authz.DivisionScope(r.Context(), body.DivisionID)
+	if targetFound && target.ExerciseID != scope.ExerciseID {
+		errs.Add("division_id", validation.CodeInvalid, "must be a division of the team's exercise")
+	}
+	if body.Position < 0 {
+		errs.Add("position", validation.CodeInvalid, "must not be negative")
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+	if target.DivisionID != scope.DivisionID && !authorize(w, r, authz.TeamCreate, target) {
+		return
+	}
+
+	if !repository.MoveTeam(r.Context(), id, body.DivisionID, body.Position) {
+		http.Error(w, "Failed to move team", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetTeamByID(r.Context(), id)
+	writeJSON(w, updated)
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Fields each PATCH route accepts, also listed in the API document. IDs,
+// parents and nested collections are changed through their own routes.
+var (
+	ExercisePatchFields = []string{"name", "start_date", "end_date", "description", "priority",
+		"exercise_event_poc", "tasked_divisions", "aoc_involvement", "srd_poc", "cpd_poc"}
+	DivisionPatchFields = []string{"name", "learning_objectives", "poc"}
+	TeamPatchFields     = []string{"name", "poc", "status", "status_start", "status_end", "comments",
+		"readiness_weight"}
+	EventPatchFields = []string{"name", "start_date", "end_date", "type", "priority", "poc", "status",
+		"description", "location"}
+	TaskPatchFields = []string{"name", "description", "status", "due_date", "assigned_to", "team_id", "team_ids"}
+)
+
+// mergePatch applies a JSON Merge Patch (RFC 7396) to target. A null in the
+// patch removes the member; objects merge recursively; anything else
+// replaces the target value.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// decodePatch applies the merge patch in the request body to current, which
+// must be a pointer to a model, and returns the top-level fields the patch
+// sets. Fields removed with null take their zero value. On failure it writes
+// the response and returns false.
+func decodePatch(w http.ResponseWriter, r *http.Request, current interface{}, allowed []string) ([]string, bool) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			http.Error(w, "Content-Type must be application/merge-patch+json", http.StatusUnsupportedMediaType)
+			return nil, false
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		http.Error(w, "Request body must be a JSON object", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var fields []string
+	var errs validation.Errors
+	for _, name := range allowed {
+		if _, ok := patch[name]; ok {
+			fields = append(fields, name)
+		}
+	}
+	if len(fields) < len(patch) {
+		var unknown []string
+		for name := range patch {
+			if !hasString(allowed, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			errs.Add(name, validation.CodeInvalid, "cannot be changed with PATCH")
+		}
+	}
+	if len(errs) > 0 {
+		validation.Write(w, errs)
+		return nil, false
+	}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		http.Error(w, "Failed to apply patch", http.StatusInternalServerError)
+		return nil, false
+	}
+	var document interface{}
+	json.Unmarshal(currentJSON, &document)
+	merged, _ := json.Marshal(mergePatch(document, patch))
+
+	// Decode into a fresh value so removed members become zero values
+	fresh := reflect.New(reflect.TypeOf(current).Elem())
+	if err := json.Unmarshal(merged, fresh.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			errs.Add(typeErr.Field, validation.CodeInvalid, "must be a "+jsonType(typeErr.Type))
+			validation.Write(w, errs)
+		} else if dateErrs, ok := dateErrors(err); ok {
+			validation.Write(w, dateErrs)
+		} else {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		}
+		return nil, false
+	}
+	reflect.ValueOf(current).Elem().Set(fresh.Elem())
+	return fields, true
+}
+
+func hasString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type a Go type decodes from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	}
+	return fmt.Sprint(t)
+}
+
+// patchID parses the {id} URL parameter
+func patchID(w http.ResponseWriter, r *http.Request, what string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid "+what+" ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// PatchExercise updates the fields of an exercise given in a JSON Merge
+// Patch. Tasked divisions are replaced only when the patch sets them.
+func PatchExercise(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+	if !authorize(w, r, authz.ExerciseUpdate, authz.Scope{ExerciseID: id}) {
+		return
+	}
+
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+	fields, ok := decodePatch(w, r, &exercise, ExercisePatchFields)
+	if !ok {
+		return
+	}
+	exercise.ID = id
+	if !validPayload(w, r, &exercise) {
+		return
+	}
+
+	if !repository.PatchExercise(r.Context(), exercise, fields) {
+		http.Error(w, "Failed to update exercise", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetExerciseByID(r.Context(), id)
+	writeJSON(w, updated)
+}
+
+// PatchDivision updates the fields of a division given in a JSON Merge Patch
+func PatchDivision(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "division")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.DivisionScope(r.Context(), id)
+	division, loaded := repository.GetDivisionByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.DivisionUpdate, scope) {
+		return
+	}
+	fields, ok := decodePatch(w, r, &division, DivisionPatchFields)
+	if !ok {
+		return
+	}
+	if !validPayload(w, r, &division) {
+		return
+	}
+
+	if !repository.PatchDivision(r.Context(), division, fields) {
+		http.Error(w, "Failed to update division", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetDivisionByID(r.Context(), id)
+	writeJSON(w, updated)
+}
+
+// PatchTeam updates the fields of a team given in a JSON Merge Patch
+func PatchTeam(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "team")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.TeamScope(r.Context(), id)
+	team, loaded := repository.GetTeamByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamUpdate, scope) {
+		return
+	}
+	fields, ok := decodePatch(w, r, &team, TeamPatchFields)
+	if !ok {
+		return
+	}
+	if !validPayload(w, r, &team) {
+		return
+	}
+
+	if !repository.PatchTeam(r.Context(), team, fields) {
+		http.Error(w, "Failed to update team", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetTeamByID(r.Context(), id)
+	writeJSON(w, updated)
+}
+
+// PatchEvent updates the fields of an event given in a JSON Merge Patch
+func PatchEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "event")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.EventScope(r.Context(), id)
+	event, loaded := repository.GetEventByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.EventUpdate, scope) {
+		return
+	}
+	fields, ok := decodePatch(w, r, &event, EventPatchFields)
+	if !ok {
+		return
+	}
+	if !validPayload(w, r, &event) {
+		return
+	}
+
+	if !repository.PatchEvent(r.Context(), event, fields) {
+		http.Error(w, "Failed to update event", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetEventByID(r.Context(), id)
+	writeJSON(w, updated)
+}
+
+// PatchTask updates the fields of a task given in a JSON Merge Patch.
+// Assigning teams needs the assign permission on each new team, as with the
+// assign routes.
+func PatchTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "task")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.TaskScope(r.Context(), id)
+	task, loaded := repository.GetTaskByID(r.Context(), id)
+	if !found || !loaded {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TaskUpdate, scope) {
+		return
+	}
+	previous := task
+	fields, ok := decodePatch(w, r, &task, TaskPatchFields)
+	if !ok {
+		return
+	}
+	if !validPayload(w, r, &task) {
+		return
+	}
+
+	if task.TeamID != nil && (previous.TeamID == nil || *task.TeamID != *previous.TeamID) &&
+		!authorizeTeams(w, r, authz.TaskAssign, task.ExerciseID, []int{*task.TeamID}) {
+		return
+	}
+	if hasString(fields, "team_ids") {
+		var added []int
+		for _, teamID := range task.TeamIDs {
+			if !hasInt(previous.TeamIDs, teamID) {
+				added = append(added, teamID)
+			}
+		}
+		if !authorize(w, r, authz.TaskAssign, scope) || !authorizeTeams(w, r, authz.TaskAssign, task.ExerciseID, added) {
+			return
+		}
+	}
+
+	if !repository.PatchTask(r.Context(), task, fields) {
+		http.Error(w, "Failed to update task", http.StatusInternalServerError)
+		return
+	}
+
+	updated, _ := repository.GetTaskByID(r.Context(), id)
+	writeJSON(w, updated)
+}
+
+func hasInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
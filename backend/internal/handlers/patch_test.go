@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Cases from RFC 7396, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch, want interface{}
+		json.Unmarshal([]byte(tt.target), &target)
+		json.Unmarshal([]byte(tt.patch), &patch)
+		json.Unmarshal([]byte(tt.want), &want)
+		if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("mergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+// patchRequest sends body through decodePatch onto current
+func patchRequest(contentType, body string, current interface{}, allowed []string) (*httptest.ResponseRecorder, []string, bool) {
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	fields, ok := decodePatch(w, r, current, allowed)
+	return w, fields, ok
+}
+
+func TestDecodePatch(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := models.Team{ID: 4, Name: "Blue", POC: "Maj Lee", Status: "red", Comments: "Comms outage",
+		StatusStart: start, ReadinessWeight: 3}
+
+	team := current
+	w, fields, ok := patchRequest("application/merge-patch+json",
+		`{"status":"yellow","comments":null,"status_end":"2026-03-04"}`, &team, TeamPatchFields)
+	if !ok {
+		t.Fatalf("decodePatch() failed: %d %s", w.Code, w.Body)
+	}
+	if want := []string{"status", "status_end", "comments"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	want := current
+	want.Status, want.Comments, want.StatusEnd = "yellow", "", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	if !reflect.DeepEqual(team, want) {
+		t.Errorf("patched team = %+v, want %+v", team, want)
+	}
+}
+
+func TestDecodePatchMergesObjects(t *testing.T) {
+	type contact struct {
+		Name  string `json:"name"`
+		Phone string `json:"phone,omitempty"`
+		Email string `json:"email,omitempty"`
+	}
+	type division struct {
+		Name string  `json:"name"`
+		POC  contact `json:"poc"`
+	}
+	current := division{Name: "Cyber", POC: contact{Name: "Lee", Phone: "555-0100", Email: "lee@example.mil"}}
+
+	_, fields, ok := patchRequest("", `{"poc":{"phone":"555-0199","email":null}}`, &current, []string{"name", "poc"})
+	if !ok {
+		t.Fatal("decodePatch() failed")
+	}
+	want := division{Name: "Cyber", POC: contact{Name: "Lee", Phone: "555-0199"}}
+	if !reflect.DeepEqual(current, want) || !reflect.DeepEqual(fields, []string{"poc"}) {
+		t.Errorf("decodePatch() = %+v setting %v, want %+v setting [poc]", current, fields, want)
+	}
+}
+
+func TestDecodePatchRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+		fields      []validation.FieldError
+	}{
+		{"unknown and disallowed fields", "", `{"status":"red","id":9,"colour":"blue","division_id":2}`,
+			http.StatusUnprocessableEntity, []validation.FieldError{
+				{Field: "colour", Code: validation.CodeInvalid, Message: "cannot be changed with PATCH"},
+				{Field: "division_id", Code: validation.CodeInvalid, Message: "cannot be changed with PATCH"},
+				{Field: "id", Code: validation.CodeInvalid, Message: "cannot be changed with PATCH"},
+			}},
+		{"wrong type", "", `{"readiness_weight":"heavy"}`, http.StatusUnprocessableEntity, []validation.FieldError{
+			{Field: "readiness_weight", Code: validation.CodeInvalid, Message: "must be a number"},
+		}},
+		{"bad date", "", `{"status_start":"tomorrow"}`, http.StatusUnprocessableEntity, []validation.FieldError{
+			{Field: "status_start", Code: validation.CodeInvalid, Message: "must be a date (YYYY-MM-DD) or RFC 3339 timestamp"},
+		}},
+		{"not an object", "", `["status"]`, http.StatusBadRequest, nil},
+		{"null body", "", `null`, http.StatusBadRequest, nil},
+		{"wrong content type", "text/plain", `{"status":"red"}`, http.StatusUnsupportedMediaType, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := models.Team{ID: 4, Name: "Blue", Status: "green"}
+			w, _, ok := patchRequest(tt.contentType, tt.body, &team, TeamPatchFields)
+			if ok || w.Code != tt.code {
+				t.Fatalf("decodePatch() = %v with %d, want failure with %d", ok, w.Code, tt.code)
+			}
+			if team.Status != "green" {
+				t.Errorf("a rejected patch changed the team to %+v", team)
+			}
+			if tt.fields == nil {
+				return
+			}
+			var body struct {
+				Fields []validation.FieldError `json:"fields"`
+			}
+			json.NewDecoder(w.Body).Decode(&body)
+			if !reflect.DeepEqual(body.Fields, tt.fields) {
+				t.Errorf("fields = %+v, want %+v", body.Fields, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
+	"srd-calendar-project/backend/internal/repository"
+	"time"
+)
+
+// readinessDay reads the optional date query parameter, defaulting to today
+func readinessDay(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	day, err := models.ParseDate("date", r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, "Invalid date; use YYYY-MM-DD", http.StatusBadRequest)
+		return day, false
+	}
+	if day.IsZero() {
+		day = time.Now()
+	}
+	return readiness.Day(day), true
+}
+
+// GetExerciseReadiness returns an exercise's readiness on a date, with each
+// division's and the team statuses in effect that day
+func GetExerciseReadiness(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+	day, ok := readinessDay(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, readiness.Exercise(exercise, day))
+}
+
+// GetDivisionReadiness returns a division's readiness on a date with the
+// team statuses in effect that day
+func GetDivisionReadiness(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "division")
+	if !ok {
+		return
+	}
+	day, ok := readinessDay(w, r)
+	if !ok {
+		return
+	}
+
+	scope, found := authz.DivisionScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.Read, scope) {
+		return
+	}
+	division, found := repository.GetDivisionByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Division not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, readiness.Division(division, day))
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/sitrep"
+	"srd-calendar-project/backend/internal/validation"
+	"time"
+)
+
+var sitrepContentTypes = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+}
+
+// sitrepPolicy sandboxes rendered HTML reports. Templates are written by
+// exercise planners and html/template only escapes the data, so markup in the
+// template itself is served as written; the sandbox keeps any script in it
+// from running as the viewer.
+const sitrepPolicy = "sandbox; default-src 'none'; style-src 'unsafe-inline'"
+
+// writeSitrep writes a rendered report in format
+func writeSitrep(w http.ResponseWriter, format string, out []byte) {
+	w.Header().Set("Content-Type", sitrepContentTypes[format])
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if format == "html" {
+		w.Header().Set("Content-Security-Policy", sitrepPolicy)
+	}
+	w.Write(out)
+}
+
+// buildSitrep loads what an exercise's situation report on day needs and
+// builds it
+func buildSitrep(r *http.Request, exercise models.Exercise, day time.Time) (models.Sitrep, bool) {
+	now := time.Now().UTC()
+	periods, ok := repository.GetStatusPeriods(r.Context(), exercise.ID)
+	if !ok {
+		return models.Sitrep{}, false
+	}
+	tasks, ok := repository.GetOpenTasksDue(r.Context(), exercise.ID, sitrep.AsOf(day, now).Add(sitrep.DueSoon()))
+	if !ok {
+		return models.Sitrep{}, false
+	}
+	events := repository.GetEventsForExercise(r.Context(), exercise.ID)
+	return sitrep.Build(exercise, periods, events, tasks, day, now), true
+}
+
+// effectiveTemplate fills in the built-in templates an exercise has not
+// replaced
+func effectiveTemplate(tmpl models.SitrepTemplate) models.SitrepTemplate {
+	tmpl.Markdown = sitrep.Source(tmpl, "markdown")
+	tmpl.HTML = sitrep.Source(tmpl, "html")
+	return tmpl
+}
+
+// GetSitrep returns an exercise's situation report on a date (today by
+// default): division and team statuses with comments, status changes in the
+// last 24 hours, the day's and next day's events, and overdue and due-soon
+// tasks. format=markdown or format=html renders it with the exercise's
+// template.
+func GetSitrep(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if _, known := sitrepContentTypes[format]; !known && format != "json" {
+		http.Error(w, "Invalid format; use json, markdown or html", http.StatusBadRequest)
+		return
+	}
+	day, ok := readinessDay(w, r)
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+	report, ok := buildSitrep(r, exercise, day)
+	if !ok {
+		http.Error(w, "Failed to build SITREP", http.StatusInternalServerError)
+		return
+	}
+	if format == "json" {
+		writeJSON(w, report)
+		return
+	}
+
+	tmpl, ok := repository.GetSitrepTemplate(r.Context(), id)
+	if !ok {
+		http.Error(w, "Failed to load SITREP template", http.StatusInternalServerError)
+		return
+	}
+	out, err := sitrep.Render(tmpl, format, report)
+	if err != nil {
+		http.Error(w, "Failed to render SITREP template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeSitrep(w, format, out)
+}
+
+// GetSitrepTemplate returns the templates an exercise's situation reports
+// are rendered with, the built-in ones where the exercise has not set its own
+func GetSitrepTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	if _, found := repository.GetExerciseByID(r.Context(), id); !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	tmpl, ok := repository.GetSitrepTemplate(r.Context(), id)
+	if !ok {
+		http.Error(w, "Failed to load SITREP template", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, effectiveTemplate(tmpl))
+}
+
+// SetSitrepTemplate replaces an exercise's situation report templates. An
+// empty template goes back to the built-in one. Each template must render
+// today's report.
+func SetSitrepTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	var tmpl models.SitrepTemplate
+	if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	tmpl.ExerciseID = id
+	// The built-in text is stored as no template, so it follows later
+	// changes to the built-in one
+	if tmpl.Markdown == sitrep.DefaultMarkdown {
+		tmpl.Markdown = ""
+	}
+	if tmpl.HTML == sitrep.DefaultHTML {
+		tmpl.HTML = ""
+	}
+
+	if !authorize(w, r, authz.ExerciseUpdate, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+	if !validPayload(w, r, &tmpl) {
+		return
+	}
+
+	// A template that fails on a real report would fail every SITREP
+	report, ok := buildSitrep(r, exercise, time.Now())
+	if !ok {
+		http.Error(w, "Failed to build SITREP", http.StatusInternalServerError)
+		return
+	}
+	var errs validation.Errors
+	for _, format := range sitrep.Formats {
+		if _, err := sitrep.Render(tmpl, format, report); err != nil {
+			errs.Add(format, validation.CodeInvalid, err.Error())
+		}
+	}
+	if !validErrors(w, errs) {
+		return
+	}
+
+	if !repository.SetSitrepTemplate(r.Context(), tmpl) {
+		http.Error(w, "Failed to save SITREP template", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, effectiveTemplate(tmpl))
+}
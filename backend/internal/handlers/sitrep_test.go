@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/sitrep"
+	"strings"
+	"testing"
+)
+
+func TestWriteSitrepSandboxesHTML(t *testing.T) {
+	tmpl := models.SitrepTemplate{HTML: `<h1>{{.Name}}</h1><script>fetch("/api/grants", {method: "POST"})</script>`}
+	out, err := sitrep.Render(tmpl, "html", models.Sitrep{Name: "Red Flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The template's own markup is not escaped, which is why the response
+	// must be sandboxed
+	if !strings.Contains(string(out), "<script>") {
+		t.Fatalf("rendered report lost the template's script: %s", out)
+	}
+
+	w := httptest.NewRecorder()
+	writeSitrep(w, "html", out)
+	if got := w.Header().Get("Content-Security-Policy"); got != "sandbox; default-src 'none'; style-src 'unsafe-inline'" {
+		t.Errorf("Content-Security-Policy = %q, want a sandbox that blocks scripts", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestWriteSitrepMarkdown(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeSitrep(w, "markdown", []byte("# SITREP"))
+	if w.Header().Get("Content-Type") != "text/markdown; charset=utf-8" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("headers = %v", w.Header())
+	}
+	if w.Body.String() != "# SITREP" {
+		t.Errorf("body = %q", w.Body)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/statuscheck"
+	"time"
+)
+
+// ConfirmTeamStatus records that a team's status is still accurate without
+// changing it, clearing any stale flag
+func ConfirmTeamStatus(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "team")
+	if !ok {
+		return
+	}
+
+	scope, found := authz.TeamScope(r.Context(), id)
+	if !found {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TeamUpdate, scope) {
+		return
+	}
+
+	team, ok := repository.ConfirmTeamStatus(r.Context(), id)
+	if !ok {
+		http.Error(w, "Failed to confirm team status", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, team)
+}
+
+// GetStaleStatuses lists the teams of an exercise whose status has not been
+// set or confirmed within the stale interval, longest unconfirmed first
+func GetStaleStatuses(w http.ResponseWriter, r *http.Request) {
+	id, ok := patchID(w, r, "exercise")
+	if !ok {
+		return
+	}
+
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: id}) {
+		return
+	}
+	exercise, found := repository.GetExerciseByID(r.Context(), id)
+	if !found {
+		http.Error(w, "Exercise not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	staleAfter := statuscheck.StaleAfter()
+	report := models.StaleStatusReport{
+		ExerciseID:        exercise.ID,
+		Name:              exercise.Name,
+		StaleAfterSeconds: int64(staleAfter / time.Second),
+		GeneratedAt:       now.UTC(),
+		Teams:             []models.StaleTeam{},
+	}
+	for _, div := range exercise.Divisions {
+		for _, team := range div.Teams {
+			unconfirmed := now.Sub(team.StatusConfirmedAt)
+			if unconfirmed < staleAfter && !team.StatusStale {
+				continue
+			}
+			report.Teams = append(report.Teams, models.StaleTeam{
+				TeamID:             team.ID,
+				Name:               team.Name,
+				DivisionID:         div.ID,
+				DivisionName:       div.Name,
+				Status:             team.Status,
+				POC:                team.POC,
+				StatusConfirmedAt:  team.StatusConfirmedAt,
+				UnconfirmedSeconds: int64(unconfirmed / time.Second),
+				UnconfirmedFor:     heldFor(unconfirmed),
+				Flagged:            team.StatusStale,
+			})
+		}
+	}
+	sort.SliceStable(report.Teams, func(i, j int) bool {
+		return report.Teams[i].StatusConfirmedAt.Before(report.Teams[j].StatusConfirmedAt)
+	})
+
+	writeJSON(w, report)
+}
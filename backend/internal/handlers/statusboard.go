@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
+	"srd-calendar-project/backend/internal/repository"
+	"strings"
+	"time"
+)
+
+// queryList reads a query parameter given more than once or as a
+// comma-separated list
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, value := range r.URL.Query()[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// heldFor formats how long a status has been held, to the largest two units
+func heldFor(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	minutes := int(d/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// GetStatusBoard returns every team of the exercises running today, grouped
+// by exercise and division, for a wall display. Teams show the status in
+// effect today. division and status narrow the teams shown; each takes
+// several values.
+func GetStatusBoard(w http.ResponseWriter, r *http.Request) {
+	divisions := map[string]bool{}
+	for _, name := range queryList(r, "division") {
+		divisions[strings.ToLower(name)] = true
+	}
+	statuses := map[string]bool{}
+	for _, status := range queryList(r, "status") {
+		status = strings.ToLower(status)
+		if status != "green" && status != "yellow" && status != "red" {
+			http.Error(w, "Invalid status; use green, yellow or red", http.StatusBadRequest)
+			return
+		}
+		statuses[status] = true
+	}
+	filtered := len(divisions) > 0 || len(statuses) > 0
+
+	now := time.Now()
+	today := readiness.Day(now)
+	board := models.StatusBoard{
+		Date:        today.Format("2006-01-02"),
+		GeneratedAt: now.UTC(),
+		Exercises:   []models.BoardExercise{},
+	}
+
+	for _, ex := range readableExercises(r.Context(), repository.GetAllExercises(r.Context())) {
+		if today.Before(readiness.Day(ex.StartDate)) || today.After(readiness.Day(ex.EndDate)) {
+			continue
+		}
+		rollup := readiness.Exercise(ex, today)
+		exercise := models.BoardExercise{
+			ExerciseID: ex.ID,
+			Name:       ex.Name,
+			StartDate:  ex.StartDate,
+			EndDate:    ex.EndDate,
+			Readiness:  rollup.Readiness,
+			Divisions:  []models.BoardDivision{},
+		}
+
+		for i, div := range ex.Divisions {
+			if len(divisions) > 0 && !divisions[strings.ToLower(div.Name)] {
+				continue
+			}
+			division := models.BoardDivision{
+				DivisionID: div.ID,
+				Name:       div.Name,
+				Readiness:  rollup.Divisions[i].Readiness,
+				Teams:      []models.BoardTeam{},
+			}
+			for _, team := range div.Teams {
+				// The status the rollup counts, so the board agrees with it
+				status := readiness.TeamStatus(team, today)
+				if len(statuses) > 0 && !statuses[status] {
+					continue
+				}
+				since := readiness.StatusSince(team, today)
+				held := now.Sub(since)
+				if held < 0 {
+					held = 0
+				}
+				division.Teams = append(division.Teams, models.BoardTeam{
+					TeamID:         team.ID,
+					Name:           team.Name,
+					Status:         status,
+					ReportedStatus: team.Status,
+					StatusStart:    team.StatusStart,
+					StatusEnd:      team.StatusEnd,
+					POC:            team.POC,
+					Comments:       team.Comments,
+					StatusSince:    since,
+					HeldSeconds:    int64(held / time.Second),
+					HeldFor:        heldFor(held),
+				})
+			}
+			if len(statuses) > 0 && len(division.Teams) == 0 {
+				continue
+			}
+			exercise.Divisions = append(exercise.Divisions, division)
+		}
+
+		if filtered && len(exercise.Divisions) == 0 {
+			continue
+		}
+		board.Exercises = append(board.Exercises, exercise)
+	}
+
+	writeJSON(w, board)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/stream"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamHeartbeat keeps idle connections open through proxies
+const streamHeartbeat = 25 * time.Second
+
+// streamReplayLimit caps how many missed changes are replayed on resume
+const streamReplayLimit = 1000
+
+// StreamChanges pushes change notifications to the client as Server-Sent
+// Events. Clients can filter by exercise_id and by a comma-separated list of
+// change types (wildcards such as "team.*" are allowed), and resume after a
+// reconnect by sending the Last-Event-ID header.
+func StreamChanges(w http.ResponseWriter, r *http.Request) {
+	exerciseID := 0
+	if exerciseIDStr := r.URL.Query().Get("exercise_id"); exerciseIDStr != "" {
+		var err error
+		exerciseID, err = strconv.Atoi(exerciseIDStr)
+		if err != nil {
+			http.Error(w, "Invalid exercise_id", http.StatusBadRequest)
+			return
+		}
+		if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: exerciseID}) {
+			return
+		}
+	}
+
+	var types []string
+	if typesStr := r.URL.Query().Get("types"); typesStr != "" {
+		for _, t := range strings.Split(typesStr, ",") {
+			t = strings.TrimSpace(t)
+			if !changes.IsKnownType(t) {
+				http.Error(w, fmt.Sprintf("Unknown change type %q", t), http.StatusBadRequest)
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	var lastEventID int64
+	lastEventIDStr := r.Header.Get("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventIDStr != "" {
+		var err error
+		lastEventID, err = strconv.ParseInt(lastEventIDStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Subscribe before replaying so nothing recorded in between is missed
+	sub := stream.Subscribe()
+	defer stream.Unsubscribe(sub)
+
+	matches := func(change changes.Change) bool {
+		if exerciseID != 0 && change.ExerciseID != exerciseID {
+			return false
+		}
+		if !authz.Can(r.Context(), authz.Read, authz.Scope{ExerciseID: change.ExerciseID}) {
+			return false
+		}
+		if len(types) == 0 {
+			return true
+		}
+		for _, t := range types {
+			if t == "*" || t == change.Type || t == changes.Category(change.Type) {
+				return true
+			}
+		}
+		return false
+	}
+
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	if lastEventID > 0 {
+		for _, change := range stream.GetChangesSince(r.Context(), lastEventID, exerciseID, streamReplayLimit) {
+			if matches(change) {
+				writeChange(w, change)
+			}
+			lastEventID = change.ID
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and
+				// resumes from the change log
+				return
+			}
+			if change.ID <= lastEventID || !matches(change) {
+				continue
+			}
+			writeChange(w, change)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": keep-alive\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeChange writes a single change as an SSE message
+func writeChange(w http.ResponseWriter, change changes.Change) {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+}
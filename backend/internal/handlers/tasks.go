@@ -3,12 +3,14 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/changes"
 	"srd-calendar-project/backend/internal/database"
 	"srd-calendar-project/backend/internal/models"
 )
@@ -27,6 +29,10 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorize(w, r, authz.Read, authz.Scope{ExerciseID: exerciseID}) {
+		return
+	}
+
 	query := `
 		SELECT t.id, t.exercise_id, t.team_id, t.name, t.description, t.status,
 		       t.due_date, t.assigned_to, t.completed_at, t.created_at, t.updated_at,
@@ -46,9 +52,9 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 			t.created_at DESC
 	`
 
-	rows, err := database.DB.Query(query, exerciseID)
+	rows, err := database.DB.QueryContext(r.Context(), query, exerciseID)
 	if err != nil {
-		log.Printf("Error querying tasks: %v", err)
+		slog.ErrorContext(r.Context(), "Error querying tasks", "error", err)
 		http.Error(w, "Error fetching tasks", http.StatusInternalServerError)
 		return
 	}
@@ -77,7 +83,7 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 			&divisionName,
 		)
 		if err != nil {
-			log.Printf("Error scanning task: %v", err)
+			slog.ErrorContext(r.Context(), "Error scanning task", "error", err)
 			continue
 		}
 
@@ -105,9 +111,9 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 			WHERE tt.task_id = $1
 			ORDER BY tm.name
 		`
-		teamRows, err := database.DB.Query(teamsQuery, task.ID)
+		teamRows, err := database.DB.QueryContext(r.Context(), teamsQuery, task.ID)
 		if err != nil {
-			log.Printf("Error loading teams for task %d: %v", task.ID, err)
+			slog.ErrorContext(r.Context(), "Error loading teams for task", "task_id", task.ID, "error", err)
 		} else {
 			var teamIDs []int
 			var teams []models.Team
@@ -116,7 +122,7 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 				var poc, status, comments, divisionName sql.NullString
 				err := teamRows.Scan(&team.ID, &team.Name, &poc, &status, &comments, &divisionName)
 				if err != nil {
-					log.Printf("Error scanning team for task: %v", err)
+					slog.ErrorContext(r.Context(), "Error scanning team for task", "error", err)
 					continue
 				}
 				team.POC = poc.String
@@ -151,13 +157,22 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required fields
-	if task.Name == "" {
-		http.Error(w, "Task name is required", http.StatusBadRequest)
+	if !validPayload(w, r, &task) {
 		return
 	}
-	if task.ExerciseID == 0 {
-		http.Error(w, "Exercise ID is required", http.StatusBadRequest)
+
+	scope := authz.Scope{ExerciseID: task.ExerciseID}
+	if task.TeamID != nil {
+		var found bool
+		if scope, found = authz.TeamScope(r.Context(), *task.TeamID); !found || scope.ExerciseID != task.ExerciseID {
+			http.Error(w, "Team not found", http.StatusBadRequest)
+			return
+		}
+	}
+	if !authorize(w, r, authz.TaskCreate, scope) {
+		return
+	}
+	if len(task.TeamIDs) > 0 && !authorizeTeams(w, r, authz.TaskAssign, task.ExerciseID, task.TeamIDs) {
 		return
 	}
 
@@ -177,7 +192,7 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		teamID = sql.NullInt64{Int64: int64(*task.TeamID), Valid: true}
 	}
 
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(r.Context(),
 		query,
 		task.ExerciseID,
 		teamID,
@@ -189,7 +204,7 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
 
 	if err != nil {
-		log.Printf("Error creating task: %v", err)
+		slog.ErrorContext(r.Context(), "Error creating task", "error", err)
 		http.Error(w, "Error creating task", http.StatusInternalServerError)
 		return
 	}
@@ -197,11 +212,11 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	// Handle multiple team assignments
 	if len(task.TeamIDs) > 0 {
 		for _, teamID := range task.TeamIDs {
-			_, err := database.DB.Exec(
+			_, err := database.DB.ExecContext(r.Context(),
 				"INSERT INTO task_teams (task_id, team_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
 				task.ID, teamID)
 			if err != nil {
-				log.Printf("Error assigning task to team %d: %v", teamID, err)
+				slog.ErrorContext(r.Context(), "Error assigning task to team", "team_id", teamID, "error", err)
 			}
 		}
 
@@ -213,7 +228,7 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 			WHERE tt.task_id = $1
 			ORDER BY tm.name
 		`
-		teamRows, err := database.DB.Query(teamsQuery, task.ID)
+		teamRows, err := database.DB.QueryContext(r.Context(), teamsQuery, task.ID)
 		if err == nil {
 			var teams []models.Team
 			for teamRows.Next() {
@@ -233,6 +248,8 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	changes.Emit(r.Context(), database.DB, changes.TaskCreated, task.ExerciseID, task)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(task)
@@ -255,6 +272,23 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	task.ID = taskID
 
+	scope, found := authz.TaskScope(r.Context(), taskID)
+	if !found {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	task.ExerciseID = scope.ExerciseID
+	if !validPayload(w, r, &task) {
+		return
+	}
+	if !authorize(w, r, authz.TaskUpdate, scope) {
+		return
+	}
+	if task.TeamID != nil && *task.TeamID != scope.TeamID &&
+		!authorizeTeams(w, r, authz.TaskAssign, scope.ExerciseID, []int{*task.TeamID}) {
+		return
+	}
+
 	// Handle status change to completed
 	var completedAt *time.Time
 	if task.Status == "completed" {
@@ -267,7 +301,7 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		SET name = $2, description = $3, status = $4, due_date = $5, 
 		    assigned_to = $6, team_id = $7, completed_at = $8, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
-		RETURNING updated_at
+		RETURNING exercise_id, updated_at
 	`
 
 	var teamID sql.NullInt64
@@ -275,7 +309,7 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		teamID = sql.NullInt64{Int64: int64(*task.TeamID), Valid: true}
 	}
 
-	err = database.DB.QueryRow(
+	err = database.DB.QueryRowContext(r.Context(),
 		query,
 		task.ID,
 		task.Name,
@@ -285,13 +319,13 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		sql.NullString{String: task.AssignedTo, Valid: task.AssignedTo != ""},
 		teamID,
 		completedAt,
-	).Scan(&task.UpdatedAt)
+	).Scan(&task.ExerciseID, &task.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Task not found", http.StatusNotFound)
 		} else {
-			log.Printf("Error updating task: %v", err)
+			slog.ErrorContext(r.Context(), "Error updating task", "error", err)
 			http.Error(w, "Error updating task", http.StatusInternalServerError)
 		}
 		return
@@ -299,6 +333,8 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	task.CompletedAt = completedAt
 
+	changes.Emit(r.Context(), database.DB, changes.TaskUpdated, task.ExerciseID, task)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(task)
 }
@@ -313,19 +349,34 @@ func AssignTaskToTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		TeamID *int `json:"team_id"`
+		TeamID *int `json:"team_id" validate:"exists=team"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if !validPayload(w, r, &body) {
+		return
+	}
+
+	scope, found := authz.TaskScope(r.Context(), taskID)
+	if !found {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TaskAssign, scope) {
+		return
+	}
+	if body.TeamID != nil && !authorizeTeams(w, r, authz.TaskAssign, scope.ExerciseID, []int{*body.TeamID}) {
+		return
+	}
 
 	query := `
 		UPDATE tasks 
 		SET team_id = $2, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
-		RETURNING updated_at
+		RETURNING exercise_id, updated_at
 	`
 
 	var teamID sql.NullInt64
@@ -333,18 +384,24 @@ func AssignTaskToTeam(w http.ResponseWriter, r *http.Request) {
 		teamID = sql.NullInt64{Int64: int64(*body.TeamID), Valid: true}
 	}
 
+	var exerciseID int
 	var updatedAt time.Time
-	err = database.DB.QueryRow(query, taskID, teamID).Scan(&updatedAt)
+	err = database.DB.QueryRowContext(r.Context(), query, taskID, teamID).Scan(&exerciseID, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Task not found", http.StatusNotFound)
 		} else {
-			log.Printf("Error assigning task to team: %v", err)
+			slog.ErrorContext(r.Context(), "Error assigning task to team", "error", err)
 			http.Error(w, "Error assigning task", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	changes.Emit(r.Context(), database.DB, changes.TaskAssigned, exerciseID, map[string]interface{}{
+		"task_id": taskID,
+		"team_id": body.TeamID,
+	})
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Task assignment updated successfully",
@@ -362,57 +419,78 @@ func AssignTaskToMultipleTeams(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		TeamIDs []int `json:"team_ids"`
+		TeamIDs []int `json:"team_ids" validate:"exists=team"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if !validPayload(w, r, &body) {
+		return
+	}
+
+	scope, found := authz.TaskScope(r.Context(), taskID)
+	if !found {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	if !authorize(w, r, authz.TaskAssign, scope) {
+		return
+	}
+	if !authorizeTeams(w, r, authz.TaskAssign, scope.ExerciseID, body.TeamIDs) {
+		return
+	}
 
 	// Start transaction
-	tx, err := database.DB.Begin()
+	tx, err := database.DB.BeginTx(r.Context(), nil)
 	if err != nil {
-		log.Printf("Error starting transaction: %v", err)
+		slog.ErrorContext(r.Context(), "Error starting transaction", "error", err)
 		http.Error(w, "Error assigning teams", http.StatusInternalServerError)
 		return
 	}
 	defer tx.Rollback()
 
 	// Clear existing team assignments
-	_, err = tx.Exec("DELETE FROM task_teams WHERE task_id = $1", taskID)
+	_, err = tx.ExecContext(r.Context(), "DELETE FROM task_teams WHERE task_id = $1", taskID)
 	if err != nil {
-		log.Printf("Error clearing existing team assignments: %v", err)
+		slog.ErrorContext(r.Context(), "Error clearing existing team assignments", "error", err)
 		http.Error(w, "Error assigning teams", http.StatusInternalServerError)
 		return
 	}
 
 	// Add new team assignments
 	for _, teamID := range body.TeamIDs {
-		_, err = tx.Exec("INSERT INTO task_teams (task_id, team_id) VALUES ($1, $2)", taskID, teamID)
+		_, err = tx.ExecContext(r.Context(), "INSERT INTO task_teams (task_id, team_id) VALUES ($1, $2)", taskID, teamID)
 		if err != nil {
-			log.Printf("Error assigning task to team %d: %v", teamID, err)
+			slog.ErrorContext(r.Context(), "Error assigning task to team", "team_id", teamID, "error", err)
 			http.Error(w, "Error assigning teams", http.StatusInternalServerError)
 			return
 		}
 	}
 
 	// Update task's updated_at timestamp
+	var exerciseID int
 	var updatedAt time.Time
-	err = tx.QueryRow("UPDATE tasks SET updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING updated_at", taskID).Scan(&updatedAt)
+	err = tx.QueryRowContext(r.Context(), "UPDATE tasks SET updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING exercise_id, updated_at", taskID).Scan(&exerciseID, &updatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Task not found", http.StatusNotFound)
 		} else {
-			log.Printf("Error updating task timestamp: %v", err)
+			slog.ErrorContext(r.Context(), "Error updating task timestamp", "error", err)
 			http.Error(w, "Error assigning teams", http.StatusInternalServerError)
 		}
 		return
 	}
 
+	changes.Emit(r.Context(), tx, changes.TaskAssigned, exerciseID, map[string]interface{}{
+		"task_id":  taskID,
+		"team_ids": body.TeamIDs,
+	})
+
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(r.Context(), "Error committing transaction", "error", err)
 		http.Error(w, "Error assigning teams", http.StatusInternalServerError)
 		return
 	}
@@ -426,7 +504,7 @@ func AssignTaskToMultipleTeams(w http.ResponseWriter, r *http.Request) {
 		WHERE tt.task_id = $1
 		ORDER BY tm.name
 	`
-	teamRows, err := database.DB.Query(teamsQuery, taskID)
+	teamRows, err := database.DB.QueryContext(r.Context(), teamsQuery, taskID)
 	var teams []models.Team
 	if err == nil {
 		for teamRows.Next() {
@@ -460,25 +538,29 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := `DELETE FROM tasks WHERE id = $1`
-	result, err := database.DB.Exec(query, taskID)
-	if err != nil {
-		log.Printf("Error deleting task: %v", err)
-		http.Error(w, "Error deleting task", http.StatusInternalServerError)
+	scope, found := authz.TaskScope(r.Context(), taskID)
+	if !found {
+		http.Error(w, "Task not found", http.StatusNotFound)
 		return
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("Error getting rows affected: %v", err)
-		http.Error(w, "Error deleting task", http.StatusInternalServerError)
+	if !authorize(w, r, authz.TaskDelete, scope) {
 		return
 	}
 
-	if rowsAffected == 0 {
-		http.Error(w, "Task not found", http.StatusNotFound)
+	query := `DELETE FROM tasks WHERE id = $1 RETURNING exercise_id`
+	var exerciseID int
+	err = database.DB.QueryRowContext(r.Context(), query, taskID).Scan(&exerciseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Task not found", http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Error deleting task", "error", err)
+			http.Error(w, "Error deleting task", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	changes.Emit(r.Context(), database.DB, changes.TaskDeleted, exerciseID, map[string]int{"id": taskID})
+
 	w.WriteHeader(http.StatusNoContent)
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/logging"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/repository"
+	"srd-calendar-project/backend/internal/validation"
+)
+
+// SetTeamStatuses applies one status, and optionally one status window and
+// comment, to a set of teams: the listed team_ids, or every team of a
+// division or exercise. Every team must be updatable by the caller and the
+// update is all or nothing. Window and comment fields left out keep each
+// team's current values; an empty string clears them.
+func SetTeamStatuses(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TeamIDs     []int   `json:"team_ids" validate:"exists=team"`
+		DivisionID  int     `json:"division_id" validate:"exists=division"`
+		ExerciseID  int     `json:"exercise_id" validate:"exists=exercise"`
+		Status      string  `json:"status" validate:"required,oneof=green yellow red"`
+		StatusStart *string `json:"status_start"`
+		StatusEnd   *string `json:"status_end"`
+		Comments    *string `json:"comments" validate:"max=10000"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var errs validation.Errors
+	selectors := 0
+	for _, set := range []bool{len(body.TeamIDs) > 0, body.DivisionID != 0, body.ExerciseID != 0} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		errs.Add("team_ids", validation.CodeRequired, "give exactly one of team_ids, division_id or exercise_id")
+	}
+
+	fields := []string{"status"}
+	var update models.Team
+	if body.StatusStart != nil {
+		start, err := models.ParseDate("status_start", *body.StatusStart)
+		if dateErrs, ok := dateErrors(err); ok {
+			errs = append(errs, dateErrs...)
+		}
+		update.StatusStart = start
+		fields = append(fields, "status_start")
+	}
+	if body.StatusEnd != nil {
+		end, err := models.ParseDate("status_end", *body.StatusEnd)
+		if dateErrs, ok := dateErrors(err); ok {
+			errs = append(errs, dateErrs...)
+		}
+		update.StatusEnd = end
+		fields = append(fields, "status_end")
+	}
+	if body.Comments != nil {
+		update.Comments = *body.Comments
+		fields = append(fields, "comments")
+	}
+	if !validPayload(w, r, &body, errs...) {
+		return
+	}
+
+	teams, ok := selectTeams(w, r, body.TeamIDs, body.DivisionID, body.ExerciseID)
+	if !ok {
+		return
+	}
+	for _, team := range teams {
+		if !authorize(w, r, authz.TeamUpdate, authz.Scope{ExerciseID: team.ExerciseID, DivisionID: team.DivisionID, TeamID: team.ID}) {
+			return
+		}
+	}
+
+	for i := range teams {
+		team := &teams[i]
+		team.Status = body.Status
+		if hasString(fields, "status_start") {
+			team.StatusStart = update.StatusStart
+		}
+		if hasString(fields, "status_end") {
+			team.StatusEnd = update.StatusEnd
+		}
+		if hasString(fields, "comments") {
+			team.Comments = update.Comments
+		}
+		if !team.StatusStart.IsZero() && team.StatusEnd.Before(team.StatusStart) {
+			errs.Add("status_end", validation.CodeDateOrder,
+				fmt.Sprintf("must not be before status_start (team %d, %s)", team.ID, team.Name))
+		}
+	}
+	if !validErrors(w, errs) {
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Setting team statuses",
+		"teams", len(teams), "status", body.Status, "comments", logging.Sensitive(update.Comments))
+
+	previous, ok := repository.SetTeamStatuses(r.Context(), teams, fields)
+	if !ok {
+		http.Error(w, "Failed to update team statuses", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]models.TeamStatusResult, len(teams))
+	for i, team := range teams {
+		results[i] = models.TeamStatusResult{
+			TeamID:         team.ID,
+			Name:           team.Name,
+			ExerciseID:     team.ExerciseID,
+			DivisionID:     team.DivisionID,
+			PreviousStatus: previous[team.ID],
+			Status:         team.Status,
+			Changed:        previous[team.ID] != team.Status,
+		}
+	}
+	writeJSON(w, results)
+}
+
+// selectTeams loads the teams a bulk update applies to. Listed team IDs
+// must all exist.
+func selectTeams(w http.ResponseWriter, r *http.Request, teamIDs []int, divisionID, exerciseID int) ([]models.Team, bool) {
+	var teams []models.Team
+	found := true
+	field := "team_ids"
+	switch {
+	case len(teamIDs) > 0:
+		teams, found = repository.GetTeamsByIDs(r.Context(), teamIDs)
+	case divisionID != 0:
+		field = "division_id"
+		scope, _ := authz.DivisionScope(r.Context(), divisionID)
+		teams = repository.GetTeamsForDivision(r.Context(), scope.ExerciseID, divisionID)
+	default:
+		field = "exercise_id"
+		teams, found = repository.GetTeamsForExercise(r.Context(), exerciseID)
+	}
+	if !found {
+		http.Error(w, "Failed to load teams", http.StatusInternalServerError)
+		return nil, false
+	}
+	if len(teams) == 0 {
+		var errs validation.Errors
+		errs.Add(field, validation.CodeInvalid, "selects no teams")
+		return nil, validErrors(w, errs)
+	}
+	return teams, true
+}
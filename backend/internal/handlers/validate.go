@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+)
+
+// validPayload checks payload against its validate tags, adds any problems
+// found by the caller, and writes a 422 response listing them all when
+// there are any
+func validPayload(w http.ResponseWriter, r *http.Request, payload interface{}, extra ...validation.FieldError) bool {
+	errs := append(validation.Struct(r.Context(), payload), extra...)
+	return validErrors(w, errs)
+}
+
+// validErrors writes a 422 response when errs is not empty
+func validErrors(w http.ResponseWriter, errs validation.Errors) bool {
+	if len(errs) > 0 {
+		validation.Write(w, errs)
+		return false
+	}
+	return true
+}
+
+// nestedErrors checks the divisions and teams of an exercise payload, naming
+// each field by its path, like divisions[0].teams[1].status. The links to
+// their parents are not checked: the exercise sets them.
+func nestedErrors(ctx context.Context, exercise models.Exercise) validation.Errors {
+	var errs validation.Errors
+	add := func(prefix string, found validation.Errors, parent string) {
+		for _, fe := range found {
+			if fe.Field != parent {
+				errs.Add(prefix+fe.Field, fe.Code, fe.Message)
+			}
+		}
+	}
+	for i, div := range exercise.Divisions {
+		prefix := fmt.Sprintf("divisions[%d].", i)
+		add(prefix, validation.Struct(ctx, div), "exercise_id")
+		for j, team := range div.Teams {
+			add(fmt.Sprintf("%steams[%d].", prefix, j), validation.Struct(ctx, team), "division_id")
+		}
+	}
+	return errs
+}
+
+// decodeTeam decodes a team from the request body and returns the fields
+// the body gave, by JSON name. A malformed status date is a field error (422)
+// rather than a bad request.
+func decodeTeam(w http.ResponseWriter, r *http.Request, team *models.Team) (map[string]json.RawMessage, bool) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	var given map[string]json.RawMessage
+	if err = json.Unmarshal(data, &given); err == nil {
+		err = json.Unmarshal(data, team)
+	}
+	if err == nil {
+		return given, true
+	}
+	if errs, ok := dateErrors(err); ok {
+		validation.Write(w, errs)
+		return nil, false
+	}
+	http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	return nil, false
+}
+
+// dateErrors turns a *models.DateError into a field error
+func dateErrors(err error) (validation.Errors, bool) {
+	var dateErr *models.DateError
+	if !errors.As(err, &dateErr) {
+		return nil, false
+	}
+	var errs validation.Errors
+	errs.Add(dateErr.Field, validation.CodeInvalid, "must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	return errs, true
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+	"srd-calendar-project/backend/internal/webhooks"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetWebhooks returns all webhook subscriptions
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks.GetSubscriptions(r.Context()))
+}
+
+// GetWebhook returns a single webhook subscription
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	sub, found := webhooks.GetSubscription(r.Context(), id)
+	if !found {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// CreateWebhook creates a webhook subscription. The response is the only time
+// the signing secret is returned.
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var sub models.WebhookSubscription
+	sub.Active = true
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validPayload(w, r, &sub, validateWebhook(sub)...) {
+		return
+	}
+
+	created, err := webhooks.CreateSubscription(r.Context(), sub)
+	if err != nil {
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateWebhook updates a webhook subscription's URL, event filters and state
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var sub models.WebhookSubscription
+	sub.Active = true
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	sub.ID = id
+
+	if !validPayload(w, r, &sub, validateWebhook(sub)...) {
+		return
+	}
+
+	if !webhooks.UpdateSubscription(r.Context(), sub) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	updated, _ := webhooks.GetSubscription(r.Context(), id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteWebhook deletes a webhook subscription and its delivery log
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if !webhooks.DeleteSubscription(r.Context(), id) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries returns the delivery log for a subscription, optionally
+// filtered by status. Pass the last ID of a page as before_id for the next.
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, found := webhooks.GetSubscription(r.Context(), id); !found {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			http.Error(w, "limit must be between 1 and 500", http.StatusBadRequest)
+			return
+		}
+	}
+
+	beforeID := 0
+	if v := r.URL.Query().Get("before_id"); v != "" {
+		if beforeID, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries := webhooks.GetDeliveries(r.Context(), id, r.URL.Query().Get("status"), beforeID, limit)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetWebhookDelivery returns a single delivery with its attempt log
+func GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, ok := webhookDeliveryFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// RedeliverWebhook queues a delivery to be sent again immediately
+func RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, ok := webhookDeliveryFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if !webhooks.Redeliver(r.Context(), delivery.ID) {
+		http.Error(w, "Failed to schedule redelivery", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "pending"})
+}
+
+// webhookDeliveryFromRequest loads the delivery named in the URL and checks it
+// belongs to the subscription in the URL, writing an error response if not
+func webhookDeliveryFromRequest(w http.ResponseWriter, r *http.Request) (models.WebhookDelivery, bool) {
+	subscriptionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return models.WebhookDelivery{}, false
+	}
+	deliveryID, err := strconv.Atoi(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return models.WebhookDelivery{}, false
+	}
+
+	delivery, found := webhooks.GetDelivery(r.Context(), deliveryID)
+	if !found || delivery.SubscriptionID != subscriptionID {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return models.WebhookDelivery{}, false
+	}
+
+	return delivery, true
+}
+
+// validateWebhook checks the subscription URL and event filters
+func validateWebhook(sub models.WebhookSubscription) validation.Errors {
+	var errs validation.Errors
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.Add("url", validation.CodeInvalid, "must be an absolute http or https URL")
+	}
+	if len(sub.Events) == 0 {
+		errs.Add("events", validation.CodeRequired, "at least one event type is required")
+	}
+	for i, eventType := range sub.Events {
+		if !changes.IsKnownType(eventType) {
+			errs.Add(fmt.Sprintf("events[%d]", i), validation.CodeOneOf, fmt.Sprintf("unknown event type %q", eventType))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,101 @@
+// Package logging sets up structured logging with log/slog. Every line
+// written with a request context carries the request ID assigned by chi's
+// RequestID middleware, so the lines of one request can be found together.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"srd-calendar-project/backend/internal/config"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// redact controls whether Sensitive values are masked
+var redact atomic.Bool
+
+// Setup installs the default slog logger. The standard library log package
+// writes through it too, so output from dependencies is structured as well.
+func Setup(cfg config.LoggingConfig) {
+	var level slog.Level
+	// The level has been validated with the rest of the configuration
+	_ = level.UnmarshalText([]byte(cfg.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+
+	redact.Store(cfg.Redact)
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// contextHandler adds the request ID from the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Sensitive is free text entered by users, such as a point of contact,
+// comment or chatbot message. It is logged as "[redacted]" when redaction is
+// enabled.
+type Sensitive string
+
+func (s Sensitive) LogValue() slog.Value {
+	if redact.Load() && s != "" {
+		return slog.StringValue("[redacted]")
+	}
+	return slog.StringValue(string(s))
+}
+
+// Middleware logs one line per request once the response has been written.
+// It must run after middleware.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			w.Header().Set(middleware.RequestIDHeader, id)
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		started := time.Now()
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration_ms", float64(time.Since(started).Microseconds())/1000,
+			"remote", r.RemoteAddr,
+		)
+	})
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"srd-calendar-project/backend/internal/database"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP requests by method, chi route pattern and status code.", "method", "route", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency by method and chi route pattern.", "method", "route")
+
+	queryDuration = NewHistogramVec("repository_query_duration_seconds",
+		"Time spent in repository operations.", "operation")
+
+	// ChatbotIntents counts chatbot messages by the intent they were matched to
+	ChatbotIntents = NewCounterVec("chatbot_intents_total",
+		"Chatbot messages by recognised intent.", "intent")
+
+	// Jobs counts background job runs by job and outcome
+	Jobs = NewCounterVec("background_jobs_total",
+		"Background job runs by job and outcome.", "job", "outcome")
+)
+
+func init() {
+	stats := func(read func(s sql.DBStats) float64) func() float64 {
+		return func() float64 {
+			if database.DB == nil {
+				return 0
+			}
+			return read(database.DB.Stats())
+		}
+	}
+
+	NewGaugeFunc("db_max_open_connections", "Maximum number of open database connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	NewGaugeFunc("db_open_connections", "Open database connections, in use and idle.",
+		stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	NewGaugeFunc("db_in_use_connections", "Database connections currently in use.",
+		stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	NewGaugeFunc("db_idle_connections", "Idle database connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	NewCounterFunc("db_wait_count_total", "Times a query waited for a free database connection.",
+		stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	NewCounterFunc("db_wait_duration_seconds_total", "Total time spent waiting for a free database connection.",
+		stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	NewCounterFunc("db_max_idle_closed_total", "Connections closed because the idle pool was full.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	NewCounterFunc("db_max_lifetime_closed_total", "Connections closed for reaching their maximum lifetime.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
+
+// Middleware records the count and latency of every request, labelled with
+// the chi route pattern rather than the raw path so IDs do not create a new
+// series each
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.Inc(r.Method, route, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(started).Seconds(), r.Method, route)
+	})
+}
+
+// ObserveQuery records how long a repository operation took. Call it with
+// defer at the top of the operation: defer metrics.ObserveQuery("name", time.Now())
+func ObserveQuery(operation string, started time.Time) {
+	queryDuration.Observe(time.Since(started).Seconds(), operation)
+}
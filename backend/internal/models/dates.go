@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DateError reports a time field that is neither a date nor an RFC 3339
+// timestamp
+type DateError struct {
+	Field string
+	Value string
+}
+
+func (e *DateError) Error() string {
+	return e.Field + ": invalid date " + e.Value
+}
+
+// ParseDate accepts a date (YYYY-MM-DD, taken as UTC midnight) or an RFC 3339
+// timestamp. An empty string is the zero time. field names the value in the
+// returned *DateError.
+func ParseDate(field, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, &DateError{Field: field, Value: s}
+}
+
+// UnmarshalJSON decodes a team, accepting status_start and status_end as
+// dates as well as timestamps, since the status window is usually whole days.
+// A team without readiness_weight gets the default weight of 1.
+func (t *Team) UnmarshalJSON(data []byte) error {
+	type plain Team
+	var decoded struct {
+		plain
+		StatusStart *string `json:"status_start"`
+		StatusEnd   *string `json:"status_end"`
+	}
+	decoded.ReadinessWeight = 1
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	team := Team(decoded.plain)
+	var err error
+	if decoded.StatusStart != nil {
+		if team.StatusStart, err = ParseDate("status_start", *decoded.StatusStart); err != nil {
+			return err
+		}
+	}
+	if decoded.StatusEnd != nil {
+		if team.StatusEnd, err = ParseDate("status_end", *decoded.StatusEnd); err != nil {
+			return err
+		}
+	}
+	*t = team
+	return nil
+}
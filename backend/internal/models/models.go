@@ -4,69 +4,373 @@ import "time"
 
 type Exercise struct {
 	ID               int                `json:"id"`
-	Name             string             `json:"name"`
-	StartDate        time.Time          `json:"start_date"`
-	EndDate          time.Time          `json:"end_date"`
-	Description      string             `json:"description"`
-	Priority         string             `json:"priority"`         // "high", "medium", "low"
-	ExerciseEventPOC string             `json:"exercise_event_poc"`
-	TaskedDivisions  []string           `json:"tasked_divisions"`
-	AOCInvolvement   string             `json:"aoc_involvement"`
-	SRDPOC           string             `json:"srd_poc"`
-	CPDPOC           string             `json:"cpd_poc"`
+	Name             string             `json:"name" validate:"required,max=255"`
+	StartDate        time.Time          `json:"start_date" validate:"required"`
+	EndDate          time.Time          `json:"end_date" validate:"required,notbefore=StartDate"`
+	Description      string             `json:"description" validate:"max=10000"`
+	Priority         string             `json:"priority" validate:"oneof=high medium low"` // "high", "medium", "low"
+	ExerciseEventPOC string             `json:"exercise_event_poc" validate:"max=255"`
+	TaskedDivisions  []string           `json:"tasked_divisions" validate:"max=255"`
+	AOCInvolvement   string             `json:"aoc_involvement" validate:"max=255"`
+	SRDPOC           string             `json:"srd_poc" validate:"max=255"`
+	CPDPOC           string             `json:"cpd_poc" validate:"max=255"`
 	Divisions        []Division         `json:"divisions"`
 	Events           []Event            `json:"events"`
+	Readiness        *Readiness         `json:"readiness,omitempty"` // Computed for today when listed
 }
 
 type Division struct {
 	ID                 int    `json:"id"`
-	ExerciseID         int    `json:"exercise_id"`
-	Name               string `json:"name"`
-	LearningObjectives string `json:"learning_objectives"`
+	ExerciseID         int    `json:"exercise_id" validate:"required,exists=exercise"`
+	Name               string `json:"name" validate:"required,max=255"`
+	LearningObjectives string `json:"learning_objectives" validate:"max=10000"`
+	POC                string `json:"poc" validate:"max=255" doc:"Division lead; notified by escalation policies"`
+	SortOrder          int    `json:"sort_order"` // Position among the exercise's divisions; set by reordering
 	Teams              []Team `json:"teams"`
+	Readiness          *Readiness `json:"readiness,omitempty"` // Computed for today when listed
 }
 
 type Team struct {
 	ID         int       `json:"id"`
 	ExerciseID int       `json:"exercise_id"`
-	Name       string    `json:"name"`
-	DivisionID int       `json:"division_id"`
-	POC        string    `json:"poc"`
-	Status     string    `json:"status"` // "green", "yellow", "red"
+	Name       string    `json:"name" validate:"required,max=255"`
+	DivisionID int       `json:"division_id" validate:"required,exists=division"`
+	POC        string    `json:"poc" validate:"max=255"`
+	Status     string    `json:"status" validate:"oneof=green yellow red"` // "green", "yellow", "red"
+	StatusStart time.Time `json:"status_start" doc:"Date (YYYY-MM-DD) or RFC 3339 timestamp."`
+	StatusEnd   time.Time `json:"status_end" validate:"notbefore=StatusStart" doc:"Date (YYYY-MM-DD) or RFC 3339 timestamp."`
+	Comments   string    `json:"comments" validate:"max=10000"`
+	SortOrder  int       `json:"sort_order"` // Position among the division's teams; set by reordering
+	ReadinessWeight int  `json:"readiness_weight" validate:"min=0" doc:"How much the team counts toward readiness percentages; 0 leaves it out of the rollup. Defaults to 1; an update without it keeps the current weight."`
+	StatusChangedAt time.Time `json:"status_changed_at" doc:"When the team's status last changed; set by the server"`
+	StatusConfirmedAt time.Time `json:"status_confirmed_at" doc:"When the team's status was last set or confirmed; set by the server"`
+	StatusStale     bool      `json:"status_stale" doc:"Flagged by the stale status check; cleared when the status is set or confirmed"`
+}
+
+// TeamStatusResult reports what a bulk status update did to one team
+type TeamStatusResult struct {
+	TeamID         int    `json:"team_id"`
+	Name           string `json:"name"`
+	ExerciseID     int    `json:"exercise_id"`
+	DivisionID     int    `json:"division_id"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+	Changed        bool   `json:"changed" doc:"Whether the status differs from the previous one"`
+}
+
+// Readiness summarizes the team statuses in effect on one day
+type Readiness struct {
+	Date          string  `json:"date" doc:"Day the statuses are taken from (YYYY-MM-DD, UTC)"`
+	Rule          string  `json:"rule" doc:"worst_of or percentage"`
+	Status        string  `json:"status" doc:"green, yellow or red; unknown when no team counts"`
+	Teams         int     `json:"teams" doc:"Teams counted, leaving out those with weight 0"`
+	Green         int     `json:"green"`
+	Yellow        int     `json:"yellow"`
+	Red           int     `json:"red"`
+	GreenPercent  float64 `json:"green_percent" doc:"Share of the counted weight that is green"`
+	YellowPercent float64 `json:"yellow_percent"`
+	RedPercent    float64 `json:"red_percent"`
+}
+
+// TeamReadiness is one team's part in a readiness rollup
+type TeamReadiness struct {
+	TeamID         int       `json:"team_id"`
+	Name           string    `json:"name"`
+	Status         string    `json:"status" doc:"Status in effect on the day"`
+	ReportedStatus string    `json:"reported_status" doc:"The team's current status, which applies only inside its window"`
+	StatusStart    time.Time `json:"status_start"`
+	StatusEnd      time.Time `json:"status_end"`
+	Weight         int       `json:"weight"`
+}
+
+// DivisionReadiness is a division's rollup with the teams behind it
+type DivisionReadiness struct {
+	DivisionID int             `json:"division_id"`
+	Name       string          `json:"name"`
+	Readiness  Readiness       `json:"readiness"`
+	Teams      []TeamReadiness `json:"teams"`
+}
+
+// ExerciseReadiness is an exercise's rollup with its divisions'
+type ExerciseReadiness struct {
+	ExerciseID int                 `json:"exercise_id"`
+	Name       string              `json:"name"`
+	Readiness  Readiness           `json:"readiness"`
+	Divisions  []DivisionReadiness `json:"divisions"`
+}
+
+// StatusBoard lists the teams of every exercise running on a day
+type StatusBoard struct {
+	Date        string          `json:"date" doc:"Day the board covers (YYYY-MM-DD, UTC)"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Exercises   []BoardExercise `json:"exercises"`
+}
+
+// BoardExercise is an exercise on the status board
+type BoardExercise struct {
+	ExerciseID int             `json:"exercise_id"`
+	Name       string          `json:"name"`
+	StartDate  time.Time       `json:"start_date"`
+	EndDate    time.Time       `json:"end_date"`
+	Readiness  Readiness       `json:"readiness" doc:"Over all of the exercise's teams, whatever the filters"`
+	Divisions  []BoardDivision `json:"divisions"`
+}
+
+// BoardDivision is a division on the status board
+type BoardDivision struct {
+	DivisionID int         `json:"division_id"`
+	Name       string      `json:"name"`
+	Readiness  Readiness   `json:"readiness" doc:"Over all of the division's teams, whatever the filters"`
+	Teams      []BoardTeam `json:"teams"`
+}
+
+// BoardTeam is a team on the status board
+type BoardTeam struct {
+	TeamID         int       `json:"team_id"`
+	Name           string    `json:"name"`
+	Status         string    `json:"status" doc:"Status in effect today, as counted by the readiness rollup"`
+	ReportedStatus string    `json:"reported_status" doc:"The team's current status, which applies only inside its window"`
+	StatusStart    time.Time `json:"status_start"`
+	StatusEnd      time.Time `json:"status_end"`
+	POC            string    `json:"poc"`
+	Comments       string    `json:"comments" doc:"The team's latest comment"`
+	StatusSince    time.Time `json:"status_since" doc:"When the status in effect today took effect: when it was reported or its window opened, whichever is later, or when its window closed"`
+	HeldSeconds    int64     `json:"held_seconds" doc:"How long the team has held the status in effect today"`
+	HeldFor        string    `json:"held_for" doc:"held_seconds for display, e.g. 2d 5h"`
+}
+
+// StaleStatusReport lists the teams of an exercise whose status has not been
+// set or confirmed within the stale interval
+type StaleStatusReport struct {
+	ExerciseID        int         `json:"exercise_id"`
+	Name              string      `json:"name"`
+	StaleAfterSeconds int64       `json:"stale_after_seconds" doc:"How long a status may go unconfirmed before it is stale"`
+	GeneratedAt       time.Time   `json:"generated_at"`
+	Teams             []StaleTeam `json:"teams"`
+}
+
+// StaleTeam is a team whose status is stale, longest unconfirmed first
+type StaleTeam struct {
+	TeamID             int       `json:"team_id"`
+	Name               string    `json:"name"`
+	DivisionID         int       `json:"division_id"`
+	DivisionName       string    `json:"division_name"`
+	Status             string    `json:"status"`
+	POC                string    `json:"poc"`
+	StatusConfirmedAt  time.Time `json:"status_confirmed_at" doc:"When the status was last set or confirmed"`
+	UnconfirmedSeconds int64     `json:"unconfirmed_seconds"`
+	UnconfirmedFor     string    `json:"unconfirmed_for" doc:"unconfirmed_seconds for display, e.g. 3d 2h"`
+	Flagged            bool      `json:"flagged" doc:"Whether the status check has flagged the team"`
+}
+
+// EscalationPolicy is the ordered list of escalation steps of an exercise
+type EscalationPolicy struct {
+	ExerciseID int              `json:"exercise_id"`
+	Steps      []EscalationStep `json:"steps"`
+}
+
+// EscalationStep notifies someone once a team has held a status for a while
+type EscalationStep struct {
+	Status       string `json:"status" validate:"required,oneof=yellow red"`
+	AfterMinutes int    `json:"after_minutes" validate:"required,min=1" doc:"Minutes the team must have held the status"`
+	Notify       string `json:"notify" validate:"required,oneof=team_poc division_poc exercise_poc" doc:"team_poc, division_poc (the division lead) or exercise_poc (the exercise event, SRD and CPD POCs)"`
+}
+
+// Escalation records one escalation step triggered for a team
+type Escalation struct {
+	ID             int        `json:"id"`
+	ExerciseID     int        `json:"exercise_id"`
+	TeamID         int        `json:"team_id"`
+	TeamName       string     `json:"team_name"`
+	DivisionID     int        `json:"division_id"`
+	DivisionName   string     `json:"division_name"`
+	Status         string     `json:"status"`
+	StatusSince    time.Time  `json:"status_since" doc:"When the team changed to the status, or its status window opened if later"`
+	AfterMinutes   int        `json:"after_minutes"`
+	Notify         string     `json:"notify"`
+	Recipients     []string   `json:"recipients" doc:"The POCs notified"`
+	TriggeredAt    time.Time  `json:"triggered_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy string     `json:"acknowledged_by"`
+	Note           string     `json:"note"`
+}
+
+// StatusPeriod is a stretch of time over which a team held one status
+type StatusPeriod struct {
+	TeamID      int        `json:"team_id"`
+	Status      string     `json:"status"`
+	StatusStart time.Time  `json:"status_start" doc:"The team's status window while it held the status; unset bounds are open"`
+	StatusEnd   time.Time  `json:"status_end"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at" doc:"Unset while the team still holds the status"`
+}
+
+// StatusTime is how long a team, division or exercise spent in each status
+// over a range
+type StatusTime struct {
+	TrackedHours  float64 `json:"tracked_hours" doc:"Hours of the range covered by status history"`
+	GreenHours    float64 `json:"green_hours"`
+	YellowHours   float64 `json:"yellow_hours"`
+	RedHours      float64 `json:"red_hours"`
+	UnknownHours  float64 `json:"unknown_hours" doc:"Hours when no team counted toward the rollup"`
+	GreenPercent  float64 `json:"green_percent" doc:"Share of the tracked hours that were green"`
+	YellowPercent float64 `json:"yellow_percent"`
+	RedPercent    float64 `json:"red_percent"`
+}
+
+// Recovery summarizes how quickly teams went from red back to green
+type Recovery struct {
+	RedEpisodes int      `json:"red_episodes" doc:"Times a team turned red within the range"`
+	Recovered   int      `json:"recovered" doc:"Red episodes that have since returned to green"`
+	MTTRHours   *float64 `json:"mttr_hours" doc:"Mean hours from red to green over the recovered episodes; null when none recovered"`
+}
+
+// ExerciseAnalytics reports how an exercise's statuses held up over a range
+type ExerciseAnalytics struct {
+	ExerciseID  int                 `json:"exercise_id"`
+	Name        string              `json:"name"`
+	From        string              `json:"from" doc:"First day covered (YYYY-MM-DD, UTC)"`
+	To          string              `json:"to" doc:"Last day covered (YYYY-MM-DD, UTC)"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Time        StatusTime          `json:"time" doc:"Time the exercise readiness rollup spent in each status"`
+	Recovery    Recovery            `json:"recovery" doc:"Over the red episodes of every team"`
+	Divisions   []DivisionAnalytics `json:"divisions"`
+	Daily       []DailyReadiness    `json:"daily"`
+}
+
+// DivisionAnalytics is a division's part in the exercise analytics
+type DivisionAnalytics struct {
+	DivisionID int             `json:"division_id"`
+	Name       string          `json:"name"`
+	Time       StatusTime      `json:"time" doc:"Time the division readiness rollup spent in each status"`
+	Recovery   Recovery        `json:"recovery" doc:"Over the red episodes of the division's teams"`
+	Teams      []TeamAnalytics `json:"teams"`
+}
+
+// TeamAnalytics is a team's part in the exercise analytics
+type TeamAnalytics struct {
+	TeamID   int        `json:"team_id"`
+	Name     string     `json:"name"`
+	Weight   int        `json:"weight"`
+	Time     StatusTime `json:"time"`
+	Recovery Recovery   `json:"recovery"`
+}
+
+// DailyReadiness is the weighted share of team time spent in each status on
+// one day
+type DailyReadiness struct {
+	Date          string                   `json:"date" doc:"YYYY-MM-DD, UTC"`
+	GreenPercent  float64                  `json:"green_percent"`
+	YellowPercent float64                  `json:"yellow_percent"`
+	RedPercent    float64                  `json:"red_percent"`
+	Divisions     []DivisionDailyReadiness `json:"divisions"`
+}
+
+// DivisionDailyReadiness is a division's share of team time in each status on
+// one day
+type DivisionDailyReadiness struct {
+	DivisionID    int     `json:"division_id"`
+	Name          string  `json:"name"`
+	GreenPercent  float64 `json:"green_percent"`
+	YellowPercent float64 `json:"yellow_percent"`
+	RedPercent    float64 `json:"red_percent"`
+}
+
+// ExerciseTrend summarizes one exercise so exercises can be compared
+type ExerciseTrend struct {
+	ExerciseID int        `json:"exercise_id"`
+	Name       string     `json:"name"`
+	StartDate  time.Time  `json:"start_date"`
+	EndDate    time.Time  `json:"end_date"`
+	Time       StatusTime `json:"time" doc:"Time the exercise readiness rollup spent in each status"`
+	Recovery   Recovery   `json:"recovery"`
+}
+
+// Sitrep is an exercise's situation report for one day
+type Sitrep struct {
+	ExerciseID     int                  `json:"exercise_id"`
+	Name           string               `json:"name"`
+	Date           string               `json:"date" doc:"Day reported on (YYYY-MM-DD, UTC)"`
+	Tomorrow       string               `json:"tomorrow" doc:"The day after, whose events are listed"`
+	AsOf           time.Time            `json:"as_of" doc:"Now for today, the end of a past day, the start of a future one"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+	Readiness      Readiness            `json:"readiness"`
+	Divisions      []SitrepDivision     `json:"divisions"`
+	StatusChanges  []SitrepStatusChange `json:"status_changes" doc:"Team status changes in the 24 hours before as_of, oldest first"`
+	EventsToday    []Event              `json:"events_today"`
+	EventsTomorrow []Event              `json:"events_tomorrow"`
+	OverdueTasks   []Task               `json:"overdue_tasks" doc:"Open tasks due before as_of, most overdue first"`
+	DueSoonTasks   []Task               `json:"due_soon_tasks" doc:"Open tasks due within the due-soon interval after as_of"`
+}
+
+// SitrepDivision is a division in a situation report
+type SitrepDivision struct {
+	DivisionID int          `json:"division_id"`
+	Name       string       `json:"name"`
+	POC        string       `json:"poc"`
+	Readiness  Readiness    `json:"readiness"`
+	Teams      []SitrepTeam `json:"teams"`
+}
+
+// SitrepTeam is a team in a situation report
+type SitrepTeam struct {
+	TeamID      int       `json:"team_id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status" doc:"Status in effect on the day"`
 	StatusStart time.Time `json:"status_start"`
 	StatusEnd   time.Time `json:"status_end"`
-	Comments   string    `json:"comments"`
+	StatusSince time.Time `json:"status_since" doc:"When the team changed to its current status"`
+	POC         string    `json:"poc"`
+	Comments    string    `json:"comments"`
+}
+
+// SitrepStatusChange is one team status change in a situation report
+type SitrepStatusChange struct {
+	TeamID         int       `json:"team_id"`
+	TeamName       string    `json:"team_name"`
+	DivisionName   string    `json:"division_name"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+// SitrepTemplate holds the templates an exercise's situation reports are
+// rendered with. An empty template means the built-in one.
+type SitrepTemplate struct {
+	ExerciseID int    `json:"exercise_id"`
+	Markdown   string `json:"markdown" validate:"max=100000" doc:"Go text/template rendered with the report"`
+	HTML       string `json:"html" validate:"max=100000" doc:"Go html/template rendered with the report"`
 }
 
 type Event struct {
 	ID         int       `json:"id"`
-	ExerciseID int       `json:"exercise_id"`
-	Name       string    `json:"name"`
-	StartDate  time.Time `json:"start_date"`
-	EndDate    time.Time `json:"end_date"`
-	Type       string    `json:"type"`       // "milestone", "phase", "meeting", etc.
-	Priority   string    `json:"priority"`   // "high", "medium", "low"
-	POC        string    `json:"poc"`
-	Status     string    `json:"status"`     // "planned", "in-progress", "completed", "cancelled"
-	Description string   `json:"description"`
-	Location   string    `json:"location"`
+	ExerciseID int       `json:"exercise_id" validate:"required,exists=exercise"`
+	Name       string    `json:"name" validate:"required,max=255"`
+	StartDate  time.Time `json:"start_date" validate:"required"`
+	EndDate    time.Time `json:"end_date" validate:"required,notbefore=StartDate"`
+	Type       string    `json:"type" validate:"oneof=milestone phase meeting training deployment other"`
+	Priority   string    `json:"priority" validate:"oneof=high medium low"`
+	POC        string    `json:"poc" validate:"max=255"`
+	Status     string    `json:"status" validate:"oneof=planned in-progress completed cancelled"`
+	Description string   `json:"description" validate:"max=10000"`
+	Location   string    `json:"location" validate:"max=255"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type Task struct {
 	ID          int        `json:"id"`
-	ExerciseID  int        `json:"exercise_id"`
-	TeamID      *int       `json:"team_id"`      // Keep for backward compatibility
-	TeamIDs     []int      `json:"team_ids"`     // New field for multiple teams
+	ExerciseID  int        `json:"exercise_id" validate:"required,exists=exercise"`
+	TeamID      *int       `json:"team_id" validate:"exists=team"` // Keep for backward compatibility
+	TeamIDs     []int      `json:"team_ids" validate:"exists=team"` // New field for multiple teams
 	Teams       []Team     `json:"teams"`        // Full team objects for display
 	TeamName    string     `json:"team_name"`    // For display purposes (backward compatibility)
 	DivisionName string    `json:"division_name"` // For display purposes (backward compatibility)
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`       // "pending", "in-progress", "completed"
+	Name        string     `json:"name" validate:"required,max=255"`
+	Description string     `json:"description" validate:"max=10000"`
+	Status      string     `json:"status" validate:"oneof=pending in-progress completed"`
 	DueDate     *time.Time `json:"due_date"`
-	AssignedTo  string     `json:"assigned_to"`  // Keep for backward compatibility
+	AssignedTo  string     `json:"assigned_to" validate:"max=255"` // Keep for backward compatibility
 	CompletedAt *time.Time `json:"completed_at"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
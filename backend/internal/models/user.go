@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type User struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username" validate:"max=255"`
+	DisplayName  string     `json:"display_name" validate:"max=255"`
+	Email        string     `json:"email" validate:"max=255"`
+	PasswordHash string     `json:"-"`
+	Active       bool       `json:"active"`
+	Service      bool       `json:"service"` // Service accounts own service API keys and cannot sign in
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type AuditEntry struct {
+	ID            int         `json:"id"`
+	ActorID       *int        `json:"actor_id"`
+	ActorUsername string      `json:"actor_username"` // "system" for changes made without a signed-in user
+	Action        string      `json:"action"`         // Change type, e.g. "team.status_changed"
+	ExerciseID    *int        `json:"exercise_id"`
+	Data          interface{} `json:"data"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type RoleGrant struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id" validate:"required,exists=user"`
+	Username   string    `json:"username,omitempty"`
+	Role       string    `json:"role" validate:"required"`               // "admin", "exercise_planner", "division_lead", "team_lead", "viewer"
+	ExerciseID *int      `json:"exercise_id" validate:"exists=exercise"` // Nil for a grant that applies to every exercise
+	DivisionID *int      `json:"division_id" validate:"exists=division"` // Narrows an exercise grant to one division
+	TeamID     *int      `json:"team_id" validate:"exists=team"`         // Narrows a division grant to one team
+	GrantedBy  *int      `json:"granted_by"`
+	Source     string    `json:"source"` // "manual", or "oidc" for grants mapped from identity provider claims at login
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`    // "personal" or "service"
+	Prefix     string     `json:"prefix"`  // Start of the key, to tell keys apart without storing them
+	UserID     int        `json:"user_id"` // Owner of a personal key, or the service account of a service key
+	Username   string     `json:"username,omitempty"`
+	Scopes     []string   `json:"scopes"` // "read", "tasks:write", "admin"
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  *int       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
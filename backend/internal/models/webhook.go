@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"` // Only returned when the subscription is created
+	Events      []string  `json:"events"`           // Change types such as "team.status_changed", "team.*" or "*"
+	Description string    `json:"description" validate:"max=10000"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int                      `json:"id"`
+	SubscriptionID int                      `json:"subscription_id"`
+	EventType      string                   `json:"event_type"`
+	Payload        json.RawMessage          `json:"payload"`
+	Status         string                   `json:"status"` // "pending", "delivered", "failed"
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time               `json:"last_attempt_at"`
+	ResponseStatus *int                     `json:"response_status"`
+	LastError      string                   `json:"last_error"`
+	DeliveredAt    *time.Time               `json:"delivered_at"`
+	CreatedAt      time.Time                `json:"created_at"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             int       `json:"id"`
+	DeliveryID     int       `json:"delivery_id"`
+	AttemptedAt    time.Time `json:"attempted_at"`
+	ResponseStatus *int      `json:"response_status"`
+	Error          string    `json:"error"`
+	DurationMS     int       `json:"duration_ms"`
+}
@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"srd-calendar-project/backend/internal/authz"
+	serverconfig "srd-calendar-project/backend/internal/config"
+	"strings"
+)
+
+// RoleMapping grants a role to users whose ID token carries Value in Claim.
+// Claim defaults to the configured groups claim. The scope fields narrow the
+// grant the same way they do for grants made by hand.
+type RoleMapping struct {
+	Claim      string `json:"claim"`
+	Value      string `json:"value"`
+	Role       string `json:"role"`
+	ExerciseID *int   `json:"exercise_id"`
+	DivisionID *int   `json:"division_id"`
+	TeamID     *int   `json:"team_id"`
+}
+
+// Config describes the identity provider and how its users map onto local
+// accounts
+type Config struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string // Empty for a public client, which relies on PKCE alone
+	RedirectURL   string // Must point at /api/auth/oidc/callback
+	PostLoginURL  string // Where the browser is sent after the callback
+	ProviderName  string // Shown on the sign-in button
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+	RoleMappings  []RoleMapping
+}
+
+var config Config
+
+// Enabled reports whether single sign-on has been configured
+func Enabled() bool {
+	return config.Issuer != ""
+}
+
+// ProviderName returns the label for the sign-in button
+func ProviderName() string {
+	return config.ProviderName
+}
+
+// LoadConfig applies the identity provider settings from the server
+// configuration. Single sign-on stays disabled when no issuer is set.
+func LoadConfig(settings serverconfig.OIDCConfig) error {
+	c := Config{
+		Issuer:        strings.TrimSuffix(settings.Issuer, "/"),
+		ClientID:      settings.ClientID,
+		ClientSecret:  settings.ClientSecret,
+		RedirectURL:   settings.RedirectURL,
+		PostLoginURL:  settings.PostLoginURL,
+		ProviderName:  settings.ProviderName,
+		Scopes:        settings.Scopes,
+		UsernameClaim: settings.UsernameClaim,
+		GroupsClaim:   settings.GroupsClaim,
+	}
+	if c.Issuer == "" {
+		return nil
+	}
+
+	if c.ClientID == "" || c.RedirectURL == "" {
+		return fmt.Errorf("client_id and redirect_url are required when issuer is set")
+	}
+	if c.PostLoginURL == "" {
+		c.PostLoginURL = "/"
+	}
+	if c.ProviderName == "" {
+		c.ProviderName = "Single Sign-On"
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "profile", "email"}
+	}
+	if c.UsernameClaim == "" {
+		c.UsernameClaim = "preferred_username"
+	}
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = "groups"
+	}
+
+	if len(settings.RoleMappings) > 0 {
+		if err := json.Unmarshal(settings.RoleMappings, &c.RoleMappings); err != nil {
+			return fmt.Errorf("invalid role_mappings: %w", err)
+		}
+	}
+	for i, m := range c.RoleMappings {
+		if !authz.IsRole(m.Role) {
+			return fmt.Errorf("role_mappings entry %d: unknown role %q", i, m.Role)
+		}
+		if m.Value == "" {
+			return fmt.Errorf("role_mappings entry %d: value is required", i)
+		}
+		if m.Role == authz.RoleAdmin && (m.ExerciseID != nil || m.DivisionID != nil || m.TeamID != nil) {
+			return fmt.Errorf("role_mappings entry %d: the admin role cannot be scoped", i)
+		}
+		if m.Claim == "" {
+			c.RoleMappings[i].Claim = c.GroupsClaim
+		}
+	}
+
+	config = c
+	return nil
+}
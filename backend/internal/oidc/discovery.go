@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// discoveryTTL is how long the discovery document and signing keys are cached
+const discoveryTTL = time.Hour
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// metadata holds the parts of the provider's discovery document that the
+// authorization-code flow needs
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+var (
+	mu        sync.Mutex
+	provider  *metadata
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+)
+
+// discover returns the provider metadata, fetching the discovery document
+// and signing keys when the cache is empty or stale
+func discover() (*metadata, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if provider != nil && time.Since(fetchedAt) < discoveryTTL {
+		return provider, nil
+	}
+	return refresh()
+}
+
+// signingKey returns the provider key with the given ID. An unknown key ID
+// triggers one refresh, since providers rotate keys without notice.
+func signingKey(kid string) (crypto.PublicKey, error) {
+	if _, err := discover(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if _, err := refresh(); err != nil {
+		return nil, err
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// refresh fetches the discovery document and key set. mu must be held.
+func refresh() (*metadata, error) {
+	var m metadata
+	if err := getJSON(config.Issuer+"/.well-known/openid-configuration", &m); err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %w", err)
+	}
+	if m.Issuer != config.Issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", m.Issuer, config.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing required endpoints")
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(m.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %w", err)
+	}
+
+	parsed := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		parsed[k.Kid] = key
+	}
+
+	provider, keys, fetchedAt = &m, parsed, time.Now()
+	return provider, nil
+}
+
+func getJSON(url string, v interface{}) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jwk is a single JSON Web Key. Only RSA and P-256 EC keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC key")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
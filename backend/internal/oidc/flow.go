@@ -0,0 +1,187 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// StateCookie binds a login attempt to the browser that started it
+	StateCookie = "oidc_state"
+	// loginTimeout is how long the user has to finish signing in at the provider
+	loginTimeout = 10 * time.Minute
+)
+
+// ErrLoginExpired is returned when the callback's state is unknown, already
+// used or older than loginTimeout
+var ErrLoginExpired = errors.New("sign-in attempt expired or was already used")
+
+// Begin starts an authorization-code login with PKCE. It returns the
+// provider URL to send the browser to and the state value to store in
+// StateCookie.
+func Begin(ctx context.Context) (string, string, error) {
+	m, err := discover()
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := database.DB.ExecContext(ctx, "DELETE FROM oidc_logins WHERE expires_at <= CURRENT_TIMESTAMP"); err != nil {
+		slog.ErrorContext(ctx, "Error pruning sign-in attempts", "error", err)
+	}
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO oidc_logins (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		hashState(state), verifier, nonce, time.Now().Add(loginTimeout))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error storing sign-in attempt", "error", err)
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {config.RedirectURL},
+		"scope":                 {strings.Join(config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(m.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return m.AuthorizationEndpoint + separator + params.Encode(), state, nil
+}
+
+// Finish completes a login: it redeems the authorization code, verifies the
+// ID token, and returns the local user, provisioning them on first sign-in
+// and refreshing their mapped roles
+func Finish(ctx context.Context, state, code string) (models.User, error) {
+	var verifier, nonce string
+	err := database.DB.QueryRowContext(ctx, `
+		DELETE FROM oidc_logins
+		WHERE state_hash = $1 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING code_verifier, nonce`,
+		hashState(state)).Scan(&verifier, &nonce)
+	if err != nil {
+		return models.User{}, ErrLoginExpired
+	}
+
+	rawIDToken, err := exchange(ctx, code, verifier)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	claims, err := verifyIDToken(rawIDToken, nonce)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return provision(ctx, claims)
+}
+
+// exchange redeems an authorization code at the token endpoint and returns
+// the raw ID token
+func exchange(ctx context.Context, code, verifier string) (string, error) {
+	m, err := discover()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"client_id":     {config.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("redeeming authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s: %s %s", resp.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token response has no ID token")
+	}
+
+	return body.IDToken, nil
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// PostLoginURL returns where the browser goes once the callback is done.
+// errorCode is appended as sso_error so the sign-in page can explain a
+// failure.
+func PostLoginURL(errorCode string) string {
+	if errorCode == "" {
+		return config.PostLoginURL
+	}
+
+	separator := "?"
+	if strings.Contains(config.PostLoginURL, "?") {
+		separator = "&"
+	}
+	return config.PostLoginURL + separator + "sso_error=" + url.QueryEscape(errorCode)
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"srd-calendar-project/backend/internal/auth"
+	"srd-calendar-project/backend/internal/authz"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+)
+
+var (
+	// ErrUserInactive is returned when the linked local account is deactivated
+	ErrUserInactive = errors.New("account is deactivated")
+	// ErrUsernameConflict is returned on first sign-in when the username from
+	// the ID token already belongs to a different local account. Accounts are
+	// never linked by username, since that would let the provider take over a
+	// local administrator.
+	ErrUsernameConflict = errors.New("username already belongs to another account")
+)
+
+// provision returns the local user linked to the token's issuer and
+// subject, creating the account on first sign-in. Profile fields and mapped
+// roles are refreshed on every sign-in so changes made at the provider take
+// effect at the user's next login.
+func provision(ctx context.Context, claims Claims) (models.User, error) {
+	issuer, subject := claims.String("iss"), claims.String("sub")
+	displayName := claims.String("name")
+	email := claims.String("email")
+
+	var userID int
+	err := database.DB.QueryRowContext(ctx, `
+		UPDATE users
+		SET display_name = COALESCE(NULLIF($3, ''), display_name),
+		    email = COALESCE(NULLIF($4, ''), email),
+		    last_login_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE oidc_issuer = $1 AND oidc_subject = $2
+		RETURNING id`,
+		issuer, subject, displayName, email).Scan(&userID)
+
+	if err == sql.ErrNoRows {
+		username := usernameFromClaims(claims)
+		err = database.DB.QueryRowContext(ctx, `
+			INSERT INTO users (username, display_name, email, active, oidc_issuer, oidc_subject, last_login_at)
+			VALUES ($1, $2, $3, TRUE, $4, $5, CURRENT_TIMESTAMP)
+			ON CONFLICT (username) DO NOTHING
+			RETURNING id`,
+			username, displayName, email, issuer, subject).Scan(&userID)
+		if err == sql.ErrNoRows {
+			slog.WarnContext(ctx, "Single sign-on refused: username belongs to another account", "username", username)
+			return models.User{}, ErrUsernameConflict
+		}
+		if err == nil {
+			slog.InfoContext(ctx, "Provisioned user", "username", username, "issuer", issuer)
+		}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error provisioning single sign-on user", "error", err)
+		return models.User{}, err
+	}
+
+	user, found := auth.GetUserByID(ctx, userID)
+	if !found {
+		return models.User{}, errors.New("provisioned user not found")
+	}
+	if !user.Active {
+		return models.User{}, ErrUserInactive
+	}
+
+	if err := authz.ReplaceGrants(ctx, user.ID, authz.SourceOIDC, mappedGrants(claims)); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// usernameFromClaims picks the local username for a new account: the
+// configured username claim, then the email address, then the subject
+func usernameFromClaims(claims Claims) string {
+	for _, name := range []string{config.UsernameClaim, "email", "sub"} {
+		if v := strings.TrimSpace(claims.String(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// mappedGrants returns the grants the role mappings give for claims
+func mappedGrants(claims Claims) []models.RoleGrant {
+	grants := []models.RoleGrant{}
+	for _, m := range config.RoleMappings {
+		if !contains(claims.Values(m.Claim), m.Value) {
+			continue
+		}
+		grants = append(grants, models.RoleGrant{
+			Role:       m.Role,
+			ExerciseID: m.ExerciseID,
+			DivisionID: m.DivisionID,
+			TeamID:     m.TeamID,
+		})
+	}
+	return grants
+}
@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking token timestamps
+const clockSkew = 2 * time.Minute
+
+// Claims is the decoded payload of an ID token
+type Claims map[string]interface{}
+
+// String returns a string claim, or "" when it is missing or not a string
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Values returns a claim as a list of strings. A single string claim is
+// returned as a one-element list, so a provider that sends one group as a
+// plain string still matches.
+func (c Claims) Values(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	f, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// verifyIDToken checks an ID token's signature, issuer, audience, lifetime
+// and nonce, and returns its claims
+func verifyIDToken(raw, nonce string) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed ID token header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed ID token signature")
+	}
+	key, err := signingKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, digest[:], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+
+	m, err := discover()
+	if err != nil {
+		return nil, err
+	}
+	if claims.String("iss") != m.Issuer {
+		return nil, fmt.Errorf("ID token issued by %q, expected %q", claims.String("iss"), m.Issuer)
+	}
+
+	audiences := claims.Values("aud")
+	if !contains(audiences, config.ClientID) {
+		return nil, errors.New("ID token was not issued for this client")
+	}
+	if len(audiences) > 1 && claims.String("azp") != config.ClientID {
+		return nil, errors.New("ID token authorized party does not match this client")
+	}
+
+	now := time.Now()
+	exp, ok := claims.time("exp")
+	if !ok || now.After(exp.Add(clockSkew)) {
+		return nil, errors.New("ID token has expired")
+	}
+	if iat, ok := claims.time("iat"); ok && iat.After(now.Add(clockSkew)) {
+		return nil, errors.New("ID token was issued in the future")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.String("nonce")), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce does not match")
+	}
+	if claims.String("sub") == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+
+	return claims, nil
+}
+
+// verifySignature checks a SHA-256 based JWS signature. Only RS256 and
+// ES256 are accepted, and the algorithm must match the key type so a token
+// cannot pick a weaker check.
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("ID token algorithm does not match signing key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature); err != nil {
+			return errors.New("invalid ID token signature")
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("ID token algorithm does not match signing key")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid ID token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported ID token algorithm %q", alg)
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,388 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document, served at
+// /api/openapi.json with an interactive viewer at /api/docs. Request and
+// response schemas are generated from the Go types the handlers use, so the
+// lengths and allowed values in validate tags appear in the document.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"srd-calendar-project/backend/internal/handlers"
+	"srd-calendar-project/backend/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// route describes one operation. Body and Response are values of the types
+// sent and returned; a nil Response means no body.
+type route struct {
+	Method, Path, Tag, Summary string
+	Public                     bool
+	Params                     []Parameter
+	Body                       interface{}
+	Patch                      []string // Fields of Body a merge patch may set
+	Status                     int
+	Response                   interface{}
+	ContentType                string // Response media type when not JSON
+}
+
+func pathID(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "integer"}}
+}
+
+func query(name, typ, description string, required bool) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Required: required, Schema: &Schema{Type: typ}}
+}
+
+var routes = []route{
+	{Method: "GET", Path: "/healthz", Tag: "Operations", Summary: "Liveness probe", Public: true, Response: "ok", ContentType: "text/plain"},
+	{Method: "GET", Path: "/readyz", Tag: "Operations", Summary: "Readiness probe; 503 when the database or schema is not ready", Public: true, Response: ReadyStatus{}},
+	{Method: "GET", Path: "/metrics", Tag: "Operations", Summary: "Prometheus metrics", Public: true, Response: "", ContentType: "text/plain"},
+	{Method: "GET", Path: "/api/openapi.json", Tag: "Operations", Summary: "This document", Public: true, Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/api/docs", Tag: "Operations", Summary: "Interactive API documentation", Public: true, Response: "", ContentType: "text/html"},
+
+	{Method: "GET", Path: "/api/auth/config", Tag: "Authentication", Summary: "Available sign-in methods", Public: true, Response: AuthConfig{}},
+	{Method: "POST", Path: "/api/auth/login", Tag: "Authentication", Summary: "Sign in with a username and password", Public: true, Body: LoginRequest{}, Response: LoginResponse{}},
+	{Method: "POST", Path: "/api/auth/oidc/login", Tag: "Authentication", Summary: "Start single sign-on", Public: true, Response: OIDCLogin{}},
+	{Method: "GET", Path: "/api/auth/oidc/callback", Tag: "Authentication", Summary: "Identity provider redirect after single sign-on; redirects to the application", Public: true,
+		Params: []Parameter{query("code", "string", "Authorization code", false), query("state", "string", "State from the login request", false),
+			query("error", "string", "Error reported by the identity provider", false)},
+		Status: http.StatusFound},
+	{Method: "POST", Path: "/api/auth/logout", Tag: "Authentication", Summary: "End the current session", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/auth/me", Tag: "Authentication", Summary: "The signed-in user", Response: models.User{}},
+	{Method: "PUT", Path: "/api/auth/password", Tag: "Authentication", Summary: "Change the signed-in user's password and end their other sessions", Body: ChangePasswordRequest{}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/stream", Tag: "Live updates", Summary: "Server-Sent Events stream of changes",
+		Params: []Parameter{query("exercise_id", "integer", "Only changes to this exercise", false),
+			query("types", "string", "Comma-separated change types or wildcards, e.g. team.*", false),
+			query("last_event_id", "integer", "Replay changes after this ID; the Last-Event-ID header does the same", false)},
+		Response: "", ContentType: "text/event-stream"},
+
+	{Method: "GET", Path: "/api/users", Tag: "Users", Summary: "List user accounts", Response: []models.User{}},
+	{Method: "POST", Path: "/api/users", Tag: "Users", Summary: "Create a local user account", Body: CreateUserRequest{}, Status: http.StatusCreated, Response: models.User{}},
+	{Method: "PUT", Path: "/api/users/{id}", Tag: "Users", Summary: "Update a user's display name, email and active flag", Params: []Parameter{pathID("id", "User ID")}, Body: models.User{}, Response: models.User{}},
+	{Method: "DELETE", Path: "/api/users/{id}", Tag: "Users", Summary: "Delete a user account", Params: []Parameter{pathID("id", "User ID")}, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/audit", Tag: "Users", Summary: "Audit log",
+		Params: []Parameter{query("exercise_id", "integer", "", false), query("actor_id", "integer", "", false),
+			query("action", "string", "Change type", false), query("before_id", "integer", "Entries older than this one; pass the last ID of a page for the next", false),
+			query("limit", "integer", "1 to 1000; defaults to 100", false)},
+		Response: []models.AuditEntry{}},
+
+	{Method: "GET", Path: "/api/api-keys", Tag: "API keys", Summary: "List your API keys", Params: []Parameter{query("all", "boolean", "Every key; user administrators only", false)}, Response: []models.APIKey{}},
+	{Method: "POST", Path: "/api/api-keys", Tag: "API keys", Summary: "Create an API key", Body: CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: CreatedAPIKey{}},
+	{Method: "DELETE", Path: "/api/api-keys/{id}", Tag: "API keys", Summary: "Revoke an API key", Params: []Parameter{pathID("id", "API key ID")}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/me/permissions", Tag: "Roles", Summary: "The signed-in user's grants and the permissions each gives", Response: []GrantPermissions{}},
+	{Method: "GET", Path: "/api/grants", Tag: "Roles", Summary: "List role grants",
+		Params: []Parameter{query("user_id", "integer", "", false), query("exercise_id", "integer", "", false)}, Response: []models.RoleGrant{}},
+	{Method: "POST", Path: "/api/grants", Tag: "Roles", Summary: "Grant a role", Body: models.RoleGrant{}, Status: http.StatusCreated, Response: models.RoleGrant{}},
+	{Method: "DELETE", Path: "/api/grants/{id}", Tag: "Roles", Summary: "Revoke a role grant", Params: []Parameter{pathID("id", "Grant ID")}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/exercises", Tag: "Exercises", Summary: "List exercises",
+		Params: []Parameter{query("division_id", "integer", "Exercises containing this division", false),
+			query("team_id", "integer", "Exercises containing this team", false),
+			query("division_name", "string", "Exercises with a division of this name", false),
+			query("team_name", "string", "Exercises with a team of this name", false)},
+		Response: []models.Exercise{}},
+	{Method: "POST", Path: "/api/exercises", Tag: "Exercises", Summary: "Create an exercise", Body: models.Exercise{}, Status: http.StatusCreated, Response: models.Exercise{}},
+	{Method: "PUT", Path: "/api/exercises/{id}", Tag: "Exercises", Summary: "Replace an exercise", Params: []Parameter{pathID("id", "Exercise ID")}, Body: models.Exercise{}, Response: models.Exercise{}},
+	{Method: "PATCH", Path: "/api/exercises/{id}", Tag: "Exercises", Summary: "Change some fields of an exercise", Params: []Parameter{pathID("id", "Exercise ID")}, Body: models.Exercise{}, Patch: handlers.ExercisePatchFields, Response: models.Exercise{}},
+	{Method: "DELETE", Path: "/api/exercises/{id}", Tag: "Exercises", Summary: "Delete an exercise", Params: []Parameter{pathID("id", "Exercise ID")}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/divisions", Tag: "Divisions and teams", Summary: "List an exercise's divisions with their teams",
+		Params: []Parameter{query("exercise_id", "integer", "", true)}, Response: []models.Division{}},
+	{Method: "POST", Path: "/api/divisions", Tag: "Divisions and teams", Summary: "Create a division", Body: models.Division{}, Status: http.StatusCreated, Response: models.Division{}},
+	{Method: "PUT", Path: "/api/divisions/update", Tag: "Divisions and teams", Summary: "Update a division's name and learning objectives; the body's id names the division", Body: models.Division{}, Response: models.Division{}},
+	{Method: "PATCH", Path: "/api/divisions/{id}", Tag: "Divisions and teams", Summary: "Change some fields of a division", Params: []Parameter{pathID("id", "Division ID")}, Body: models.Division{}, Patch: handlers.DivisionPatchFields, Response: models.Division{}},
+	{Method: "DELETE", Path: "/api/divisions/{id}", Tag: "Divisions and teams", Summary: "Delete a division and its teams", Params: []Parameter{pathID("id", "Division ID")}, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/exercises/{id}/readiness", Tag: "Exercises", Summary: "Readiness of an exercise and its divisions from the team statuses in effect on a date",
+		Params: []Parameter{pathID("id", "Exercise ID"), query("date", "string", "YYYY-MM-DD; defaults to today", false)}, Response: models.ExerciseReadiness{}},
+	{Method: "GET", Path: "/api/exercises/{id}/analytics", Tag: "Exercises", Summary: "Time in each status per team, division and exercise, mean time to recover from red and daily readiness, from the status history",
+		Params: []Parameter{pathID("id", "Exercise ID"), query("from", "string", "First day, YYYY-MM-DD; defaults to the exercise's start", false),
+			query("to", "string", "Last day, YYYY-MM-DD; defaults to the exercise's end", false),
+			query("format", "string", "json (default) or csv", false),
+			query("view", "string", "For csv: status (default) for time in status, daily for daily readiness", false)},
+		Response: models.ExerciseAnalytics{}},
+	{Method: "GET", Path: "/api/analytics/trend", Tag: "Exercises", Summary: "Time in status and recovery of each exercise that has started, in start order, to compare exercises",
+		Params: []Parameter{query("from", "string", "Only exercises running on or after this day, YYYY-MM-DD", false),
+			query("to", "string", "Only exercises running on or before this day, YYYY-MM-DD", false),
+			query("format", "string", "json (default) or csv", false)},
+		Response: []models.ExerciseTrend{}},
+	{Method: "GET", Path: "/api/exercises/{id}/sitrep", Tag: "Exercises", Summary: "Situation report for a day: division and team statuses, status changes in the last 24 hours, today's and tomorrow's events, overdue and due-soon tasks",
+		Params: []Parameter{pathID("id", "Exercise ID"), query("date", "string", "YYYY-MM-DD; defaults to today", false),
+			query("format", "string", "json (default), or markdown or html rendered with the exercise's SITREP template", false)},
+		Response: models.Sitrep{}},
+	{Method: "GET", Path: "/api/exercises/{id}/sitrep-template", Tag: "Exercises", Summary: "The templates an exercise's SITREPs are rendered with; the built-in ones where it has not set its own", Params: []Parameter{pathID("id", "Exercise ID")}, Response: models.SitrepTemplate{}},
+	{Method: "PUT", Path: "/api/exercises/{id}/sitrep-template", Tag: "Exercises", Summary: "Replace an exercise's SITREP templates; an empty template goes back to the built-in one", Params: []Parameter{pathID("id", "Exercise ID")}, Body: models.SitrepTemplate{}, Response: models.SitrepTemplate{}},
+	{Method: "GET", Path: "/api/exercises/{id}/stale-statuses", Tag: "Exercises", Summary: "Teams whose status has not been set or confirmed within the stale interval, longest unconfirmed first",
+		Params: []Parameter{pathID("id", "Exercise ID")}, Response: models.StaleStatusReport{}},
+	{Method: "GET", Path: "/api/exercises/{id}/escalation-policy", Tag: "Exercises", Summary: "An exercise's escalation steps", Params: []Parameter{pathID("id", "Exercise ID")}, Response: models.EscalationPolicy{}},
+	{Method: "PUT", Path: "/api/exercises/{id}/escalation-policy", Tag: "Exercises", Summary: "Replace an exercise's escalation steps; an empty list turns escalation off", Params: []Parameter{pathID("id", "Exercise ID")}, Body: models.EscalationPolicy{}, Response: models.EscalationPolicy{}},
+	{Method: "GET", Path: "/api/exercises/{id}/escalations", Tag: "Exercises", Summary: "An exercise's escalation history, newest first",
+		Params: []Parameter{pathID("id", "Exercise ID"), query("open", "boolean", "true leaves out acknowledged escalations", false)}, Response: []models.Escalation{}},
+	{Method: "POST", Path: "/api/escalations/{id}/acknowledge", Tag: "Exercises", Summary: "Acknowledge an escalation, stopping the remaining steps until the team's status changes", Params: []Parameter{pathID("id", "Escalation ID")}, Body: EscalationAckRequest{}, Response: models.Escalation{}},
+	{Method: "PUT", Path: "/api/exercises/{id}/divisions/order", Tag: "Divisions and teams", Summary: "Set the order of an exercise's divisions", Params: []Parameter{pathID("id", "Exercise ID")}, Body: DivisionOrderRequest{}, Response: []models.Division{}},
+	{Method: "GET", Path: "/api/divisions/{id}/teams", Tag: "Divisions and teams", Summary: "List a division's teams", Params: []Parameter{pathID("id", "Division ID")}, Response: []models.Team{}},
+	{Method: "GET", Path: "/api/divisions/{id}/readiness", Tag: "Divisions and teams", Summary: "Readiness of a division from the team statuses in effect on a date",
+		Params: []Parameter{pathID("id", "Division ID"), query("date", "string", "YYYY-MM-DD; defaults to today", false)}, Response: models.DivisionReadiness{}},
+	{Method: "PUT", Path: "/api/divisions/{id}/teams/order", Tag: "Divisions and teams", Summary: "Set the order of a division's teams", Params: []Parameter{pathID("id", "Division ID")}, Body: TeamOrderRequest{}, Response: []models.Team{}},
+	{Method: "POST", Path: "/api/teams", Tag: "Divisions and teams", Summary: "Create a team", Body: models.Team{}, Status: http.StatusCreated, Response: models.Team{}},
+	{Method: "POST", Path: "/api/teams/status", Tag: "Divisions and teams", Summary: "Set the status of several teams at once; all or nothing, one audit entry per team", Body: TeamStatusRequest{}, Response: []models.TeamStatusResult{}},
+	{Method: "GET", Path: "/api/teams/{id}", Tag: "Divisions and teams", Summary: "Get a team", Params: []Parameter{pathID("id", "Team ID")}, Response: models.Team{}},
+	{Method: "PUT", Path: "/api/teams/{id}", Tag: "Divisions and teams", Summary: "Replace a team's name, POC, status window and comments", Params: []Parameter{pathID("id", "Team ID")}, Body: models.Team{}, Response: models.Team{}},
+	{Method: "PATCH", Path: "/api/teams/{id}", Tag: "Divisions and teams", Summary: "Change some fields of a team", Params: []Parameter{pathID("id", "Team ID")}, Body: models.Team{}, Patch: handlers.TeamPatchFields, Response: models.Team{}},
+	{Method: "POST", Path: "/api/teams/{id}/move", Tag: "Divisions and teams", Summary: "Move a team to another division of its exercise, keeping its tasks, status and history", Params: []Parameter{pathID("id", "Team ID")}, Body: TeamMoveRequest{}, Response: models.Team{}},
+	{Method: "POST", Path: "/api/teams/{id}/confirm", Tag: "Divisions and teams", Summary: "Confirm a team's status is still accurate without changing it, clearing any stale flag", Params: []Parameter{pathID("id", "Team ID")}, Response: models.Team{}},
+	{Method: "DELETE", Path: "/api/teams/{id}", Tag: "Divisions and teams", Summary: "Delete a team", Params: []Parameter{pathID("id", "Team ID")}, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/status-board", Tag: "Divisions and teams", Summary: "Every team of the exercises running today, grouped by exercise and division",
+		Params: []Parameter{query("division", "string", "Only divisions with these names; comma-separated or repeated", false),
+			query("status", "string", "Only teams with these statuses: green, yellow, red; comma-separated or repeated", false)},
+		Response: models.StatusBoard{}},
+
+	{Method: "GET", Path: "/api/events", Tag: "Events", Summary: "List an exercise's events", Params: []Parameter{query("exercise_id", "integer", "", true)}, Response: []models.Event{}},
+	{Method: "POST", Path: "/api/events", Tag: "Events", Summary: "Create an event", Body: models.Event{}, Response: models.Event{}},
+	{Method: "PUT", Path: "/api/events/{id}", Tag: "Events", Summary: "Update an event; the body's id names the event", Params: []Parameter{pathID("id", "Event ID")}, Body: models.Event{}, Response: StatusResponse{}},
+	{Method: "PATCH", Path: "/api/events/{id}", Tag: "Events", Summary: "Change some fields of an event", Params: []Parameter{pathID("id", "Event ID")}, Body: models.Event{}, Patch: handlers.EventPatchFields, Response: models.Event{}},
+	{Method: "DELETE", Path: "/api/events/{id}", Tag: "Events", Summary: "Delete an event", Params: []Parameter{pathID("id", "Event ID")}, Response: StatusResponse{}},
+
+	{Method: "GET", Path: "/api/tasks", Tag: "Tasks", Summary: "List an exercise's tasks", Params: []Parameter{query("exercise_id", "integer", "", true)}, Response: []models.Task{}},
+	{Method: "POST", Path: "/api/tasks", Tag: "Tasks", Summary: "Create a task", Body: models.Task{}, Status: http.StatusCreated, Response: models.Task{}},
+	{Method: "PUT", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Update a task", Params: []Parameter{pathID("id", "Task ID")}, Body: models.Task{}, Response: models.Task{}},
+	{Method: "PUT", Path: "/api/tasks/{id}/assign", Tag: "Tasks", Summary: "Assign a task to one team, or unassign it", Params: []Parameter{pathID("id", "Task ID")}, Body: TaskAssignRequest{}, Response: TaskAssignResponse{}},
+	{Method: "PUT", Path: "/api/tasks/{id}/assign-multiple", Tag: "Tasks", Summary: "Set the teams a task is assigned to", Params: []Parameter{pathID("id", "Task ID")}, Body: TaskAssignMultipleRequest{}, Response: TaskAssignMultipleResponse{}},
+	{Method: "PATCH", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Change some fields of a task", Params: []Parameter{pathID("id", "Task ID")}, Body: models.Task{}, Patch: handlers.TaskPatchFields, Response: models.Task{}},
+	{Method: "DELETE", Path: "/api/tasks/{id}", Tag: "Tasks", Summary: "Delete a task", Params: []Parameter{pathID("id", "Task ID")}, Status: http.StatusNoContent},
+
+	{Method: "GET", Path: "/api/webhooks", Tag: "Webhooks", Summary: "List webhook subscriptions", Response: []models.WebhookSubscription{}},
+	{Method: "POST", Path: "/api/webhooks", Tag: "Webhooks", Summary: "Create a webhook subscription; the response carries the signing secret", Body: models.WebhookSubscription{}, Status: http.StatusCreated, Response: models.WebhookSubscription{}},
+	{Method: "GET", Path: "/api/webhooks/{id}", Tag: "Webhooks", Summary: "Get a webhook subscription", Params: []Parameter{pathID("id", "Subscription ID")}, Response: models.WebhookSubscription{}},
+	{Method: "PUT", Path: "/api/webhooks/{id}", Tag: "Webhooks", Summary: "Update a webhook subscription", Params: []Parameter{pathID("id", "Subscription ID")}, Body: models.WebhookSubscription{}, Response: models.WebhookSubscription{}},
+	{Method: "DELETE", Path: "/api/webhooks/{id}", Tag: "Webhooks", Summary: "Delete a webhook subscription and its deliveries", Params: []Parameter{pathID("id", "Subscription ID")}, Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/webhooks/{id}/deliveries", Tag: "Webhooks", Summary: "List a subscription's deliveries",
+		Params: []Parameter{pathID("id", "Subscription ID"), query("status", "string", "pending, delivered or failed", false),
+			query("before_id", "integer", "Deliveries older than this one; pass the last ID of a page for the next", false), query("limit", "integer", "1 to 500; defaults to 50", false)},
+		Response: []models.WebhookDelivery{}},
+	{Method: "GET", Path: "/api/webhooks/{id}/deliveries/{deliveryID}", Tag: "Webhooks", Summary: "Get a delivery with its attempt log",
+		Params: []Parameter{pathID("id", "Subscription ID"), pathID("deliveryID", "Delivery ID")}, Response: models.WebhookDelivery{}},
+	{Method: "POST", Path: "/api/webhooks/{id}/deliveries/{deliveryID}/redeliver", Tag: "Webhooks", Summary: "Queue a delivery again",
+		Params: []Parameter{pathID("id", "Subscription ID"), pathID("deliveryID", "Delivery ID")}, Status: http.StatusAccepted, Response: StatusResponse{}},
+
+	{Method: "POST", Path: "/api/chatbot", Tag: "Chatbot", Summary: "Send the chatbot a message", Body: ChatbotRequest{}, Response: ChatbotReply{}},
+}
+
+var (
+	buildOnce sync.Once
+	document  Document
+	encoded   []byte
+)
+
+// Spec returns the API document
+func Spec() Document {
+	buildOnce.Do(build)
+	return document
+}
+
+func build() {
+	s := &schemas{components: map[string]*Schema{}}
+	textError := map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}
+
+	document = Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "AOC Event Tracker API",
+			Version: "1.0",
+			Description: "Exercises, their divisions, teams, events and tasks. Errors are plain text, " +
+				"except invalid request fields, which get 422 with a JSON list of field errors.",
+		},
+		Paths: map[string]map[string]*Operation{},
+		Components: Components{
+			Schemas: s.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				"session": {Type: "apiKey", In: "cookie", Name: "session", Description: "Set by signing in"},
+				"bearer":  {Type: "http", Scheme: "bearer", Description: "A session token or an API key (aoc_...)"},
+			},
+		},
+		Security: []map[string][]string{{"session": {}}, {"bearer": {}}},
+	}
+
+	seenTags := map[string]bool{}
+	for _, rt := range routes {
+		if !seenTags[rt.Tag] {
+			seenTags[rt.Tag] = true
+			document.Tags = append(document.Tags, Tag{Name: rt.Tag})
+		}
+
+		op := &Operation{
+			Tags:        []string{rt.Tag},
+			Summary:     rt.Summary,
+			OperationID: operationID(rt),
+			Parameters:  rt.Params,
+			Responses:   map[string]*Response{},
+		}
+		if rt.Public {
+			op.Security = []map[string][]string{{}}
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &Response{Description: http.StatusText(status)}
+		if rt.Response != nil {
+			contentType := rt.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success.Content = map[string]MediaType{contentType: {Schema: s.of(rt.Response)}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		if rt.Patch != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/merge-patch+json": {Schema: s.patch(rt.Body, rt.Patch)}}}
+		} else if rt.Body != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: s.of(rt.Body)}}}
+			op.Responses["400"] = &Response{Description: "Malformed request", Content: textError}
+			op.Responses["422"] = &Response{Description: "Invalid fields",
+				Content: map[string]MediaType{"application/json": {Schema: s.of(ValidationErrors{})}}}
+		}
+		if !rt.Public {
+			op.Responses["401"] = &Response{Description: "Not signed in", Content: textError}
+			op.Responses["403"] = &Response{Description: "Not permitted", Content: textError}
+		}
+		if strings.Contains(rt.Path, "{") {
+			op.Responses["404"] = &Response{Description: "Not found", Content: textError}
+		}
+
+		if document.Paths[rt.Path] == nil {
+			document.Paths[rt.Path] = map[string]*Operation{}
+		}
+		document.Paths[rt.Path][strings.ToLower(rt.Method)] = op
+	}
+
+	encoded, _ = json.MarshalIndent(document, "", "  ")
+}
+
+// operationID derives a stable identifier such as putTasksIdAssign
+func operationID(rt route) string {
+	id := strings.ToLower(rt.Method)
+	for _, part := range strings.FieldsFunc(rt.Path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == '_'
+	}) {
+		if part == "api" {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// Operations lists every documented method and path, sorted, as "GET /path"
+func Operations() []string {
+	var ops []string
+	for path, methods := range Spec().Paths {
+		for method := range methods {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// Handler serves the document
+func Handler(w http.ResponseWriter, r *http.Request) {
+	buildOnce.Do(build)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>AOC Event Tracker API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="docs"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#docs", withCredentials: true });
+  </script>
+</body>
+</html>
+`
+
+// Docs serves an interactive viewer for the document. The viewer's scripts
+// are loaded from a CDN by the browser.
+func Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
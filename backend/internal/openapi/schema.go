@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas builds schemas from Go types. Named structs become components
+// and are referenced with $ref.
+type schemas struct {
+	components map[string]*Schema
+}
+
+// of returns the schema for the type of v
+func (s *schemas) of(v interface{}) *Schema {
+	return s.forType(reflect.TypeOf(v))
+}
+
+func (s *schemas) forType(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType || t.Kind() == reflect.Interface:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.forType(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.forType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.forType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Register before filling in so self-references terminate
+			s.components[t.Name()] = &Schema{}
+			*s.components[t.Name()] = *s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &Schema{}
+}
+
+// object describes a struct's JSON fields, applying the rules in their
+// validate tags and the text of their doc tags
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded := s.object(field.Type)
+			for name, prop := range embedded.Properties {
+				schema.Properties[name] = prop
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.forType(field.Type)
+		prop.Description = field.Tag.Get("doc")
+		target := prop
+		if prop.Type == "array" {
+			target = prop.Items
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			rule, arg, _ := strings.Cut(rule, "=")
+			switch rule {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "max":
+				n, _ := strconv.Atoi(arg)
+				target.MaxLength = &n
+			case "min":
+				n, _ := strconv.Atoi(arg)
+				target.Minimum = &n
+			case "oneof":
+				target.Enum = strings.Fields(arg)
+			case "notbefore":
+				if f, ok := t.FieldByName(arg); ok {
+					other, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+					prop.Description = strings.TrimSpace(prop.Description + " Must not be before " + other + ".")
+				}
+			case "exists":
+				prop.Description = strings.TrimSpace(prop.Description + " ID of an existing " + arg + ".")
+			}
+		}
+		if prop.Ref != "" && prop.Description != "" {
+			// $ref siblings are ignored in OpenAPI 3.0, so keep the reference bare
+			prop.Description = ""
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+// patch returns the schema of a JSON Merge Patch of v limited to fields: any
+// subset of them, with null clearing a field
+func (s *schemas) patch(v interface{}, fields []string) *Schema {
+	t := reflect.TypeOf(v)
+	full := s.object(t)
+	schema := &Schema{
+		Type:        "object",
+		Description: "JSON Merge Patch (RFC 7396) of " + t.Name() + ". Only the fields given change; null clears a field.",
+		Properties:  map[string]*Schema{},
+	}
+	for _, field := range fields {
+		if prop, ok := full.Properties[field]; ok {
+			schema.Properties[field] = prop
+		}
+	}
+	return schema
+}
@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/validation"
+	"time"
+)
+
+// Payloads that the handlers decode into or encode from anonymous structs
+// and maps. Keep these in step with the handlers.
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponse struct {
+	User      models.User `json:"user"`
+	Token     string      `json:"token" doc:"Session token, also set as the session cookie; send it as a bearer token from scripts"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+type AuthConfig struct {
+	Local bool `json:"local"`
+	OIDC  struct {
+		Enabled bool   `json:"enabled"`
+		Name    string `json:"name"`
+	} `json:"oidc"`
+}
+
+type OIDCLogin struct {
+	AuthorizationURL string `json:"authorization_url" doc:"Identity provider URL to send the browser to"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type CreateUserRequest struct {
+	models.User
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string     `json:"name" validate:"required,max=255"`
+	Type          string     `json:"type" validate:"oneof=personal service" doc:"Defaults to personal"`
+	Scopes        []string   `json:"scopes" validate:"required,oneof=read tasks:write admin"`
+	ExpiresAt     *time.Time `json:"expires_at" doc:"At most 365 days away; defaults to 90 days"`
+	ExpiresInDays int        `json:"expires_in_days" doc:"Alternative to expires_at"`
+	UserID        int        `json:"user_id" doc:"Existing service account for a service key; one is created from the name when omitted"`
+}
+
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key" doc:"The key itself; only returned here"`
+}
+
+type GrantPermissions struct {
+	models.RoleGrant
+	Permissions []string `json:"permissions"`
+}
+
+type DivisionOrderRequest struct {
+	DivisionIDs []int `json:"division_ids" validate:"required" doc:"Every division of the exercise, in the new order"`
+}
+
+type TeamOrderRequest struct {
+	TeamIDs []int `json:"team_ids" validate:"required" doc:"Every team of the division, in the new order"`
+}
+
+type TeamMoveRequest struct {
+	DivisionID int `json:"division_id" validate:"required,exists=division" doc:"Division of the same exercise to move the team to."`
+	Position   int `json:"position" doc:"Place among the division's teams, counting from 1; 0 or omitted puts the team last"`
+}
+
+type TeamStatusRequest struct {
+	TeamIDs     []int   `json:"team_ids" doc:"Teams to update; give exactly one of team_ids, division_id and exercise_id"`
+	DivisionID  int     `json:"division_id" doc:"Update every team of this division"`
+	ExerciseID  int     `json:"exercise_id" doc:"Update every team of this exercise"`
+	Status      string  `json:"status" validate:"required,oneof=green yellow red"`
+	StatusStart *string `json:"status_start" doc:"Date (YYYY-MM-DD) or RFC 3339 timestamp; left out keeps each team's, empty clears it"`
+	StatusEnd   *string `json:"status_end" doc:"Date (YYYY-MM-DD) or RFC 3339 timestamp; left out keeps each team's, empty clears it"`
+	Comments    *string `json:"comments" validate:"max=10000" doc:"Left out keeps each team's comments"`
+}
+
+type TaskAssignRequest struct {
+	TeamID *int `json:"team_id" doc:"Team to assign, or null to unassign"`
+}
+
+type TaskAssignResponse struct {
+	Message   string    `json:"message"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaskAssignMultipleRequest struct {
+	TeamIDs []int `json:"team_ids" doc:"Replaces the task's current teams"`
+}
+
+type TaskAssignMultipleResponse struct {
+	Message   string        `json:"message"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Teams     []models.Team `json:"teams"`
+}
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadyStatus struct {
+	Status string            `json:"status" doc:"\"ready\" or \"not ready\""`
+	Checks map[string]string `json:"checks" doc:"Result of each check, \"ok\" or the error"`
+}
+
+type ChatbotRequest struct {
+	Message string `json:"message" validate:"required"`
+}
+
+type ChatbotReply struct {
+	Reply string `json:"reply"`
+}
+
+type ValidationErrors struct {
+	Error   string                  `json:"error"`
+	Message string                  `json:"message"`
+	Fields  []validation.FieldError `json:"fields"`
+}
+
+type EscalationAckRequest struct {
+	Note string `json:"note" validate:"max=10000" doc:"Optional"`
+}
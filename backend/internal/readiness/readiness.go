@@ -0,0 +1,199 @@
+// Package readiness rolls team statuses up into division and exercise
+// readiness.
+//
+// A team's status counts only inside its status window: from status_start
+// to the end of the day of status_end, with an unset bound left open.
+// Outside the window the team counts as green. Teams with readiness weight 0
+// are left out. The configured rule then decides the rollup:
+//
+//	worst_of     red if any counted team is red, else yellow if any is
+//	             yellow, else green
+//	percentage   red if the red share of the weight reaches red_percent,
+//	             else green if the green share reaches green_percent,
+//	             else yellow
+//
+// Every rollup also reports the counts and weighted shares behind it.
+package readiness
+
+import (
+	"math"
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/models"
+	"time"
+)
+
+// Unknown is the rollup status when no team counts
+const Unknown = "unknown"
+
+var rules = config.Default().Readiness
+
+// Configure sets the rollup rule. Call it once at startup.
+func Configure(cfg config.ReadinessConfig) {
+	rules = cfg
+}
+
+// Day returns the UTC day containing t, as midnight
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// TeamStatus returns the status a team has on day: its status inside its
+// window, green outside it
+func TeamStatus(team models.Team, day time.Time) string {
+	day = Day(day)
+	if !team.StatusStart.IsZero() && !team.StatusStart.Before(day.AddDate(0, 0, 1)) {
+		return "green"
+	}
+	if !team.StatusEnd.IsZero() && team.StatusEnd.Before(day) {
+		return "green"
+	}
+	if team.Status == "" {
+		return "green"
+	}
+	return team.Status
+}
+
+// WindowEnd returns when a status window ending at end closes: a date-only
+// end lasts through its day
+func WindowEnd(end time.Time) time.Time {
+	if end.Equal(Day(end)) {
+		return end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// StatusSince returns when the status a team has on day (see TeamStatus)
+// took effect: when it was reported or its window opened, whichever is later,
+// or when its window closed once it has lapsed back to green
+func StatusSince(team models.Team, day time.Time) time.Time {
+	day = Day(day)
+	since := team.StatusChangedAt
+	if !team.StatusStart.IsZero() && !team.StatusStart.Before(day.AddDate(0, 0, 1)) {
+		return since
+	}
+	if !team.StatusEnd.IsZero() && team.StatusEnd.Before(day) {
+		if end := WindowEnd(team.StatusEnd); end.After(since) {
+			return end
+		}
+		return since
+	}
+	if team.StatusStart.After(since) {
+		return team.StatusStart
+	}
+	return since
+}
+
+// Teams rolls up the statuses of teams on day
+func Teams(teams []models.Team, day time.Time) models.Readiness {
+	day = Day(day)
+	result := models.Readiness{Date: day.Format("2006-01-02"), Rule: rules.Rule}
+
+	weights := map[string]int{}
+	total := 0
+	for _, team := range teams {
+		if team.ReadinessWeight <= 0 {
+			continue
+		}
+		status := TeamStatus(team, day)
+		switch status {
+		case "red":
+			result.Red++
+		case "yellow":
+			result.Yellow++
+		default:
+			status = "green"
+			result.Green++
+		}
+		weights[status] += team.ReadinessWeight
+		total += team.ReadinessWeight
+	}
+	result.Teams = result.Green + result.Yellow + result.Red
+	if total == 0 {
+		result.Status = Unknown
+		return result
+	}
+
+	share := func(status string) float64 {
+		return math.Round(float64(weights[status])*1000/float64(total)) / 10
+	}
+	result.GreenPercent = share("green")
+	result.YellowPercent = share("yellow")
+	result.RedPercent = share("red")
+
+	switch rules.Rule {
+	case "percentage":
+		exact := func(status string) float64 { return float64(weights[status]) * 100 / float64(total) }
+		switch {
+		case exact("red") >= rules.RedPercent:
+			result.Status = "red"
+		case exact("green") >= rules.GreenPercent:
+			result.Status = "green"
+		default:
+			result.Status = "yellow"
+		}
+	default:
+		switch {
+		case result.Red > 0:
+			result.Status = "red"
+		case result.Yellow > 0:
+			result.Status = "yellow"
+		default:
+			result.Status = "green"
+		}
+	}
+	return result
+}
+
+// Division rolls up a division's teams on day, listing each team
+func Division(division models.Division, day time.Time) models.DivisionReadiness {
+	result := models.DivisionReadiness{
+		DivisionID: division.ID,
+		Name:       division.Name,
+		Readiness:  Teams(division.Teams, day),
+		Teams:      []models.TeamReadiness{},
+	}
+	for _, team := range division.Teams {
+		result.Teams = append(result.Teams, models.TeamReadiness{
+			TeamID:         team.ID,
+			Name:           team.Name,
+			Status:         TeamStatus(team, day),
+			ReportedStatus: team.Status,
+			StatusStart:    team.StatusStart,
+			StatusEnd:      team.StatusEnd,
+			Weight:         team.ReadinessWeight,
+		})
+	}
+	return result
+}
+
+// Exercise rolls up an exercise's divisions on day. The exercise rollup is
+// taken over every team, not over the division rollups, so weights carry
+// across divisions.
+func Exercise(exercise models.Exercise, day time.Time) models.ExerciseReadiness {
+	result := models.ExerciseReadiness{
+		ExerciseID: exercise.ID,
+		Name:       exercise.Name,
+		Divisions:  []models.DivisionReadiness{},
+	}
+	var teams []models.Team
+	for _, division := range exercise.Divisions {
+		result.Divisions = append(result.Divisions, Division(division, day))
+		teams = append(teams, division.Teams...)
+	}
+	result.Readiness = Teams(teams, day)
+	return result
+}
+
+// Annotate sets the readiness on day of an exercise and its divisions
+func Annotate(exercise *models.Exercise, day time.Time) {
+	var teams []models.Team
+	for i := range exercise.Divisions {
+		division := &exercise.Divisions[i]
+		rollup := Teams(division.Teams, day)
+		division.Readiness = &rollup
+		teams = append(teams, division.Teams...)
+	}
+	rollup := Teams(teams, day)
+	exercise.Readiness = &rollup
+}
@@ -0,0 +1,155 @@
+package readiness
+
+import (
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func date(d int, hour int) time.Time {
+	return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestTeamStatus(t *testing.T) {
+	day := date(10, 15)
+	tests := []struct {
+		name       string
+		status     string
+		start, end time.Time
+		want       string
+	}{
+		{"no window", "red", time.Time{}, time.Time{}, "red"},
+		{"no status", "", time.Time{}, time.Time{}, "green"},
+		{"inside the window", "red", date(9, 0), date(11, 0), "red"},
+		{"starts later the same day", "red", date(10, 20), time.Time{}, "red"},
+		{"not started", "red", date(11, 0), time.Time{}, "green"},
+		{"date-only end lasts through its day", "yellow", time.Time{}, date(10, 0), "yellow"},
+		{"ended earlier the same day", "yellow", time.Time{}, date(10, 6), "yellow"},
+		{"ended", "red", date(1, 0), date(9, 0), "green"},
+		{"ended late the day before", "red", time.Time{}, date(9, 23), "green"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := models.Team{Status: tt.status, StatusStart: tt.start, StatusEnd: tt.end}
+			if got := TeamStatus(team, day); got != tt.want {
+				t.Errorf("TeamStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusSince(t *testing.T) {
+	day := date(10, 15)
+	changed := date(7, 9)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       time.Time
+	}{
+		{"no window", time.Time{}, time.Time{}, changed},
+		{"window opened before the report", date(5, 0), time.Time{}, changed},
+		{"window opened after the report", date(9, 0), date(12, 0), date(9, 0)},
+		{"window opens later today", date(10, 20), time.Time{}, date(10, 20)},
+		{"window not started: green since the report", date(12, 0), time.Time{}, changed},
+		{"date-only end lapsed at the end of its day", time.Time{}, date(8, 0), date(9, 0)},
+		{"timestamped end lapsed when it passed", date(7, 0), date(8, 18), date(8, 18)},
+		{"window ended before the report", time.Time{}, date(6, 0), changed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := models.Team{Status: "red", StatusStart: tt.start, StatusEnd: tt.end, StatusChangedAt: changed}
+			if got := StatusSince(team, day); !got.Equal(tt.want) {
+				t.Errorf("StatusSince() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeams(t *testing.T) {
+	day := date(10, 0)
+	team := func(status string, weight int) models.Team {
+		return models.Team{Status: status, ReadinessWeight: weight}
+	}
+	worstOf := config.ReadinessConfig{Rule: "worst_of"}
+	percentage := config.ReadinessConfig{Rule: "percentage", GreenPercent: 80, RedPercent: 30}
+
+	tests := []struct {
+		name             string
+		rules            config.ReadinessConfig
+		teams            []models.Team
+		status           string
+		green, yellow    int
+		red              int
+		greenPct, redPct float64
+	}{
+		{"worst_of: one red", worstOf,
+			[]models.Team{team("green", 5), team("green", 4), team("red", 1)},
+			"red", 2, 0, 1, 90, 10},
+		{"worst_of: yellow over green", worstOf,
+			[]models.Team{team("green", 1), team("yellow", 1)},
+			"yellow", 1, 1, 0, 50, 0},
+		{"worst_of: weight 0 is left out", worstOf,
+			[]models.Team{team("green", 1), team("red", 0)},
+			"green", 1, 0, 0, 100, 0},
+		{"worst_of: window not started counts green", worstOf,
+			[]models.Team{team("green", 1), {Status: "red", ReadinessWeight: 1, StatusStart: date(11, 0)}},
+			"green", 2, 0, 0, 100, 0},
+		{"worst_of: window ended counts green", worstOf,
+			[]models.Team{{Status: "red", ReadinessWeight: 1, StatusEnd: date(9, 0)}},
+			"green", 1, 0, 0, 100, 0},
+		{"percentage: small red share", percentage,
+			[]models.Team{team("green", 8), team("yellow", 1), team("red", 1)},
+			"green", 1, 1, 1, 80, 10},
+		{"percentage: red share reaches red_percent", percentage,
+			[]models.Team{team("green", 7), team("red", 3)},
+			"red", 1, 0, 1, 70, 30},
+		{"percentage: neither threshold", percentage,
+			[]models.Team{team("green", 7), team("yellow", 2), team("red", 1)},
+			"yellow", 1, 1, 1, 70, 10},
+		{"percentage: weights, not counts", percentage,
+			[]models.Team{team("green", 1), team("red", 1), team("red", 1), team("green", 9)},
+			"green", 2, 0, 2, 83.3, 16.7},
+		{"percentage: weight 0 red is left out", percentage,
+			[]models.Team{team("green", 1), team("red", 0)},
+			"green", 1, 0, 0, 100, 0},
+		{"no counted teams", percentage,
+			[]models.Team{team("red", 0)},
+			Unknown, 0, 0, 0, 0, 0},
+		{"no teams", worstOf, nil, Unknown, 0, 0, 0, 0, 0},
+	}
+	defer Configure(rules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(tt.rules)
+			got := Teams(tt.teams, day)
+			if got.Status != tt.status || got.Rule != tt.rules.Rule || got.Date != "2026-03-10" {
+				t.Errorf("Teams() = %s by %s on %s, want %s by %s on 2026-03-10",
+					got.Status, got.Rule, got.Date, tt.status, tt.rules.Rule)
+			}
+			if got.Green != tt.green || got.Yellow != tt.yellow || got.Red != tt.red || got.Teams != tt.green+tt.yellow+tt.red {
+				t.Errorf("Teams() counts %d/%d/%d of %d, want %d/%d/%d",
+					got.Green, got.Yellow, got.Red, got.Teams, tt.green, tt.yellow, tt.red)
+			}
+			if got.GreenPercent != tt.greenPct || got.RedPercent != tt.redPct {
+				t.Errorf("Teams() shares green %v%% red %v%%, want %v%% and %v%%",
+					got.GreenPercent, got.RedPercent, tt.greenPct, tt.redPct)
+			}
+		})
+	}
+}
+
+func TestExerciseWeighsAcrossDivisions(t *testing.T) {
+	defer Configure(rules)
+	Configure(config.ReadinessConfig{Rule: "percentage", GreenPercent: 80, RedPercent: 50})
+
+	exercise := models.Exercise{Divisions: []models.Division{
+		{Name: "Cyber", Teams: []models.Team{{Status: "red", ReadinessWeight: 1}}},
+		{Name: "Space", Teams: []models.Team{{Status: "green", ReadinessWeight: 9}}},
+	}}
+	got := Exercise(exercise, date(10, 0))
+	if got.Readiness.Status != "green" || got.Divisions[0].Readiness.Status != "red" || got.Divisions[1].Readiness.Status != "green" {
+		t.Errorf("Exercise() = %s with divisions %s and %s, want green with red and green",
+			got.Readiness.Status, got.Divisions[0].Readiness.Status, got.Divisions[1].Readiness.Status)
+	}
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetEscalationPolicyDB returns an exercise's escalation steps in order
+func (r *PostgresRepository) GetEscalationPolicyDB(ctx context.Context, exerciseID int) (models.EscalationPolicy, bool) {
+	policy := models.EscalationPolicy{ExerciseID: exerciseID, Steps: []models.EscalationStep{}}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, after_minutes, notify
+		FROM escalation_steps
+		WHERE exercise_id = $1
+		ORDER BY position, id`, exerciseID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching escalation policy", "exercise_id", exerciseID, "error", err)
+		return policy, false
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var step models.EscalationStep
+		if err := rows.Scan(&step.Status, &step.AfterMinutes, &step.Notify); err != nil {
+			slog.ErrorContext(ctx, "Error scanning escalation step", "error", err)
+			return policy, false
+		}
+		policy.Steps = append(policy.Steps, step)
+	}
+	return policy, rows.Err() == nil
+}
+
+// SetEscalationPolicyDB replaces an exercise's escalation steps. Escalations
+// already triggered are kept, and a step that is kept does not trigger again
+// for the same status.
+func (r *PostgresRepository) SetEscalationPolicyDB(ctx context.Context, policy models.EscalationPolicy) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM escalation_steps WHERE exercise_id = $1", policy.ExerciseID); err != nil {
+		slog.ErrorContext(ctx, "Error clearing escalation policy", "exercise_id", policy.ExerciseID, "error", err)
+		return false
+	}
+	for i, step := range policy.Steps {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO escalation_steps (exercise_id, status, after_minutes, notify, position)
+			VALUES ($1, $2, $3, $4, $5)`, policy.ExerciseID, step.Status, step.AfterMinutes, step.Notify, i)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error saving escalation step", "exercise_id", policy.ExerciseID, "error", err)
+			return false
+		}
+	}
+	changes.Emit(ctx, tx, changes.EscalationPolicyUpdated, policy.ExerciseID, policy)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// RunEscalationsDB triggers every escalation step that is due: the team, in a
+// running exercise, has held the step's status for at least the step's time,
+// the step has not already triggered for this status, and no escalation of
+// this status has been acknowledged. The time is counted from when the
+// team's status window opened if that is later, and a team whose window has
+// ended is not escalated. Each escalation is recorded and emitted
+// as a change, which is how its recipients are notified. It returns how many
+// escalations were triggered.
+func (r *PostgresRepository) RunEscalationsDB(ctx context.Context) (int, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return 0, false
+	}
+	defer tx.Rollback()
+
+	type due struct {
+		escalation models.Escalation
+		teamPOC    string
+		divPOC     string
+		exPOCs     []string
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT s.status, s.after_minutes, s.notify,
+		       t.id, t.exercise_id, t.name, COALESCE(t.poc, ''), t.since,
+		       d.id, d.name, COALESCE(d.poc, ''),
+		       COALESCE(e.exercise_event_poc, ''), COALESCE(e.srd_poc, ''), COALESCE(e.cpd_poc, '')
+		FROM escalation_steps s
+		JOIN exercises e ON e.id = s.exercise_id
+		JOIN (
+			SELECT *, GREATEST(COALESCE(status_changed_at, created_at), status_start) AS since
+			FROM teams
+			WHERE NOT (`+expiredWindow+`)
+		) t ON t.exercise_id = s.exercise_id AND COALESCE(t.status, 'green') = s.status
+		JOIN divisions d ON d.id = t.division_id
+		WHERE e.start_date <= CURRENT_TIMESTAMP AND e.end_date + INTERVAL '1 day' > CURRENT_TIMESTAMP
+		  AND t.since <= CURRENT_TIMESTAMP - make_interval(mins => s.after_minutes)
+		  AND NOT EXISTS (
+			SELECT 1 FROM escalations x
+			WHERE x.team_id = t.id AND x.status_since = t.since
+			  AND (x.acknowledged_at IS NOT NULL
+			       OR (x.status = s.status AND x.after_minutes = s.after_minutes AND x.notify = s.notify)))
+		ORDER BY t.id, s.after_minutes, s.position`)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding due escalations", "error", err)
+		return 0, false
+	}
+	var pending []due
+	for rows.Next() {
+		var d due
+		e := &d.escalation
+		var eventPOC, srdPOC, cpdPOC string
+		err := rows.Scan(&e.Status, &e.AfterMinutes, &e.Notify,
+			&e.TeamID, &e.ExerciseID, &e.TeamName, &d.teamPOC, &e.StatusSince,
+			&e.DivisionID, &e.DivisionName, &d.divPOC,
+			&eventPOC, &srdPOC, &cpdPOC)
+		if err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "Error scanning due escalation", "error", err)
+			return 0, false
+		}
+		d.exPOCs = []string{eventPOC, srdPOC, cpdPOC}
+		pending = append(pending, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error finding due escalations", "error", err)
+		return 0, false
+	}
+
+	triggered := 0
+	for _, d := range pending {
+		e := d.escalation
+		switch e.Notify {
+		case "team_poc":
+			e.Recipients = recipients(d.teamPOC)
+		case "division_poc":
+			e.Recipients = recipients(d.divPOC)
+		default:
+			e.Recipients = recipients(d.exPOCs...)
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO escalations (exercise_id, team_id, status, status_since, after_minutes, notify, recipients)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT DO NOTHING
+			RETURNING id, triggered_at`,
+			e.ExerciseID, e.TeamID, e.Status, e.StatusSince, e.AfterMinutes, e.Notify, pq.Array(e.Recipients)).
+			Scan(&e.ID, &e.TriggeredAt)
+		if err == sql.ErrNoRows {
+			continue // Triggered by another instance
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Error recording escalation", "team_id", e.TeamID, "error", err)
+			return 0, false
+		}
+		if len(e.Recipients) == 0 {
+			slog.WarnContext(ctx, "Escalation has nobody to notify", "team_id", e.TeamID, "notify", e.Notify)
+		}
+		changes.Emit(ctx, tx, changes.TeamEscalated, e.ExerciseID, e)
+		triggered++
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return 0, false
+	}
+	return triggered, true
+}
+
+// recipients returns the POCs that are set, without repeats
+func recipients(pocs ...string) []string {
+	list := []string{}
+	for _, poc := range pocs {
+		if poc != "" && !hasField(list, poc) {
+			list = append(list, poc)
+		}
+	}
+	return list
+}
+
+// escalationQuery selects escalations with their team and division names
+const escalationQuery = `x.id, x.exercise_id, x.team_id, t.name, t.division_id, d.name, x.status, x.status_since,
+	x.after_minutes, x.notify, x.recipients, x.triggered_at, x.acknowledged_at,
+	COALESCE(x.acknowledged_by, ''), COALESCE(x.note, '')
+	FROM escalations x
+	JOIN teams t ON t.id = x.team_id
+	JOIN divisions d ON d.id = t.division_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEscalation(row rowScanner) (models.Escalation, error) {
+	var e models.Escalation
+	var acknowledgedAt sql.NullTime
+	err := row.Scan(&e.ID, &e.ExerciseID, &e.TeamID, &e.TeamName, &e.DivisionID, &e.DivisionName, &e.Status, &e.StatusSince,
+		&e.AfterMinutes, &e.Notify, pq.Array(&e.Recipients), &e.TriggeredAt, &acknowledgedAt,
+		&e.AcknowledgedBy, &e.Note)
+	if acknowledgedAt.Valid {
+		e.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return e, err
+}
+
+// GetEscalationsDB returns an exercise's escalation history, newest first.
+// openOnly leaves out acknowledged escalations.
+func (r *PostgresRepository) GetEscalationsDB(ctx context.Context, exerciseID int, openOnly bool) ([]models.Escalation, bool) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+escalationQuery+`
+		WHERE x.exercise_id = $1 AND (NOT $2 OR x.acknowledged_at IS NULL)
+		ORDER BY x.triggered_at DESC, x.id DESC`, exerciseID, openOnly)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching escalations", "exercise_id", exerciseID, "error", err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	escalations := []models.Escalation{}
+	for rows.Next() {
+		e, err := scanEscalation(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning escalation", "error", err)
+			return nil, false
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations, rows.Err() == nil
+}
+
+// GetEscalationByIDDB returns one escalation
+func (r *PostgresRepository) GetEscalationByIDDB(ctx context.Context, id int) (models.Escalation, bool) {
+	return getEscalation(ctx, r.db, id)
+}
+
+func getEscalation(ctx context.Context, q rowQuerier, id int) (models.Escalation, bool) {
+	e, err := scanEscalation(q.QueryRowContext(ctx, "SELECT "+escalationQuery+" WHERE x.id = $1", id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching escalation", "escalation_id", id, "error", err)
+		}
+		return e, false
+	}
+	return e, true
+}
+
+// AcknowledgeEscalationDB acknowledges an escalation and every other open
+// escalation of the same team and status, which stops further steps until
+// the team's status changes. It returns the acknowledged escalation.
+func (r *PostgresRepository) AcknowledgeEscalationDB(ctx context.Context, id int, by, note string) (models.Escalation, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return models.Escalation{}, false
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE escalations x
+		SET acknowledged_at = CURRENT_TIMESTAMP, acknowledged_by = $2, note = $3
+		FROM escalations a
+		WHERE a.id = $1 AND x.team_id = a.team_id AND x.status_since = a.status_since
+		  AND x.acknowledged_at IS NULL`, id, by, note)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error acknowledging escalation", "escalation_id", id, "error", err)
+		return models.Escalation{}, false
+	}
+
+	escalation, found := getEscalation(ctx, tx, id)
+	if !found {
+		return escalation, false
+	}
+	changes.Emit(ctx, tx, changes.EscalationAcknowledged, escalation.ExerciseID, escalation)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return models.Escalation{}, false
+	}
+	return escalation, true
+}
@@ -1,177 +1,505 @@
 package repository
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"srd-calendar-project/backend/internal/metrics"
 	"srd-calendar-project/backend/internal/models"
+	"time"
 )
 
 // Global repository instance
 var repo *PostgresRepository
 
-// Initialize sets up the repository with PostgreSQL
-func Initialize() {
+// Initialize sets up the repository with PostgreSQL, loading the sample
+// exercises into an empty database when seed is set
+func Initialize(ctx context.Context, seed bool) {
 	repo = NewPostgresRepository()
-	repo.InitializeDatabase()
+	if seed {
+		repo.InitializeDatabase(ctx)
+	}
 }
 
 // GetAllExercises returns all exercises from the database
-func GetAllExercises() []models.Exercise {
+func GetAllExercises(ctx context.Context) []models.Exercise {
+	defer metrics.ObserveQuery("GetAllExercises", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized, returning empty list")
+		slog.WarnContext(ctx, "Repository not initialized, returning empty list")
 		return []models.Exercise{}
 	}
-	return repo.GetAllExercisesDB()
+	return repo.GetAllExercisesDB(ctx)
 }
 
 // GetExerciseByID returns a single exercise by its ID
-func GetExerciseByID(id int) (models.Exercise, bool) {
+func GetExerciseByID(ctx context.Context, id int) (models.Exercise, bool) {
+	defer metrics.ObserveQuery("GetExerciseByID", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return models.Exercise{}, false
 	}
-	return repo.GetExerciseByIDDB(id)
+	return repo.GetExerciseByIDDB(ctx, id)
 }
 
 // CreateExercise adds a new exercise and returns it with a new ID
-func CreateExercise(exercise models.Exercise) models.Exercise {
+func CreateExercise(ctx context.Context, exercise models.Exercise) models.Exercise {
+	defer metrics.ObserveQuery("CreateExercise", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return exercise
 	}
-	return repo.CreateExerciseDB(exercise)
+	return repo.CreateExerciseDB(ctx, exercise)
 }
 
 // UpdateExercise updates an existing exercise
-func UpdateExercise(exercise models.Exercise) bool {
+func UpdateExercise(ctx context.Context, exercise models.Exercise) bool {
+	defer metrics.ObserveQuery("UpdateExercise", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.UpdateExerciseDB(exercise)
+	return repo.UpdateExerciseDB(ctx, exercise)
 }
 
 // DeleteExercise removes an exercise by its ID
-func DeleteExercise(id int) bool {
+func DeleteExercise(ctx context.Context, id int) bool {
+	defer metrics.ObserveQuery("DeleteExercise", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.DeleteExerciseDB(id)
+	return repo.DeleteExerciseDB(ctx, id)
 }
 
 // CreateDivision creates a new division for an exercise
-func CreateDivision(division models.Division) models.Division {
+func CreateDivision(ctx context.Context, division models.Division) models.Division {
+	defer metrics.ObserveQuery("CreateDivision", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return division
 	}
-	return repo.CreateDivisionDB(division)
+	return repo.CreateDivisionDB(ctx, division)
 }
 
 // UpdateDivision updates a division's information including learning objectives
-func UpdateDivision(division models.Division) bool {
+func UpdateDivision(ctx context.Context, division models.Division) bool {
+	defer metrics.ObserveQuery("UpdateDivision", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.UpdateDivisionDB(division)
+	return repo.UpdateDivisionDB(ctx, division)
 }
 
 // CreateTeam creates a new team within a division
-func CreateTeam(team models.Team) models.Team {
+func CreateTeam(ctx context.Context, team models.Team) models.Team {
+	defer metrics.ObserveQuery("CreateTeam", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return team
 	}
-	return repo.CreateTeamDB(team)
+	return repo.CreateTeamDB(ctx, team)
 }
 
 // GetEventsForExercise returns all events for a specific exercise
-func GetEventsForExercise(exerciseID int) []models.Event {
+func GetEventsForExercise(ctx context.Context, exerciseID int) []models.Event {
+	defer metrics.ObserveQuery("GetEventsForExercise", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return []models.Event{}
 	}
-	return repo.GetEventsForExercise(exerciseID)
+	return repo.GetEventsForExercise(ctx, exerciseID)
 }
 
 // CreateEvent creates a new event for an exercise
-func CreateEvent(event models.Event) models.Event {
+func CreateEvent(ctx context.Context, event models.Event) models.Event {
+	defer metrics.ObserveQuery("CreateEvent", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return event
 	}
-	return repo.CreateEventDB(event)
+	return repo.CreateEventDB(ctx, event)
 }
 
 // UpdateEvent updates an existing event
-func UpdateEvent(event models.Event) bool {
+func UpdateEvent(ctx context.Context, event models.Event) bool {
+	defer metrics.ObserveQuery("UpdateEvent", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.UpdateEventDB(event)
+	return repo.UpdateEventDB(ctx, event)
 }
 
 // DeleteEvent removes an event by its ID
-func DeleteEvent(id int) bool {
+func DeleteEvent(ctx context.Context, id int) bool {
+	defer metrics.ObserveQuery("DeleteEvent", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.DeleteEventDB(id)
+	return repo.DeleteEventDB(ctx, id)
 }
 
 // GetExercisesByDivisionID returns exercises that contain the specified division
-func GetExercisesByDivisionID(divisionID int) []models.Exercise {
+func GetExercisesByDivisionID(ctx context.Context, divisionID int) []models.Exercise {
+	defer metrics.ObserveQuery("GetExercisesByDivisionID", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return []models.Exercise{}
 	}
-	return repo.GetExercisesByDivisionIDDB(divisionID)
+	return repo.GetExercisesByDivisionIDDB(ctx, divisionID)
 }
 
 // GetExercisesByTeamID returns exercises that contain the specified team
-func GetExercisesByTeamID(teamID int) []models.Exercise {
+func GetExercisesByTeamID(ctx context.Context, teamID int) []models.Exercise {
+	defer metrics.ObserveQuery("GetExercisesByTeamID", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return []models.Exercise{}
 	}
-	return repo.GetExercisesByTeamIDDB(teamID)
+	return repo.GetExercisesByTeamIDDB(ctx, teamID)
 }
 
 // DeleteDivision removes a division and all its teams by ID
-func DeleteDivision(id int) bool {
+func DeleteDivision(ctx context.Context, id int) bool {
+	defer metrics.ObserveQuery("DeleteDivision", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.DeleteDivisionDB(id)
+	return repo.DeleteDivisionDB(ctx, id)
 }
 
 // DeleteTeam removes a team by ID
-func DeleteTeam(id int) bool {
+func DeleteTeam(ctx context.Context, id int) bool {
+	defer metrics.ObserveQuery("DeleteTeam", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized")
+		slog.WarnContext(ctx, "Repository not initialized")
 		return false
 	}
-	return repo.DeleteTeamDB(id)
+	return repo.DeleteTeamDB(ctx, id)
 }
 
 // GetExercisesByDivisionName returns exercises that contain a division with the specified name
-func GetExercisesByDivisionName(divisionName string) []models.Exercise {
+func GetExercisesByDivisionName(ctx context.Context, divisionName string) []models.Exercise {
+	defer metrics.ObserveQuery("GetExercisesByDivisionName", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized, returning empty list")
+		slog.WarnContext(ctx, "Repository not initialized, returning empty list")
 		return []models.Exercise{}
 	}
-	return repo.GetExercisesByDivisionNameDB(divisionName)
+	return repo.GetExercisesByDivisionNameDB(ctx, divisionName)
 }
 
 // GetExercisesByTeamName returns exercises that contain a team with the specified name
-func GetExercisesByTeamName(teamName string) []models.Exercise {
+func GetExercisesByTeamName(ctx context.Context, teamName string) []models.Exercise {
+	defer metrics.ObserveQuery("GetExercisesByTeamName", time.Now())
 	if repo == nil {
-		log.Println("Repository not initialized, returning empty list")
+		slog.WarnContext(ctx, "Repository not initialized, returning empty list")
 		return []models.Exercise{}
 	}
-	return repo.GetExercisesByTeamNameDB(teamName)
-}
\ No newline at end of file
+	return repo.GetExercisesByTeamNameDB(ctx, teamName)
+}
+// PatchExercise writes only the named fields of an exercise
+func PatchExercise(ctx context.Context, exercise models.Exercise, fields []string) bool {
+	defer metrics.ObserveQuery("PatchExercise", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.PatchExerciseDB(ctx, exercise, fields)
+}
+
+// GetDivisionByID returns a single division with its teams
+func GetDivisionByID(ctx context.Context, id int) (models.Division, bool) {
+	defer metrics.ObserveQuery("GetDivisionByID", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Division{}, false
+	}
+	return repo.GetDivisionByIDDB(ctx, id)
+}
+
+// PatchDivision writes only the named fields of a division
+func PatchDivision(ctx context.Context, division models.Division, fields []string) bool {
+	defer metrics.ObserveQuery("PatchDivision", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.PatchDivisionDB(ctx, division, fields)
+}
+
+// GetTeamByID returns a single team
+func GetTeamByID(ctx context.Context, id int) (models.Team, bool) {
+	defer metrics.ObserveQuery("GetTeamByID", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Team{}, false
+	}
+	return repo.GetTeamByIDDB(ctx, id)
+}
+
+// GetTeamsForDivision returns the teams of a division
+func GetTeamsForDivision(ctx context.Context, exerciseID, divisionID int) []models.Team {
+	defer metrics.ObserveQuery("GetTeamsForDivision", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return []models.Team{}
+	}
+	return repo.GetTeamsForDivision(ctx, exerciseID, divisionID)
+}
+
+// UpdateTeam writes every editable field of a team
+func UpdateTeam(ctx context.Context, team models.Team) bool {
+	defer metrics.ObserveQuery("UpdateTeam", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.UpdateTeamDB(ctx, team)
+}
+
+// PatchTeam writes only the named fields of a team
+func PatchTeam(ctx context.Context, team models.Team, fields []string) bool {
+	defer metrics.ObserveQuery("PatchTeam", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.PatchTeamDB(ctx, team, fields)
+}
+
+// GetEventByID returns a single event
+func GetEventByID(ctx context.Context, id int) (models.Event, bool) {
+	defer metrics.ObserveQuery("GetEventByID", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Event{}, false
+	}
+	return repo.GetEventByIDDB(ctx, id)
+}
+
+// PatchEvent writes only the named fields of an event
+func PatchEvent(ctx context.Context, event models.Event, fields []string) bool {
+	defer metrics.ObserveQuery("PatchEvent", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.PatchEventDB(ctx, event, fields)
+}
+
+// GetTaskByID returns a single task with its teams
+func GetTaskByID(ctx context.Context, id int) (models.Task, bool) {
+	defer metrics.ObserveQuery("GetTaskByID", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Task{}, false
+	}
+	return repo.GetTaskByIDDB(ctx, id)
+}
+
+// PatchTask writes only the named fields of a task
+func PatchTask(ctx context.Context, task models.Task, fields []string) bool {
+	defer metrics.ObserveQuery("PatchTask", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.PatchTaskDB(ctx, task, fields)
+}
+
+// ReorderDivisions sets the order of an exercise's divisions
+func ReorderDivisions(ctx context.Context, exerciseID int, ids []int) bool {
+	defer metrics.ObserveQuery("ReorderDivisions", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.ReorderDivisionsDB(ctx, exerciseID, ids)
+}
+
+// ReorderTeams sets the order of a division's teams
+func ReorderTeams(ctx context.Context, exerciseID, divisionID int, ids []int) bool {
+	defer metrics.ObserveQuery("ReorderTeams", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.ReorderTeamsDB(ctx, exerciseID, divisionID, ids)
+}
+
+// MoveTeam moves a team to another division of its exercise
+func MoveTeam(ctx context.Context, teamID, divisionID, position int) bool {
+	defer metrics.ObserveQuery("MoveTeam", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.MoveTeamDB(ctx, teamID, divisionID, position)
+}
+
+// GetTeamsByIDs returns the teams with the given IDs that exist
+func GetTeamsByIDs(ctx context.Context, ids []int) ([]models.Team, bool) {
+	defer metrics.ObserveQuery("GetTeamsByIDs", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.GetTeamsByIDsDB(ctx, ids)
+}
+
+// GetTeamsForExercise returns every team of an exercise
+func GetTeamsForExercise(ctx context.Context, exerciseID int) ([]models.Team, bool) {
+	defer metrics.ObserveQuery("GetTeamsForExercise", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.GetTeamsForExerciseDB(ctx, exerciseID)
+}
+
+// SetTeamStatuses writes the given fields of several teams atomically and
+// returns each team's previous status
+func SetTeamStatuses(ctx context.Context, teams []models.Team, fields []string) (map[int]string, bool) {
+	defer metrics.ObserveQuery("SetTeamStatuses", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.SetTeamStatusesDB(ctx, teams, fields)
+}
+
+// ExpireTeamStatuses reverts every team whose status window has ended and
+// returns how many were expired
+func ExpireTeamStatuses(ctx context.Context, revertTo string) (int, bool) {
+	defer metrics.ObserveQuery("ExpireTeamStatuses", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return 0, false
+	}
+	return repo.ExpireTeamStatusesDB(ctx, revertTo)
+}
+
+// FlagStaleTeams flags teams of running exercises whose status is older than
+// staleAfter and returns how many were newly flagged
+func FlagStaleTeams(ctx context.Context, staleAfter time.Duration) (int, bool) {
+	defer metrics.ObserveQuery("FlagStaleTeams", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return 0, false
+	}
+	return repo.FlagStaleTeamsDB(ctx, staleAfter)
+}
+
+// ConfirmTeamStatus marks a team's status as still accurate
+func ConfirmTeamStatus(ctx context.Context, id int) (models.Team, bool) {
+	defer metrics.ObserveQuery("ConfirmTeamStatus", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Team{}, false
+	}
+	return repo.ConfirmTeamStatusDB(ctx, id)
+}
+
+// GetEscalationPolicy returns an exercise's escalation steps
+func GetEscalationPolicy(ctx context.Context, exerciseID int) (models.EscalationPolicy, bool) {
+	defer metrics.ObserveQuery("GetEscalationPolicy", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.EscalationPolicy{}, false
+	}
+	return repo.GetEscalationPolicyDB(ctx, exerciseID)
+}
+
+// SetEscalationPolicy replaces an exercise's escalation steps
+func SetEscalationPolicy(ctx context.Context, policy models.EscalationPolicy) bool {
+	defer metrics.ObserveQuery("SetEscalationPolicy", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.SetEscalationPolicyDB(ctx, policy)
+}
+
+// RunEscalations triggers the escalation steps that are due and returns how
+// many were triggered
+func RunEscalations(ctx context.Context) (int, bool) {
+	defer metrics.ObserveQuery("RunEscalations", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return 0, false
+	}
+	return repo.RunEscalationsDB(ctx)
+}
+
+// GetEscalations returns an exercise's escalation history, newest first
+func GetEscalations(ctx context.Context, exerciseID int, openOnly bool) ([]models.Escalation, bool) {
+	defer metrics.ObserveQuery("GetEscalations", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.GetEscalationsDB(ctx, exerciseID, openOnly)
+}
+
+// GetEscalationByID returns one escalation
+func GetEscalationByID(ctx context.Context, id int) (models.Escalation, bool) {
+	defer metrics.ObserveQuery("GetEscalationByID", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Escalation{}, false
+	}
+	return repo.GetEscalationByIDDB(ctx, id)
+}
+
+// AcknowledgeEscalation acknowledges an escalation and stops further steps
+// for the team's current status
+func AcknowledgeEscalation(ctx context.Context, id int, by, note string) (models.Escalation, bool) {
+	defer metrics.ObserveQuery("AcknowledgeEscalation", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.Escalation{}, false
+	}
+	return repo.AcknowledgeEscalationDB(ctx, id, by, note)
+}
+
+// GetStatusPeriods returns the status history of an exercise's teams
+func GetStatusPeriods(ctx context.Context, exerciseID int) ([]models.StatusPeriod, bool) {
+	defer metrics.ObserveQuery("GetStatusPeriods", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.GetStatusPeriodsDB(ctx, exerciseID)
+}
+
+// GetSitrepTemplate returns an exercise's situation report templates
+func GetSitrepTemplate(ctx context.Context, exerciseID int) (models.SitrepTemplate, bool) {
+	defer metrics.ObserveQuery("GetSitrepTemplate", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return models.SitrepTemplate{ExerciseID: exerciseID}, false
+	}
+	return repo.GetSitrepTemplateDB(ctx, exerciseID)
+}
+
+// SetSitrepTemplate replaces an exercise's situation report templates
+func SetSitrepTemplate(ctx context.Context, tmpl models.SitrepTemplate) bool {
+	defer metrics.ObserveQuery("SetSitrepTemplate", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return false
+	}
+	return repo.SetSitrepTemplateDB(ctx, tmpl)
+}
+
+// GetOpenTasksDue returns an exercise's open tasks due before the given time
+func GetOpenTasksDue(ctx context.Context, exerciseID int, before time.Time) ([]models.Task, bool) {
+	defer metrics.ObserveQuery("GetOpenTasksDue", time.Now())
+	if repo == nil {
+		slog.WarnContext(ctx, "Repository not initialized")
+		return nil, false
+	}
+	return repo.GetOpenTasksDueDB(ctx, exerciseID, before)
+}
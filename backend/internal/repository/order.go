@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+)
+
+// New divisions and teams go after the last one in their exercise or
+// division. $1 must be the exercise ID for divisions and $2 the division ID
+// for teams, as in their INSERT statements.
+const (
+	nextDivisionOrder = "(SELECT COALESCE(MAX(sort_order), 0) + 1 FROM divisions WHERE exercise_id = $1)"
+	nextTeamOrder     = "(SELECT COALESCE(MAX(sort_order), 0) + 1 FROM teams WHERE division_id = $2)"
+)
+
+// setOrder numbers the rows of table in the order of ids, starting at 1.
+// Every row must belong to the parent named by parentColumn and parentID.
+func setOrder(ctx context.Context, tx *sql.Tx, table, parentColumn string, parentID int, ids []int) (bool, error) {
+	query := "UPDATE " + table + " SET sort_order = $3 WHERE id = $1 AND " + parentColumn + " = $2"
+	for i, id := range ids {
+		result, err := tx.ExecContext(ctx, query, id, parentID, i+1)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ReorderDivisionsDB sets the order of an exercise's divisions. ids must
+// list every division of the exercise.
+func (r *PostgresRepository) ReorderDivisionsDB(ctx context.Context, exerciseID int, ids []int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	ok, err := setOrder(ctx, tx, "divisions", "exercise_id", exerciseID, ids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reordering divisions", "exercise_id", exerciseID, "error", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.DivisionsReordered, exerciseID, map[string]interface{}{
+		"exercise_id":  exerciseID,
+		"division_ids": ids,
+	})
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// ReorderTeamsDB sets the order of a division's teams. ids must list every
+// team of the division.
+func (r *PostgresRepository) ReorderTeamsDB(ctx context.Context, exerciseID, divisionID int, ids []int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	ok, err := setOrder(ctx, tx, "teams", "division_id", divisionID, ids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reordering teams", "division_id", divisionID, "error", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.TeamsReordered, exerciseID, map[string]interface{}{
+		"division_id": divisionID,
+		"team_ids":    ids,
+	})
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// MoveTeamDB moves a team to another division of the same exercise, placing
+// it at position (counting from 1) among that division's teams, or last when
+// position is 0 or past the end. The team keeps its ID, so its tasks, status,
+// comments and history go with it; team-scoped role grants are updated to
+// the new division. A division in another exercise is refused.
+func (r *PostgresRepository) MoveTeamDB(ctx context.Context, teamID, divisionID, position int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	var exerciseID, previousDivisionID int
+	err = tx.QueryRowContext(ctx, "SELECT exercise_id, division_id FROM teams WHERE id = $1 FOR UPDATE", teamID).
+		Scan(&exerciseID, &previousDivisionID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error locking team", "team_id", teamID, "error", err)
+		}
+		return false
+	}
+
+	var sameExercise bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM divisions WHERE id = $1 AND exercise_id = $2)", divisionID, exerciseID).
+		Scan(&sameExercise)
+	if err != nil || !sameExercise {
+		slog.WarnContext(ctx, "Refusing to move team outside its exercise", "team_id", teamID, "division_id", divisionID, "error", err)
+		return false
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM teams WHERE division_id = $1 AND id <> $2 ORDER BY sort_order, id FOR UPDATE",
+		divisionID, teamID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching teams", "division_id", divisionID, "error", err)
+		return false
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "Error scanning team", "error", err)
+			return false
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if position < 1 || position > len(ids) {
+		position = len(ids) + 1
+	}
+	ids = append(ids[:position-1], append([]int{teamID}, ids[position-1:]...)...)
+
+	if _, err := tx.ExecContext(ctx, "UPDATE teams SET division_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1", teamID, divisionID); err != nil {
+		slog.ErrorContext(ctx, "Error moving team", "team_id", teamID, "error", err)
+		return false
+	}
+	if _, err := setOrder(ctx, tx, "teams", "division_id", divisionID, ids); err != nil {
+		slog.ErrorContext(ctx, "Error reordering teams", "division_id", divisionID, "error", err)
+		return false
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE role_grants SET division_id = $2 WHERE team_id = $1", teamID, divisionID); err != nil {
+		slog.ErrorContext(ctx, "Error updating team grants", "team_id", teamID, "error", err)
+		return false
+	}
+
+	team, _ := getTeam(ctx, tx, teamID)
+	changes.Emit(ctx, tx, changes.TeamMoved, exerciseID, map[string]interface{}{
+		"team":                 team,
+		"previous_division_id": previousDivisionID,
+	})
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,520 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+	"strings"
+	"time"
+)
+
+// Partial updates write only the columns named by the fields of a JSON Merge
+// Patch, so values the client did not send are never overwritten with what
+// the server read before the update. Column names match the JSON field names.
+
+// updateColumns sets the columns listed in fields to their values, plus
+// updated_at when touch is set, and reports whether the row exists. Fields
+// without a value are skipped; the caller stores those itself.
+func updateColumns(ctx context.Context, tx *sql.Tx, table string, id int, values map[string]interface{}, fields []string, touch bool) (bool, error) {
+	var set []string
+	args := []interface{}{id}
+	for _, field := range fields {
+		value, ok := values[field]
+		if !ok {
+			continue
+		}
+		args = append(args, value)
+		set = append(set, fmt.Sprintf("%s = $%d", field, len(args)))
+	}
+	if touch {
+		set = append(set, "updated_at = CURRENT_TIMESTAMP")
+	}
+	if len(set) == 0 {
+		// Nothing to write, but the row must still exist
+		set = append(set, "id = id")
+	}
+
+	result, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s WHERE id = $1", table, strings.Join(set, ", ")), args...)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// hasField reports whether a patch sets field
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// nullTime stores a zero time as NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullString stores an empty string as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// PatchExerciseDB writes the given fields of an exercise. Tasked divisions
+// are replaced only when the patch sets them, and divisions and teams are
+// never touched.
+func (r *PostgresRepository) PatchExerciseDB(ctx context.Context, exercise models.Exercise, fields []string) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	values := map[string]interface{}{
+		"name":               exercise.Name,
+		"start_date":         exercise.StartDate,
+		"end_date":           exercise.EndDate,
+		"description":        exercise.Description,
+		"priority":           exercise.Priority,
+		"exercise_event_poc": exercise.ExerciseEventPOC,
+		"aoc_involvement":    exercise.AOCInvolvement,
+		"srd_poc":            exercise.SRDPOC,
+		"cpd_poc":            exercise.CPDPOC,
+	}
+	found, err := updateColumns(ctx, tx, "exercises", exercise.ID, values, fields, true)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error patching exercise", "exercise_id", exercise.ID, "error", err)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	if hasField(fields, "tasked_divisions") {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM tasked_divisions WHERE exercise_id = $1", exercise.ID); err != nil {
+			slog.ErrorContext(ctx, "Error deleting old tasked divisions", "error", err)
+			return false
+		}
+		for _, divName := range exercise.TaskedDivisions {
+			_, err := tx.ExecContext(ctx, "INSERT INTO tasked_divisions (exercise_id, division_name) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				exercise.ID, divName)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error saving tasked division", "error", err)
+				return false
+			}
+		}
+	}
+
+	exercise.Divisions, exercise.Events = nil, nil
+	changes.Emit(ctx, tx, changes.ExerciseUpdated, exercise.ID, exercise)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// GetDivisionByIDDB returns a division with its teams
+func (r *PostgresRepository) GetDivisionByIDDB(ctx context.Context, id int) (models.Division, bool) {
+	var division models.Division
+	var learningObjectives sql.NullString
+
+	err := r.db.QueryRowContext(ctx, "SELECT id, exercise_id, name, learning_objectives, COALESCE(poc, ''), sort_order FROM divisions WHERE id = $1", id).
+		Scan(&division.ID, &division.ExerciseID, &division.Name, &learningObjectives, &division.POC, &division.SortOrder)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching division", "division_id", id, "error", err)
+		}
+		return division, false
+	}
+
+	division.LearningObjectives = learningObjectives.String
+	division.Teams = r.GetTeamsForDivision(ctx, division.ExerciseID, division.ID)
+	return division, true
+}
+
+// PatchDivisionDB writes the given fields of a division
+func (r *PostgresRepository) PatchDivisionDB(ctx context.Context, division models.Division, fields []string) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	values := map[string]interface{}{
+		"name":                division.Name,
+		"learning_objectives": division.LearningObjectives,
+		"poc":                 division.POC,
+	}
+	found, err := updateColumns(ctx, tx, "divisions", division.ID, values, fields, false)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error patching division", "division_id", division.ID, "error", err)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	division.Teams = nil
+	changes.Emit(ctx, tx, changes.DivisionUpdated, division.ExerciseID, division)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// GetTeamByIDDB returns a single team
+func (r *PostgresRepository) GetTeamByIDDB(ctx context.Context, id int) (models.Team, bool) {
+	return getTeam(ctx, r.db, id)
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getTeam reads a team through q, so a transaction sees its own writes
+func getTeam(ctx context.Context, q rowQuerier, id int) (models.Team, bool) {
+	var team models.Team
+	var poc, status, comments sql.NullString
+	var statusStart, statusEnd sql.NullTime
+
+	query := `
+		SELECT id, exercise_id, division_id, name, poc, status, status_start, status_end, comments, sort_order, readiness_weight,
+		       COALESCE(status_changed_at, created_at), COALESCE(status_confirmed_at, created_at), stale_flagged_at IS NOT NULL
+		FROM teams
+		WHERE id = $1
+	`
+	err := q.QueryRowContext(ctx, query, id).Scan(&team.ID, &team.ExerciseID, &team.DivisionID, &team.Name,
+		&poc, &status, &statusStart, &statusEnd, &comments, &team.SortOrder, &team.ReadinessWeight,
+		&team.StatusChangedAt, &team.StatusConfirmedAt, &team.StatusStale)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching team", "team_id", id, "error", err)
+		}
+		return team, false
+	}
+
+	team.POC = poc.String
+	team.Status = status.String
+	if team.Status == "" {
+		team.Status = "green"
+	}
+	team.Comments = comments.String
+	team.StatusStart = statusStart.Time
+	team.StatusEnd = statusEnd.Time
+	return team, true
+}
+
+// teamColumns are the team columns a full update writes; the exercise and
+// division are not among them
+var teamColumns = []string{"name", "poc", "status", "status_start", "status_end", "comments", "readiness_weight"}
+
+// UpdateTeamDB writes every editable field of a team
+func (r *PostgresRepository) UpdateTeamDB(ctx context.Context, team models.Team) bool {
+	return r.PatchTeamDB(ctx, team, teamColumns)
+}
+
+// PatchTeamDB writes the given fields of a team, recording a status change
+// when the status differs from the stored one
+func (r *PostgresRepository) PatchTeamDB(ctx context.Context, team models.Team, fields []string) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	previousStatus, found, err := patchTeam(ctx, tx, team, fields)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error patching team", "team_id", team.ID, "error", err)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.TeamUpdated, team.ExerciseID, team)
+	if hasField(fields, "status") && previousStatus != team.Status {
+		changes.Emit(ctx, tx, changes.TeamStatusChanged, team.ExerciseID, map[string]interface{}{
+			"team":            team,
+			"previous_status": previousStatus,
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// patchTeam locks a team, writes the given fields and returns the status it
+// had before
+func patchTeam(ctx context.Context, tx *sql.Tx, team models.Team, fields []string) (string, bool, error) {
+	var previousStatus string
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(status, 'green') FROM teams WHERE id = $1 FOR UPDATE", team.ID).Scan(&previousStatus)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	values := map[string]interface{}{
+		"name":             team.Name,
+		"poc":              team.POC,
+		"status":           team.Status,
+		"status_start":     nullTime(team.StatusStart),
+		"status_end":       nullTime(team.StatusEnd),
+		"comments":         team.Comments,
+		"readiness_weight": team.ReadinessWeight,
+	}
+	if _, err := updateColumns(ctx, tx, "teams", team.ID, values, fields, true); err != nil {
+		return "", false, err
+	}
+	// Setting a status, even the same one, confirms it
+	if hasField(fields, "status") {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE teams
+			SET status_confirmed_at = CURRENT_TIMESTAMP, stale_flagged_at = NULL,
+			    status_changed_at = CASE WHEN $2 THEN CURRENT_TIMESTAMP ELSE status_changed_at END
+			WHERE id = $1`, team.ID, previousStatus != team.Status)
+		if err != nil {
+			return "", false, err
+		}
+	}
+	if hasField(fields, "status") || hasField(fields, "status_start") || hasField(fields, "status_end") {
+		if err := recordStatusPeriod(ctx, tx, team.ID); err != nil {
+			return "", false, err
+		}
+	}
+	return previousStatus, true, nil
+}
+
+// GetEventByIDDB returns a single event
+func (r *PostgresRepository) GetEventByIDDB(ctx context.Context, id int) (models.Event, bool) {
+	var event models.Event
+	var poc, description, location sql.NullString
+
+	query := `
+		SELECT id, exercise_id, name, start_date, end_date, type, priority, poc, status, description, location, created_at, updated_at
+		FROM events
+		WHERE id = $1
+	`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&event.ID, &event.ExerciseID, &event.Name, &event.StartDate,
+		&event.EndDate, &event.Type, &event.Priority, &poc, &event.Status,
+		&description, &location, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching event", "event_id", id, "error", err)
+		}
+		return event, false
+	}
+
+	event.POC = poc.String
+	event.Description = description.String
+	event.Location = location.String
+	return event, true
+}
+
+// PatchEventDB writes the given fields of an event, recording a reschedule
+// when its dates move
+func (r *PostgresRepository) PatchEventDB(ctx context.Context, event models.Event, fields []string) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	var previousStart, previousEnd time.Time
+	err = tx.QueryRowContext(ctx, "SELECT start_date, end_date FROM events WHERE id = $1 FOR UPDATE", event.ID).
+		Scan(&previousStart, &previousEnd)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error locking event", "event_id", event.ID, "error", err)
+		}
+		return false
+	}
+
+	values := map[string]interface{}{
+		"name":        event.Name,
+		"start_date":  event.StartDate,
+		"end_date":    event.EndDate,
+		"type":        event.Type,
+		"priority":    event.Priority,
+		"poc":         event.POC,
+		"status":      event.Status,
+		"description": event.Description,
+		"location":    event.Location,
+	}
+	if _, err := updateColumns(ctx, tx, "events", event.ID, values, fields, true); err != nil {
+		slog.ErrorContext(ctx, "Error patching event", "event_id", event.ID, "error", err)
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.EventUpdated, event.ExerciseID, event)
+	if !previousStart.Equal(event.StartDate) || !previousEnd.Equal(event.EndDate) {
+		changes.Emit(ctx, tx, changes.EventRescheduled, event.ExerciseID, map[string]interface{}{
+			"event":               event,
+			"previous_start_date": previousStart,
+			"previous_end_date":   previousEnd,
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// GetTaskByIDDB returns a single task with the teams it is assigned to
+func (r *PostgresRepository) GetTaskByIDDB(ctx context.Context, id int) (models.Task, bool) {
+	var task models.Task
+	var teamID sql.NullInt64
+	var dueDate, completedAt sql.NullTime
+	var description, assignedTo, teamName, divisionName sql.NullString
+
+	query := `
+		SELECT t.id, t.exercise_id, t.team_id, t.name, t.description, t.status,
+		       t.due_date, t.assigned_to, t.completed_at, t.created_at, t.updated_at,
+		       COALESCE(tm.name, ''), COALESCE(d.name, '')
+		FROM tasks t
+		LEFT JOIN teams tm ON t.team_id = tm.id
+		LEFT JOIN divisions d ON tm.division_id = d.id
+		WHERE t.id = $1
+	`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&task.ID, &task.ExerciseID, &teamID, &task.Name, &description,
+		&task.Status, &dueDate, &assignedTo, &completedAt, &task.CreatedAt, &task.UpdatedAt, &teamName, &divisionName)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching task", "task_id", id, "error", err)
+		}
+		return task, false
+	}
+
+	task.Description = description.String
+	task.AssignedTo = assignedTo.String
+	task.TeamName = teamName.String
+	task.DivisionName = divisionName.String
+	if teamID.Valid {
+		tid := int(teamID.Int64)
+		task.TeamID = &tid
+	}
+	if dueDate.Valid {
+		task.DueDate = &dueDate.Time
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+
+	teamsQuery := `
+		SELECT tm.id, tm.exercise_id, tm.division_id, tm.name, COALESCE(tm.poc, ''), COALESCE(tm.status, 'green'), COALESCE(tm.comments, '')
+		FROM task_teams tt
+		JOIN teams tm ON tt.team_id = tm.id
+		WHERE tt.task_id = $1
+		ORDER BY tm.name
+	`
+	rows, err := r.db.QueryContext(ctx, teamsQuery, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading teams for task", "task_id", id, "error", err)
+		return task, true
+	}
+	defer rows.Close()
+
+	task.TeamIDs = []int{}
+	task.Teams = []models.Team{}
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.ID, &team.ExerciseID, &team.DivisionID, &team.Name, &team.POC, &team.Status, &team.Comments); err != nil {
+			slog.ErrorContext(ctx, "Error scanning team for task", "error", err)
+			continue
+		}
+		task.TeamIDs = append(task.TeamIDs, team.ID)
+		task.Teams = append(task.Teams, team)
+	}
+	return task, true
+}
+
+// PatchTaskDB writes the given fields of a task. Setting the status stamps
+// or clears completed_at when the task becomes or stops being completed, and
+// team_ids replaces the task's teams.
+func (r *PostgresRepository) PatchTaskDB(ctx context.Context, task models.Task, fields []string) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	var previousStatus string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(status, 'pending') FROM tasks WHERE id = $1 FOR UPDATE", task.ID).Scan(&previousStatus)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error locking task", "task_id", task.ID, "error", err)
+		}
+		return false
+	}
+
+	values := map[string]interface{}{
+		"name":        task.Name,
+		"description": nullString(task.Description),
+		"status":      task.Status,
+		"due_date":    task.DueDate,
+		"assigned_to": nullString(task.AssignedTo),
+		"team_id":     task.TeamID,
+	}
+	if hasField(fields, "status") && (task.Status == "completed") != (previousStatus == "completed") {
+		fields = append(fields, "completed_at")
+		values["completed_at"] = nil
+		if task.Status == "completed" {
+			values["completed_at"] = time.Now()
+		}
+	}
+	if _, err := updateColumns(ctx, tx, "tasks", task.ID, values, fields, true); err != nil {
+		slog.ErrorContext(ctx, "Error patching task", "task_id", task.ID, "error", err)
+		return false
+	}
+
+	if hasField(fields, "team_ids") {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM task_teams WHERE task_id = $1", task.ID); err != nil {
+			slog.ErrorContext(ctx, "Error clearing existing team assignments", "error", err)
+			return false
+		}
+		for _, teamID := range task.TeamIDs {
+			_, err := tx.ExecContext(ctx, "INSERT INTO task_teams (task_id, team_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", task.ID, teamID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error assigning task to team", "team_id", teamID, "error", err)
+				return false
+			}
+		}
+		changes.Emit(ctx, tx, changes.TaskAssigned, task.ExerciseID, map[string]interface{}{
+			"task_id":  task.ID,
+			"team_ids": task.TeamIDs,
+		})
+	}
+
+	task.Teams = nil
+	changes.Emit(ctx, tx, changes.TaskUpdated, task.ExerciseID, task)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
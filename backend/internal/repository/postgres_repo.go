@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
 	"srd-calendar-project/backend/internal/database"
 	"srd-calendar-project/backend/internal/models"
 	"time"
@@ -21,7 +23,7 @@ func NewPostgresRepository() *PostgresRepository {
 }
 
 // GetAllExercisesDB returns all exercises from the database
-func (r *PostgresRepository) GetAllExercisesDB() []models.Exercise {
+func (r *PostgresRepository) GetAllExercisesDB(ctx context.Context) []models.Exercise {
 	query := `
 		SELECT id, name, start_date, end_date, description, 
 		       COALESCE(priority, 'medium'), COALESCE(exercise_event_poc, ''), COALESCE(aoc_involvement, ''), COALESCE(srd_poc, ''), COALESCE(cpd_poc, '')
@@ -29,9 +31,9 @@ func (r *PostgresRepository) GetAllExercisesDB() []models.Exercise {
 		ORDER BY start_date
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf("Error fetching exercises: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercises", "error", err)
 		return []models.Exercise{}
 	}
 	defer rows.Close()
@@ -44,7 +46,7 @@ func (r *PostgresRepository) GetAllExercisesDB() []models.Exercise {
 		err := rows.Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate, 
 			&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 		if err != nil {
-			log.Printf("Error scanning exercise: %v", err)
+			slog.ErrorContext(ctx, "Error scanning exercise", "error", err)
 			continue
 		}
 
@@ -56,13 +58,13 @@ func (r *PostgresRepository) GetAllExercisesDB() []models.Exercise {
 		ex.CPDPOC = cpdPoc.String
 
 		// Load divisions for this exercise
-		ex.Divisions = r.GetDivisionsForExercise(ex.ID)
+		ex.Divisions = r.GetDivisionsForExercise(ctx, ex.ID)
 		
 		// Load tasked divisions
-		ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+		ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 		
 		// Load events for this exercise
-		ex.Events = r.GetEventsForExercise(ex.ID)
+		ex.Events = r.GetEventsForExercise(ctx, ex.ID)
 
 		exercises = append(exercises, ex)
 	}
@@ -71,7 +73,7 @@ func (r *PostgresRepository) GetAllExercisesDB() []models.Exercise {
 }
 
 // GetExerciseByIDDB returns a single exercise by ID from the database
-func (r *PostgresRepository) GetExerciseByIDDB(id int) (models.Exercise, bool) {
+func (r *PostgresRepository) GetExerciseByIDDB(ctx context.Context, id int) (models.Exercise, bool) {
 	var ex models.Exercise
 	var desc, eventPoc, aoc, srdPoc, cpdPoc sql.NullString
 
@@ -83,13 +85,13 @@ func (r *PostgresRepository) GetExerciseByIDDB(id int) (models.Exercise, bool) {
 	`
 
 	var priority sql.NullString
-	err := r.db.QueryRow(query, id).Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
 		&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ex, false
 		}
-		log.Printf("Error fetching exercise by ID: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercise by ID", "error", err)
 		return ex, false
 	}
 
@@ -101,19 +103,19 @@ func (r *PostgresRepository) GetExerciseByIDDB(id int) (models.Exercise, bool) {
 	ex.CPDPOC = cpdPoc.String
 
 	// Load divisions for this exercise
-	ex.Divisions = r.GetDivisionsForExercise(ex.ID)
+	ex.Divisions = r.GetDivisionsForExercise(ctx, ex.ID)
 	
 	// Load tasked divisions
-	ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+	ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 
 	return ex, true
 }
 
 // CreateExerciseDB creates a new exercise in the database
-func (r *PostgresRepository) CreateExerciseDB(exercise models.Exercise) models.Exercise {
-	tx, err := r.db.Begin()
+func (r *PostgresRepository) CreateExerciseDB(ctx context.Context, exercise models.Exercise) models.Exercise {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error starting transaction: %v", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
 		return exercise
 	}
 	defer tx.Rollback()
@@ -125,34 +127,36 @@ func (r *PostgresRepository) CreateExerciseDB(exercise models.Exercise) models.E
 		RETURNING id
 	`
 
-	err = tx.QueryRow(query, exercise.Name, exercise.StartDate, exercise.EndDate,
+	err = tx.QueryRowContext(ctx, query, exercise.Name, exercise.StartDate, exercise.EndDate,
 		exercise.Description, exercise.Priority, exercise.ExerciseEventPOC, exercise.AOCInvolvement, exercise.SRDPOC, exercise.CPDPOC).Scan(&exercise.ID)
 	if err != nil {
-		log.Printf("Error creating exercise: %v", err)
+		slog.ErrorContext(ctx, "Error creating exercise", "error", err)
 		return exercise
 	}
 
 	// Create default divisions if none provided
 	if len(exercise.Divisions) == 0 {
-		exercise.Divisions = r.createDefaultDivisions(tx, exercise.ID)
+		exercise.Divisions = r.createDefaultDivisions(ctx, tx, exercise.ID)
 	} else {
 		// Save provided divisions
 		for _, division := range exercise.Divisions {
-			r.createDivision(tx, exercise.ID, division)
+			r.createDivision(ctx, tx, exercise.ID, division)
 		}
 	}
 
 	// Save tasked divisions
 	for _, divName := range exercise.TaskedDivisions {
-		_, err = tx.Exec("INSERT INTO tasked_divisions (exercise_id, division_name) VALUES ($1, $2)", 
+		_, err = tx.ExecContext(ctx, "INSERT INTO tasked_divisions (exercise_id, division_name) VALUES ($1, $2)", 
 			exercise.ID, divName)
 		if err != nil {
-			log.Printf("Error saving tasked division: %v", err)
+			slog.ErrorContext(ctx, "Error saving tasked division", "error", err)
 		}
 	}
 
+	changes.Emit(ctx, tx, changes.ExerciseCreated, exercise.ID, exercise)
+
 	if err = tx.Commit(); err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return exercise
 	}
 
@@ -160,10 +164,10 @@ func (r *PostgresRepository) CreateExerciseDB(exercise models.Exercise) models.E
 }
 
 // UpdateExerciseDB updates an exercise in the database
-func (r *PostgresRepository) UpdateExerciseDB(exercise models.Exercise) bool {
-	tx, err := r.db.Begin()
+func (r *PostgresRepository) UpdateExerciseDB(ctx context.Context, exercise models.Exercise) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error starting transaction: %v", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
 		return false
 	}
 	defer tx.Rollback()
@@ -175,10 +179,10 @@ func (r *PostgresRepository) UpdateExerciseDB(exercise models.Exercise) bool {
 		WHERE id = $1
 	`
 
-	result, err := tx.Exec(query, exercise.ID, exercise.Name, exercise.StartDate, exercise.EndDate,
+	result, err := tx.ExecContext(ctx, query, exercise.ID, exercise.Name, exercise.StartDate, exercise.EndDate,
 		exercise.Description, exercise.Priority, exercise.ExerciseEventPOC, exercise.AOCInvolvement, exercise.SRDPOC, exercise.CPDPOC)
 	if err != nil {
-		log.Printf("Error updating exercise: %v", err)
+		slog.ErrorContext(ctx, "Error updating exercise", "error", err)
 		return false
 	}
 
@@ -191,27 +195,32 @@ func (r *PostgresRepository) UpdateExerciseDB(exercise models.Exercise) bool {
 	if len(exercise.Divisions) > 0 {
 		for _, division := range exercise.Divisions {
 			for _, team := range division.Teams {
-				r.updateTeam(tx, team)
+				team.ExerciseID = exercise.ID
+				if err := r.updateTeam(ctx, tx, team); err != nil {
+					slog.ErrorContext(ctx, "Error updating team", "team_id", team.ID, "error", err)
+				}
 			}
 		}
 	}
 
 	// Update tasked divisions
-	_, err = tx.Exec("DELETE FROM tasked_divisions WHERE exercise_id = $1", exercise.ID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM tasked_divisions WHERE exercise_id = $1", exercise.ID)
 	if err != nil {
-		log.Printf("Error deleting old tasked divisions: %v", err)
+		slog.ErrorContext(ctx, "Error deleting old tasked divisions", "error", err)
 	}
 	
 	for _, divName := range exercise.TaskedDivisions {
-		_, err = tx.Exec("INSERT INTO tasked_divisions (exercise_id, division_name) VALUES ($1, $2)", 
+		_, err = tx.ExecContext(ctx, "INSERT INTO tasked_divisions (exercise_id, division_name) VALUES ($1, $2)", 
 			exercise.ID, divName)
 		if err != nil {
-			log.Printf("Error saving tasked division: %v", err)
+			slog.ErrorContext(ctx, "Error saving tasked division", "error", err)
 		}
 	}
 
+	changes.Emit(ctx, tx, changes.ExerciseUpdated, exercise.ID, exercise)
+
 	if err = tx.Commit(); err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return false
 	}
 
@@ -219,30 +228,48 @@ func (r *PostgresRepository) UpdateExerciseDB(exercise models.Exercise) bool {
 }
 
 // DeleteExerciseDB deletes an exercise from the database
-func (r *PostgresRepository) DeleteExerciseDB(id int) bool {
+func (r *PostgresRepository) DeleteExerciseDB(ctx context.Context, id int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
 	query := "DELETE FROM exercises WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
-		log.Printf("Error deleting exercise: %v", err)
+		slog.ErrorContext(ctx, "Error deleting exercise", "error", err)
 		return false
 	}
 
 	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	if rowsAffected == 0 {
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.ExerciseDeleted, id, map[string]int{"id": id})
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+
+	return true
 }
 
 // GetDivisionsForExercise gets all divisions for an exercise
-func (r *PostgresRepository) GetDivisionsForExercise(exerciseID int) []models.Division {
+func (r *PostgresRepository) GetDivisionsForExercise(ctx context.Context, exerciseID int) []models.Division {
 	query := `
-		SELECT id, name, COALESCE(learning_objectives, '')
+		SELECT id, name, COALESCE(learning_objectives, ''), COALESCE(poc, ''), sort_order
 		FROM divisions
 		WHERE exercise_id = $1
-		ORDER BY id
+		ORDER BY sort_order, id
 	`
 
-	rows, err := r.db.Query(query, exerciseID)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID)
 	if err != nil {
-		log.Printf("Error fetching divisions: %v", err)
+		slog.ErrorContext(ctx, "Error fetching divisions", "error", err)
 		return []models.Division{}
 	}
 	defer rows.Close()
@@ -253,16 +280,16 @@ func (r *PostgresRepository) GetDivisionsForExercise(exerciseID int) []models.Di
 		var learningObjectives sql.NullString
 		div.ExerciseID = exerciseID
 		
-		err := rows.Scan(&div.ID, &div.Name, &learningObjectives)
+		err := rows.Scan(&div.ID, &div.Name, &learningObjectives, &div.POC, &div.SortOrder)
 		if err != nil {
-			log.Printf("Error scanning division: %v", err)
+			slog.ErrorContext(ctx, "Error scanning division", "error", err)
 			continue
 		}
 		
 		div.LearningObjectives = learningObjectives.String
 
 		// Load teams for this division
-		div.Teams = r.GetTeamsForDivision(exerciseID, div.ID)
+		div.Teams = r.GetTeamsForDivision(ctx, exerciseID, div.ID)
 		divisions = append(divisions, div)
 	}
 
@@ -270,18 +297,18 @@ func (r *PostgresRepository) GetDivisionsForExercise(exerciseID int) []models.Di
 }
 
 // GetDivisionsForExerciseByName returns only divisions that match the specified name for an exercise
-func (r *PostgresRepository) GetDivisionsForExerciseByName(exerciseID int, divisionName string) []models.Division {
-	log.Printf("DEBUG: GetDivisionsForExerciseByName called with exerciseID=%d, divisionName='%s'", exerciseID, divisionName)
+func (r *PostgresRepository) GetDivisionsForExerciseByName(ctx context.Context, exerciseID int, divisionName string) []models.Division {
+	slog.DebugContext(ctx, "GetDivisionsForExerciseByName called", "exercise_id", exerciseID, "division_name", divisionName)
 	query := `
-		SELECT id, name, COALESCE(learning_objectives, '')
+		SELECT id, name, COALESCE(learning_objectives, ''), COALESCE(poc, ''), sort_order
 		FROM divisions
 		WHERE exercise_id = $1 AND name = $2
-		ORDER BY id
+		ORDER BY sort_order, id
 	`
 
-	rows, err := r.db.Query(query, exerciseID, divisionName)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID, divisionName)
 	if err != nil {
-		log.Printf("Error fetching divisions by name for exercise: %v", err)
+		slog.ErrorContext(ctx, "Error fetching divisions by name for exercise", "error", err)
 		return []models.Division{}
 	}
 	defer rows.Close()
@@ -291,9 +318,9 @@ func (r *PostgresRepository) GetDivisionsForExerciseByName(exerciseID int, divis
 		var div models.Division
 		var learningObjectives sql.NullString
 
-		err := rows.Scan(&div.ID, &div.Name, &learningObjectives)
+		err := rows.Scan(&div.ID, &div.Name, &learningObjectives, &div.POC, &div.SortOrder)
 		if err != nil {
-			log.Printf("Error scanning division: %v", err)
+			slog.ErrorContext(ctx, "Error scanning division", "error", err)
 			continue
 		}
 
@@ -301,7 +328,7 @@ func (r *PostgresRepository) GetDivisionsForExerciseByName(exerciseID int, divis
 		div.LearningObjectives = learningObjectives.String
 
 		// Load teams for this division
-		div.Teams = r.GetTeamsForDivision(exerciseID, div.ID)
+		div.Teams = r.GetTeamsForDivision(ctx, exerciseID, div.ID)
 
 		divisions = append(divisions, div)
 	}
@@ -310,17 +337,18 @@ func (r *PostgresRepository) GetDivisionsForExerciseByName(exerciseID int, divis
 }
 
 // GetTeamsForDivision gets all teams for a division
-func (r *PostgresRepository) GetTeamsForDivision(exerciseID, divisionID int) []models.Team {
+func (r *PostgresRepository) GetTeamsForDivision(ctx context.Context, exerciseID, divisionID int) []models.Team {
 	query := `
-		SELECT id, name, poc, status, status_start, status_end, comments
+		SELECT id, name, poc, status, status_start, status_end, comments, sort_order, readiness_weight,
+		       COALESCE(status_changed_at, created_at), COALESCE(status_confirmed_at, created_at), stale_flagged_at IS NOT NULL
 		FROM teams
 		WHERE exercise_id = $1 AND division_id = $2
-		ORDER BY id
+		ORDER BY sort_order, id
 	`
 
-	rows, err := r.db.Query(query, exerciseID, divisionID)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID, divisionID)
 	if err != nil {
-		log.Printf("Error fetching teams: %v", err)
+		slog.ErrorContext(ctx, "Error fetching teams", "error", err)
 		return []models.Team{}
 	}
 	defer rows.Close()
@@ -334,9 +362,10 @@ func (r *PostgresRepository) GetTeamsForDivision(exerciseID, divisionID int) []m
 		team.ExerciseID = exerciseID
 		team.DivisionID = divisionID
 		
-		err := rows.Scan(&team.ID, &team.Name, &poc, &status, &statusStart, &statusEnd, &comments)
+		err := rows.Scan(&team.ID, &team.Name, &poc, &status, &statusStart, &statusEnd, &comments, &team.SortOrder, &team.ReadinessWeight,
+			&team.StatusChangedAt, &team.StatusConfirmedAt, &team.StatusStale)
 		if err != nil {
-			log.Printf("Error scanning team: %v", err)
+			slog.ErrorContext(ctx, "Error scanning team", "error", err)
 			continue
 		}
 
@@ -361,12 +390,12 @@ func (r *PostgresRepository) GetTeamsForDivision(exerciseID, divisionID int) []m
 }
 
 // GetTaskedDivisions gets the tasked divisions for an exercise
-func (r *PostgresRepository) GetTaskedDivisions(exerciseID int) []string {
+func (r *PostgresRepository) GetTaskedDivisions(ctx context.Context, exerciseID int) []string {
 	query := "SELECT division_name FROM tasked_divisions WHERE exercise_id = $1"
 	
-	rows, err := r.db.Query(query, exerciseID)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID)
 	if err != nil {
-		log.Printf("Error fetching tasked divisions: %v", err)
+		slog.ErrorContext(ctx, "Error fetching tasked divisions", "error", err)
 		return []string{}
 	}
 	defer rows.Close()
@@ -383,11 +412,11 @@ func (r *PostgresRepository) GetTaskedDivisions(exerciseID int) []string {
 }
 
 // createDefaultDivisions creates default divisions and teams for a new exercise
-func (r *PostgresRepository) createDefaultDivisions(tx *sql.Tx, exerciseID int) []models.Division {
+func (r *PostgresRepository) createDefaultDivisions(ctx context.Context, tx *sql.Tx, exerciseID int) []models.Division {
 	divisions := r.createStandardDivisions()
 
 	for i, division := range divisions {
-		divisions[i] = r.createDivision(tx, exerciseID, division)
+		divisions[i] = r.createDivision(ctx, tx, exerciseID, division)
 	}
 
 	return divisions
@@ -400,62 +429,62 @@ func (r *PostgresRepository) createStandardDivisions() []models.Division {
 			Name: "COD",
 			LearningObjectives: "Learning objectives for COD division",
 			Teams: []models.Team{
-				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team"},
+				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
 			},
 		},
 		{
 			Name: "CPD",
 			LearningObjectives: "Learning objectives for CPD division",
 			Teams: []models.Team{
-				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team"},
+				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
 			},
 		},
 		{
 			Name: "SRD",
 			LearningObjectives: "Learning objectives for SRD division",
 			Teams: []models.Team{
-				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team"},
+				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
 			},
 		},
 		{
 			Name: "ISRD",
 			LearningObjectives: "Learning objectives for ISRD division",
 			Teams: []models.Team{
-				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team"},
+				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
 			},
 		},
 		{
 			Name: "AMD",
 			LearningObjectives: "Learning objectives for AMD division",
 			Teams: []models.Team{
-				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team"},
-				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team"},
+				{Name: "Team 1", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 2", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 3", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
+				{Name: "Team 4", POC: "Team Leader", Status: "green", Comments: "Demo team", ReadinessWeight: 1},
 			},
 		},
 	}
 }
 
 // createDivision creates a division with its teams
-func (r *PostgresRepository) createDivision(tx *sql.Tx, exerciseID int, division models.Division) models.Division {
+func (r *PostgresRepository) createDivision(ctx context.Context, tx *sql.Tx, exerciseID int, division models.Division) models.Division {
 	var divID int
-	err := tx.QueryRow("INSERT INTO divisions (exercise_id, name, learning_objectives) VALUES ($1, $2, $3) RETURNING id",
-		exerciseID, division.Name, division.LearningObjectives).Scan(&divID)
+	err := tx.QueryRowContext(ctx, "INSERT INTO divisions (exercise_id, name, learning_objectives, poc, sort_order) VALUES ($1, $2, $3, $4, "+nextDivisionOrder+") RETURNING id, sort_order",
+		exerciseID, division.Name, division.LearningObjectives, division.POC).Scan(&divID, &division.SortOrder)
 	if err != nil {
-		log.Printf("Error creating division: %v", err)
+		slog.ErrorContext(ctx, "Error creating division", "error", err)
 		return division
 	}
 
@@ -465,67 +494,76 @@ func (r *PostgresRepository) createDivision(tx *sql.Tx, exerciseID int, division
 	// Create teams for this division
 	for j, team := range division.Teams {
 		var teamID int
-		err = tx.QueryRow(`
-			INSERT INTO teams (exercise_id, division_id, name, poc, status, comments)
-			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
-			exerciseID, divID, team.Name, team.POC, team.Status, team.Comments).Scan(&teamID)
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO teams (exercise_id, division_id, name, poc, status, comments, readiness_weight, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, `+nextTeamOrder+`) RETURNING id, sort_order, status_changed_at, status_confirmed_at`,
+			exerciseID, divID, team.Name, team.POC, team.Status, team.Comments, team.ReadinessWeight).
+			Scan(&teamID, &division.Teams[j].SortOrder, &division.Teams[j].StatusChangedAt, &division.Teams[j].StatusConfirmedAt)
 		
 		if err != nil {
-			log.Printf("Error creating team: %v", err)
+			slog.ErrorContext(ctx, "Error creating team", "error", err)
 			continue
 		}
 		
 		division.Teams[j].ID = teamID
 		division.Teams[j].ExerciseID = exerciseID
 		division.Teams[j].DivisionID = divID
+		if err := recordStatusPeriod(ctx, tx, teamID); err != nil {
+			slog.ErrorContext(ctx, "Error recording team status", "team_id", teamID, "error", err)
+		}
 	}
 
 	return division
 }
 
 // CreateDivisionDB creates a new division in the database
-func (r *PostgresRepository) CreateDivisionDB(division models.Division) models.Division {
+func (r *PostgresRepository) CreateDivisionDB(ctx context.Context, division models.Division) models.Division {
 	query := `
-		INSERT INTO divisions (exercise_id, name, learning_objectives)
-		VALUES ($1, $2, $3)
-		RETURNING id
+		INSERT INTO divisions (exercise_id, name, learning_objectives, poc, sort_order)
+		VALUES ($1, $2, $3, $4, `+nextDivisionOrder+`)
+		RETURNING id, sort_order
 	`
 
-	err := r.db.QueryRow(query, division.ExerciseID, division.Name, division.LearningObjectives).Scan(&division.ID)
+	err := r.db.QueryRowContext(ctx, query, division.ExerciseID, division.Name, division.LearningObjectives, division.POC).Scan(&division.ID, &division.SortOrder)
 	if err != nil {
-		log.Printf("Error creating division: %v", err)
+		slog.ErrorContext(ctx, "Error creating division", "error", err)
 		return division
 	}
 
 	// Initialize empty teams slice
 	division.Teams = []models.Team{}
+
+	changes.Emit(ctx, r.db, changes.DivisionCreated, division.ExerciseID, division)
 	return division
 }
 
 // UpdateDivisionDB updates a division's information including learning objectives
-func (r *PostgresRepository) UpdateDivisionDB(division models.Division) bool {
+func (r *PostgresRepository) UpdateDivisionDB(ctx context.Context, division models.Division) bool {
 	query := `
 		UPDATE divisions 
-		SET name = $2, learning_objectives = $3
+		SET name = $2, learning_objectives = $3, poc = $4
 		WHERE id = $1
+		RETURNING exercise_id
 	`
 
-	result, err := r.db.Exec(query, division.ID, division.Name, division.LearningObjectives)
+	err := r.db.QueryRowContext(ctx, query, division.ID, division.Name, division.LearningObjectives, division.POC).Scan(&division.ExerciseID)
 	if err != nil {
-		log.Printf("Error updating division: %v", err)
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error updating division", "error", err)
+		}
 		return false
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	changes.Emit(ctx, r.db, changes.DivisionUpdated, division.ExerciseID, division)
+	return true
 }
 
 // CreateTeamDB creates a new team in the database
-func (r *PostgresRepository) CreateTeamDB(team models.Team) models.Team {
+func (r *PostgresRepository) CreateTeamDB(ctx context.Context, team models.Team) models.Team {
 	query := `
-		INSERT INTO teams (exercise_id, division_id, name, poc, status, comments)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
+		INSERT INTO teams (exercise_id, division_id, name, poc, status, comments, readiness_weight, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, `+nextTeamOrder+`)
+		RETURNING id, sort_order, status_changed_at, status_confirmed_at
 	`
 
 	// Set default status if empty
@@ -533,22 +571,34 @@ func (r *PostgresRepository) CreateTeamDB(team models.Team) models.Team {
 		team.Status = "green"
 	}
 
-	err := r.db.QueryRow(query, team.ExerciseID, team.DivisionID, team.Name, team.POC, team.Status, team.Comments).Scan(&team.ID)
+	err := r.db.QueryRowContext(ctx, query, team.ExerciseID, team.DivisionID, team.Name, team.POC, team.Status, team.Comments, team.ReadinessWeight).Scan(&team.ID, &team.SortOrder, &team.StatusChangedAt, &team.StatusConfirmedAt)
 	if err != nil {
-		log.Printf("Error creating team: %v", err)
+		slog.ErrorContext(ctx, "Error creating team", "error", err)
 		return team
 	}
+	if err := recordStatusPeriod(ctx, r.db, team.ID); err != nil {
+		slog.ErrorContext(ctx, "Error recording team status", "team_id", team.ID, "error", err)
+	}
 
+	changes.Emit(ctx, r.db, changes.TeamCreated, team.ExerciseID, team)
 	return team
 }
 
-// updateTeam updates a team in the database
-func (r *PostgresRepository) updateTeam(tx *sql.Tx, team models.Team) error {
+// updateTeam updates a team of team.ExerciseID in the database. Rows whose
+// values are unchanged are left alone so that saving a whole exercise only
+// emits changes for the teams that were actually edited.
+func (r *PostgresRepository) updateTeam(ctx context.Context, tx *sql.Tx, team models.Team) error {
 	query := `
-		UPDATE teams 
+		UPDATE teams t
 		SET poc = $2, status = $3, status_start = $4, status_end = $5, 
-		    comments = $6, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		    comments = $6, updated_at = CURRENT_TIMESTAMP,
+		    status_changed_at = CASE WHEN old.status = $3 THEN t.status_changed_at ELSE CURRENT_TIMESTAMP END,
+		    status_confirmed_at = CURRENT_TIMESTAMP, stale_flagged_at = NULL
+		FROM (SELECT id, COALESCE(status, 'green') AS status FROM teams WHERE id = $1) old
+		WHERE t.id = old.id AND t.exercise_id = $7
+		  AND (t.poc, t.status, t.status_start, t.status_end, t.comments)
+		      IS DISTINCT FROM ($2, $3, $4::timestamp, $5::timestamp, $6)
+		RETURNING old.status, t.exercise_id, t.division_id, t.name
 	`
 
 	var statusStart, statusEnd interface{}
@@ -563,23 +613,43 @@ func (r *PostgresRepository) updateTeam(tx *sql.Tx, team models.Team) error {
 		statusEnd = nil
 	}
 
-	_, err := tx.Exec(query, team.ID, team.POC, team.Status, statusStart, statusEnd, team.Comments)
-	return err
+	var previousStatus string
+	err := tx.QueryRowContext(ctx, query, team.ID, team.POC, team.Status, statusStart, statusEnd, team.Comments, team.ExerciseID).
+		Scan(&previousStatus, &team.ExerciseID, &team.DivisionID, &team.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	changes.Emit(ctx, tx, changes.TeamUpdated, team.ExerciseID, team)
+	if err := recordStatusPeriod(ctx, tx, team.ID); err != nil {
+		return err
+	}
+	if previousStatus != team.Status {
+		changes.Emit(ctx, tx, changes.TeamStatusChanged, team.ExerciseID, map[string]interface{}{
+			"team":            team,
+			"previous_status": previousStatus,
+		})
+	}
+
+	return nil
 }
 
 // InitializeDatabase initializes the database with sample data if empty
-func (r *PostgresRepository) InitializeDatabase() {
+func (r *PostgresRepository) InitializeDatabase(ctx context.Context) {
 	// Check if there are any exercises
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM exercises").Scan(&count)
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM exercises").Scan(&count)
 	if err != nil {
-		log.Printf("Error checking exercise count: %v", err)
+		slog.ErrorContext(ctx, "Error checking exercise count", "error", err)
 		return
 	}
 
 	// If no exercises exist, create initial data
 	if count == 0 {
-		log.Println("Initializing database with real exercise data...")
+		slog.InfoContext(ctx, "Initializing database with real exercise data...")
 		
 		// Create REFORPAC exercise
 		reforpac := models.Exercise{
@@ -590,7 +660,7 @@ func (r *PostgresRepository) InitializeDatabase() {
 			Priority:    "high",
 			Divisions: r.createStandardDivisions(),
 		}
-		r.CreateExerciseDB(reforpac)
+		r.CreateExerciseDB(ctx, reforpac)
 
 		// Create KEEN EDGE exercise  
 		keenEdgeDivisions := r.createStandardDivisions()
@@ -605,7 +675,7 @@ func (r *PostgresRepository) InitializeDatabase() {
 			ExerciseEventPOC: "Mike",
 			Divisions: keenEdgeDivisions,
 		}
-		r.CreateExerciseDB(keenEdge)
+		r.CreateExerciseDB(ctx, keenEdge)
 
 		// Create BALIKATAN exercise
 		balicatanDivisions := r.createStandardDivisions()
@@ -619,14 +689,14 @@ func (r *PostgresRepository) InitializeDatabase() {
 			Priority:    "low",
 			Divisions: balicatanDivisions,
 		}
-		r.CreateExerciseDB(balikatan)
+		r.CreateExerciseDB(ctx, balikatan)
 		
-		log.Println("Real exercise data created successfully")
+		slog.InfoContext(ctx, "Real exercise data created successfully")
 	}
 }
 
 // GetEventsForExercise gets all events for an exercise
-func (r *PostgresRepository) GetEventsForExercise(exerciseID int) []models.Event {
+func (r *PostgresRepository) GetEventsForExercise(ctx context.Context, exerciseID int) []models.Event {
 	query := `
 		SELECT id, exercise_id, name, start_date, end_date, type, priority, poc, status, description, location, created_at, updated_at
 		FROM events
@@ -634,9 +704,9 @@ func (r *PostgresRepository) GetEventsForExercise(exerciseID int) []models.Event
 		ORDER BY start_date, id
 	`
 
-	rows, err := r.db.Query(query, exerciseID)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID)
 	if err != nil {
-		log.Printf("Error fetching events: %v", err)
+		slog.ErrorContext(ctx, "Error fetching events", "error", err)
 		return []models.Event{}
 	}
 	defer rows.Close()
@@ -650,7 +720,7 @@ func (r *PostgresRepository) GetEventsForExercise(exerciseID int) []models.Event
 			&event.EndDate, &event.Type, &event.Priority, &poc, &event.Status, 
 			&description, &location, &event.CreatedAt, &event.UpdatedAt)
 		if err != nil {
-			log.Printf("Error scanning event: %v", err)
+			slog.ErrorContext(ctx, "Error scanning event", "error", err)
 			continue
 		}
 		
@@ -664,59 +734,102 @@ func (r *PostgresRepository) GetEventsForExercise(exerciseID int) []models.Event
 }
 
 // CreateEventDB creates a new event in the database
-func (r *PostgresRepository) CreateEventDB(event models.Event) models.Event {
+func (r *PostgresRepository) CreateEventDB(ctx context.Context, event models.Event) models.Event {
 	query := `
 		INSERT INTO events (exercise_id, name, start_date, end_date, type, priority, poc, status, description, location)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(query, event.ExerciseID, event.Name, event.StartDate, event.EndDate,
+	err := r.db.QueryRowContext(ctx, query, event.ExerciseID, event.Name, event.StartDate, event.EndDate,
 		event.Type, event.Priority, event.POC, event.Status, event.Description, event.Location).Scan(
 		&event.ID, &event.CreatedAt, &event.UpdatedAt)
 	if err != nil {
-		log.Printf("Error creating event: %v", err)
+		slog.ErrorContext(ctx, "Error creating event", "error", err)
 		return event
 	}
 
+	changes.Emit(ctx, r.db, changes.EventCreated, event.ExerciseID, event)
+
 	return event
 }
 
 // UpdateEventDB updates an event in the database
-func (r *PostgresRepository) UpdateEventDB(event models.Event) bool {
+func (r *PostgresRepository) UpdateEventDB(ctx context.Context, event models.Event) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
 	query := `
-		UPDATE events 
+		UPDATE events e
 		SET name = $2, start_date = $3, end_date = $4, type = $5, priority = $6, 
 		    poc = $7, status = $8, description = $9, location = $10, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+		FROM (SELECT id, start_date, end_date FROM events WHERE id = $1) old
+		WHERE e.id = old.id
+		RETURNING old.start_date, old.end_date, e.exercise_id, e.updated_at
 	`
 
-	result, err := r.db.Exec(query, event.ID, event.Name, event.StartDate, event.EndDate,
-		event.Type, event.Priority, event.POC, event.Status, event.Description, event.Location)
+	var previousStart, previousEnd time.Time
+	err = tx.QueryRowContext(ctx, query, event.ID, event.Name, event.StartDate, event.EndDate,
+		event.Type, event.Priority, event.POC, event.Status, event.Description, event.Location).
+		Scan(&previousStart, &previousEnd, &event.ExerciseID, &event.UpdatedAt)
 	if err != nil {
-		log.Printf("Error updating event: %v", err)
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error updating event", "error", err)
+		}
 		return false
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	changes.Emit(ctx, tx, changes.EventUpdated, event.ExerciseID, event)
+	if !previousStart.Equal(event.StartDate) || !previousEnd.Equal(event.EndDate) {
+		changes.Emit(ctx, tx, changes.EventRescheduled, event.ExerciseID, map[string]interface{}{
+			"event":               event,
+			"previous_start_date": previousStart,
+			"previous_end_date":   previousEnd,
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+
+	return true
 }
 
 // DeleteEventDB deletes an event from the database
-func (r *PostgresRepository) DeleteEventDB(id int) bool {
-	query := "DELETE FROM events WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+func (r *PostgresRepository) DeleteEventDB(ctx context.Context, id int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error deleting event: %v", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
 		return false
 	}
+	defer tx.Rollback()
 
-	rowsAffected, _ := result.RowsAffected()
-	return rowsAffected > 0
+	var exerciseID int
+	err = tx.QueryRowContext(ctx, "DELETE FROM events WHERE id = $1 RETURNING exercise_id", id).Scan(&exerciseID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error deleting event", "error", err)
+		}
+		return false
+	}
+
+	changes.Emit(ctx, tx, changes.EventDeleted, exerciseID, map[string]int{"id": id})
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+
+	return true
 }
 
 // GetExercisesByDivisionIDDB returns exercises that contain the specified division
-func (r *PostgresRepository) GetExercisesByDivisionIDDB(divisionID int) []models.Exercise {
+func (r *PostgresRepository) GetExercisesByDivisionIDDB(ctx context.Context, divisionID int) []models.Exercise {
 	query := `
 		SELECT DISTINCT e.id, e.name, e.start_date, e.end_date, e.description,
 		       COALESCE(e.priority, 'medium'), COALESCE(e.exercise_event_poc, ''), COALESCE(e.aoc_involvement, ''), COALESCE(e.srd_poc, ''), COALESCE(e.cpd_poc, '')
@@ -726,9 +839,9 @@ func (r *PostgresRepository) GetExercisesByDivisionIDDB(divisionID int) []models
 		ORDER BY e.start_date
 	`
 
-	rows, err := r.db.Query(query, divisionID)
+	rows, err := r.db.QueryContext(ctx, query, divisionID)
 	if err != nil {
-		log.Printf("Error fetching exercises by division ID: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercises by division ID", "error", err)
 		return []models.Exercise{}
 	}
 	defer rows.Close()
@@ -741,7 +854,7 @@ func (r *PostgresRepository) GetExercisesByDivisionIDDB(divisionID int) []models
 		err := rows.Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
 			&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 		if err != nil {
-			log.Printf("Error scanning exercise: %v", err)
+			slog.ErrorContext(ctx, "Error scanning exercise", "error", err)
 			continue
 		}
 
@@ -753,13 +866,13 @@ func (r *PostgresRepository) GetExercisesByDivisionIDDB(divisionID int) []models
 		ex.CPDPOC = cpdPoc.String
 
 		// Load divisions for this exercise
-		ex.Divisions = r.GetDivisionsForExercise(ex.ID)
+		ex.Divisions = r.GetDivisionsForExercise(ctx, ex.ID)
 
 		// Load tasked divisions
-		ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+		ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 
 		// Load events for this exercise
-		ex.Events = r.GetEventsForExercise(ex.ID)
+		ex.Events = r.GetEventsForExercise(ctx, ex.ID)
 
 		exercises = append(exercises, ex)
 	}
@@ -768,7 +881,7 @@ func (r *PostgresRepository) GetExercisesByDivisionIDDB(divisionID int) []models
 }
 
 // GetExercisesByTeamIDDB returns exercises that contain the specified team
-func (r *PostgresRepository) GetExercisesByTeamIDDB(teamID int) []models.Exercise {
+func (r *PostgresRepository) GetExercisesByTeamIDDB(ctx context.Context, teamID int) []models.Exercise {
 	query := `
 		SELECT DISTINCT e.id, e.name, e.start_date, e.end_date, e.description,
 		       COALESCE(e.priority, 'medium'), COALESCE(e.exercise_event_poc, ''), COALESCE(e.aoc_involvement, ''), COALESCE(e.srd_poc, ''), COALESCE(e.cpd_poc, '')
@@ -779,9 +892,9 @@ func (r *PostgresRepository) GetExercisesByTeamIDDB(teamID int) []models.Exercis
 		ORDER BY e.start_date
 	`
 
-	rows, err := r.db.Query(query, teamID)
+	rows, err := r.db.QueryContext(ctx, query, teamID)
 	if err != nil {
-		log.Printf("Error fetching exercises by team ID: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercises by team ID", "error", err)
 		return []models.Exercise{}
 	}
 	defer rows.Close()
@@ -794,7 +907,7 @@ func (r *PostgresRepository) GetExercisesByTeamIDDB(teamID int) []models.Exercis
 		err := rows.Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
 			&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 		if err != nil {
-			log.Printf("Error scanning exercise: %v", err)
+			slog.ErrorContext(ctx, "Error scanning exercise", "error", err)
 			continue
 		}
 
@@ -806,13 +919,13 @@ func (r *PostgresRepository) GetExercisesByTeamIDDB(teamID int) []models.Exercis
 		ex.CPDPOC = cpdPoc.String
 
 		// Load divisions for this exercise
-		ex.Divisions = r.GetDivisionsForExercise(ex.ID)
+		ex.Divisions = r.GetDivisionsForExercise(ctx, ex.ID)
 
 		// Load tasked divisions
-		ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+		ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 
 		// Load events for this exercise
-		ex.Events = r.GetEventsForExercise(ex.ID)
+		ex.Events = r.GetEventsForExercise(ctx, ex.ID)
 
 		exercises = append(exercises, ex)
 	}
@@ -820,35 +933,35 @@ func (r *PostgresRepository) GetExercisesByTeamIDDB(teamID int) []models.Exercis
 	return exercises
 }
 // DeleteDivisionDB deletes a division and all its teams from the database
-func (r *PostgresRepository) DeleteDivisionDB(id int) bool {
-	tx, err := r.db.Begin()
+func (r *PostgresRepository) DeleteDivisionDB(ctx context.Context, id int) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error starting transaction: %v", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
 		return false
 	}
 	defer tx.Rollback()
 
 	// First delete all teams in this division
-	_, err = tx.Exec("DELETE FROM teams WHERE division_id = $1", id)
+	_, err = tx.ExecContext(ctx, "DELETE FROM teams WHERE division_id = $1", id)
 	if err != nil {
-		log.Printf("Error deleting teams for division %d: %v", id, err)
+		slog.ErrorContext(ctx, "Error deleting teams for division", "division_id", id, "error", err)
 		return false
 	}
 
 	// Then delete the division itself
-	result, err := tx.Exec("DELETE FROM divisions WHERE id = $1", id)
+	var exerciseID int
+	err = tx.QueryRowContext(ctx, "DELETE FROM divisions WHERE id = $1 RETURNING exercise_id", id).Scan(&exerciseID)
 	if err != nil {
-		log.Printf("Error deleting division %d: %v", id, err)
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error deleting division", "division_id", id, "error", err)
+		}
 		return false
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return false
-	}
+	changes.Emit(ctx, tx, changes.DivisionDeleted, exerciseID, map[string]int{"id": id})
 
 	if err = tx.Commit(); err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return false
 	}
 
@@ -856,48 +969,48 @@ func (r *PostgresRepository) DeleteDivisionDB(id int) bool {
 }
 
 // DeleteTeamDB deletes a team from the database
-func (r *PostgresRepository) DeleteTeamDB(id int) bool {
+func (r *PostgresRepository) DeleteTeamDB(ctx context.Context, id int) bool {
 	// Also need to remove any task assignments for this team
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error starting transaction: %v", err)
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
 		return false
 	}
 	defer tx.Rollback()
 
 	// First, unassign all tasks from this team
-	_, err = tx.Exec("UPDATE tasks SET team_id = NULL WHERE team_id = $1", id)
+	_, err = tx.ExecContext(ctx, "UPDATE tasks SET team_id = NULL WHERE team_id = $1", id)
 	if err != nil {
-		log.Printf("Error unassigning tasks from team %d: %v", id, err)
+		slog.ErrorContext(ctx, "Error unassigning tasks from team", "team_id", id, "error", err)
 		return false
 	}
 
 	// Delete team from team_tasks junction table (if it exists)
 	// First check if the table exists
 	var tableExists bool
-	err = tx.QueryRow("SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'team_tasks')").Scan(&tableExists)
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'team_tasks')").Scan(&tableExists)
 	if err == nil && tableExists {
-		_, err = tx.Exec("DELETE FROM team_tasks WHERE team_id = $1", id)
+		_, err = tx.ExecContext(ctx, "DELETE FROM team_tasks WHERE team_id = $1", id)
 		if err != nil {
-			log.Printf("Error deleting from team_tasks table: %v", err)
+			slog.ErrorContext(ctx, "Error deleting from team_tasks table", "error", err)
 			return false
 		}
 	}
 
 	// Then delete the team itself
-	result, err := tx.Exec("DELETE FROM teams WHERE id = $1", id)
+	var exerciseID int
+	err = tx.QueryRowContext(ctx, "DELETE FROM teams WHERE id = $1 RETURNING exercise_id", id).Scan(&exerciseID)
 	if err != nil {
-		log.Printf("Error deleting team %d: %v", id, err)
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error deleting team", "team_id", id, "error", err)
+		}
 		return false
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return false
-	}
+	changes.Emit(ctx, tx, changes.TeamDeleted, exerciseID, map[string]int{"id": id})
 
 	if err = tx.Commit(); err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return false
 	}
 
@@ -905,8 +1018,8 @@ func (r *PostgresRepository) DeleteTeamDB(id int) bool {
 }
 
 // GetExercisesByDivisionNameDB returns exercises that contain a division with the specified name
-func (r *PostgresRepository) GetExercisesByDivisionNameDB(divisionName string) []models.Exercise {
-	log.Printf("DEBUG: GetExercisesByDivisionNameDB called with divisionName='%s'", divisionName)
+func (r *PostgresRepository) GetExercisesByDivisionNameDB(ctx context.Context, divisionName string) []models.Exercise {
+	slog.DebugContext(ctx, "GetExercisesByDivisionNameDB called", "division_name", divisionName)
 	query := `
 		SELECT DISTINCT e.id, e.name, e.start_date, e.end_date, e.description,
 		       COALESCE(e.priority, 'medium'), COALESCE(e.exercise_event_poc, ''), COALESCE(e.aoc_involvement, ''), COALESCE(e.srd_poc, ''), COALESCE(e.cpd_poc, '')
@@ -916,9 +1029,9 @@ func (r *PostgresRepository) GetExercisesByDivisionNameDB(divisionName string) [
 		ORDER BY e.start_date
 	`
 
-	rows, err := r.db.Query(query, divisionName)
+	rows, err := r.db.QueryContext(ctx, query, divisionName)
 	if err != nil {
-		log.Printf("Error fetching exercises by division name: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercises by division name", "error", err)
 		return []models.Exercise{}
 	}
 	defer rows.Close()
@@ -931,7 +1044,7 @@ func (r *PostgresRepository) GetExercisesByDivisionNameDB(divisionName string) [
 		err := rows.Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
 			&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 		if err != nil {
-			log.Printf("Error scanning exercise: %v", err)
+			slog.ErrorContext(ctx, "Error scanning exercise", "error", err)
 			continue
 		}
 
@@ -943,14 +1056,14 @@ func (r *PostgresRepository) GetExercisesByDivisionNameDB(divisionName string) [
 		ex.CPDPOC = cpdPoc.String
 
 		// Load only the divisions that match the filter criteria
-		ex.Divisions = r.GetDivisionsForExerciseByName(ex.ID, divisionName)
-		log.Printf("DEBUG: Exercise %s (ID: %d) has %d matching divisions for name '%s'", ex.Name, ex.ID, len(ex.Divisions), divisionName)
+		ex.Divisions = r.GetDivisionsForExerciseByName(ctx, ex.ID, divisionName)
+		slog.DebugContext(ctx, "Exercise has matching divisions", "exercise", ex.Name, "exercise_id", ex.ID, "divisions", len(ex.Divisions), "division_name", divisionName)
 
 		// Load tasked divisions
-		ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+		ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 
 		// Load events for this exercise
-		ex.Events = r.GetEventsForExercise(ex.ID)
+		ex.Events = r.GetEventsForExercise(ctx, ex.ID)
 
 		exercises = append(exercises, ex)
 	}
@@ -959,7 +1072,7 @@ func (r *PostgresRepository) GetExercisesByDivisionNameDB(divisionName string) [
 }
 
 // GetExercisesByTeamNameDB returns exercises that contain a team with the specified name
-func (r *PostgresRepository) GetExercisesByTeamNameDB(teamName string) []models.Exercise {
+func (r *PostgresRepository) GetExercisesByTeamNameDB(ctx context.Context, teamName string) []models.Exercise {
 	query := `
 		SELECT DISTINCT e.id, e.name, e.start_date, e.end_date, e.description,
 		       COALESCE(e.priority, 'medium'), COALESCE(e.exercise_event_poc, ''), COALESCE(e.aoc_involvement, ''), COALESCE(e.srd_poc, ''), COALESCE(e.cpd_poc, '')
@@ -970,9 +1083,9 @@ func (r *PostgresRepository) GetExercisesByTeamNameDB(teamName string) []models.
 		ORDER BY e.start_date
 	`
 
-	rows, err := r.db.Query(query, teamName)
+	rows, err := r.db.QueryContext(ctx, query, teamName)
 	if err != nil {
-		log.Printf("Error fetching exercises by team name: %v", err)
+		slog.ErrorContext(ctx, "Error fetching exercises by team name", "error", err)
 		return []models.Exercise{}
 	}
 	defer rows.Close()
@@ -985,7 +1098,7 @@ func (r *PostgresRepository) GetExercisesByTeamNameDB(teamName string) []models.
 		err := rows.Scan(&ex.ID, &ex.Name, &ex.StartDate, &ex.EndDate,
 			&desc, &priority, &eventPoc, &aoc, &srdPoc, &cpdPoc)
 		if err != nil {
-			log.Printf("Error scanning exercise: %v", err)
+			slog.ErrorContext(ctx, "Error scanning exercise", "error", err)
 			continue
 		}
 
@@ -997,13 +1110,13 @@ func (r *PostgresRepository) GetExercisesByTeamNameDB(teamName string) []models.
 		ex.CPDPOC = cpdPoc.String
 
 		// Load divisions for this exercise, filtered by team name
-		ex.Divisions = r.GetDivisionsForExerciseByTeamName(ex.ID, teamName)
+		ex.Divisions = r.GetDivisionsForExerciseByTeamName(ctx, ex.ID, teamName)
 
 		// Load tasked divisions
-		ex.TaskedDivisions = r.GetTaskedDivisions(ex.ID)
+		ex.TaskedDivisions = r.GetTaskedDivisions(ctx, ex.ID)
 
 		// Load events for this exercise
-		ex.Events = r.GetEventsForExercise(ex.ID)
+		ex.Events = r.GetEventsForExercise(ctx, ex.ID)
 
 		exercises = append(exercises, ex)
 	}
@@ -1012,18 +1125,18 @@ func (r *PostgresRepository) GetExercisesByTeamNameDB(teamName string) []models.
 }
 
 // GetDivisionsForExerciseByTeamName returns divisions for an exercise that contain a team with the specified name
-func (r *PostgresRepository) GetDivisionsForExerciseByTeamName(exerciseID int, teamName string) []models.Division {
+func (r *PostgresRepository) GetDivisionsForExerciseByTeamName(ctx context.Context, exerciseID int, teamName string) []models.Division {
 	query := `
-		SELECT DISTINCT d.id, d.name, COALESCE(d.learning_objectives, '')
+		SELECT DISTINCT d.id, d.name, COALESCE(d.learning_objectives, ''), COALESCE(d.poc, ''), d.sort_order
 		FROM divisions d
 		INNER JOIN teams t ON d.id = t.division_id
 		WHERE d.exercise_id = $1 AND t.name = $2
-		ORDER BY d.id
+		ORDER BY d.sort_order, d.id
 	`
 
-	rows, err := r.db.Query(query, exerciseID, teamName)
+	rows, err := r.db.QueryContext(ctx, query, exerciseID, teamName)
 	if err != nil {
-		log.Printf("Error fetching divisions for exercise %d with team %s: %v", exerciseID, teamName, err)
+		slog.ErrorContext(ctx, "Error fetching divisions by team name", "exercise_id", exerciseID, "team_name", teamName, "error", err)
 		return []models.Division{}
 	}
 	defer rows.Close()
@@ -1033,9 +1146,9 @@ func (r *PostgresRepository) GetDivisionsForExerciseByTeamName(exerciseID int, t
 		var division models.Division
 		var learningObjectives sql.NullString
 
-		err := rows.Scan(&division.ID, &division.Name, &learningObjectives)
+		err := rows.Scan(&division.ID, &division.Name, &learningObjectives, &division.POC, &division.SortOrder)
 		if err != nil {
-			log.Printf("Error scanning division: %v", err)
+			slog.ErrorContext(ctx, "Error scanning division", "error", err)
 			continue
 		}
 
@@ -1043,7 +1156,7 @@ func (r *PostgresRepository) GetDivisionsForExerciseByTeamName(exerciseID int, t
 		division.LearningObjectives = learningObjectives.String
 
 		// Load teams for this division, filtered by team name
-		division.Teams = r.GetTeamsForDivisionByName(division.ID, teamName)
+		division.Teams = r.GetTeamsForDivisionByName(ctx, division.ID, teamName)
 
 		divisions = append(divisions, division)
 	}
@@ -1052,19 +1165,20 @@ func (r *PostgresRepository) GetDivisionsForExerciseByTeamName(exerciseID int, t
 }
 
 // GetTeamsForDivisionByName returns teams for a division filtered by team name
-func (r *PostgresRepository) GetTeamsForDivisionByName(divisionID int, teamName string) []models.Team {
+func (r *PostgresRepository) GetTeamsForDivisionByName(ctx context.Context, divisionID int, teamName string) []models.Team {
 	query := `
 		SELECT id, name, COALESCE(poc, ''), COALESCE(status, 'green'),
 		       COALESCE(status_start, CURRENT_TIMESTAMP), COALESCE(status_end, CURRENT_TIMESTAMP),
-		       COALESCE(comments, ''), exercise_id
+		       COALESCE(comments, ''), exercise_id, sort_order, readiness_weight,
+		       COALESCE(status_changed_at, created_at), COALESCE(status_confirmed_at, created_at), stale_flagged_at IS NOT NULL
 		FROM teams
 		WHERE division_id = $1 AND name = $2
-		ORDER BY id
+		ORDER BY sort_order, id
 	`
 
-	rows, err := r.db.Query(query, divisionID, teamName)
+	rows, err := r.db.QueryContext(ctx, query, divisionID, teamName)
 	if err != nil {
-		log.Printf("Error fetching teams for division %d with name %s: %v", divisionID, teamName, err)
+		slog.ErrorContext(ctx, "Error fetching teams by name", "division_id", divisionID, "team_name", teamName, "error", err)
 		return []models.Team{}
 	}
 	defer rows.Close()
@@ -1075,9 +1189,10 @@ func (r *PostgresRepository) GetTeamsForDivisionByName(divisionID int, teamName
 		var poc, status, comments sql.NullString
 
 		err := rows.Scan(&team.ID, &team.Name, &poc, &status,
-			&team.StatusStart, &team.StatusEnd, &comments, &team.ExerciseID)
+			&team.StatusStart, &team.StatusEnd, &comments, &team.ExerciseID, &team.SortOrder, &team.ReadinessWeight,
+			&team.StatusChangedAt, &team.StatusConfirmedAt, &team.StatusStale)
 		if err != nil {
-			log.Printf("Error scanning team: %v", err)
+			slog.ErrorContext(ctx, "Error scanning team", "error", err)
 			continue
 		}
 
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetSitrepTemplateDB returns the templates an exercise's situation reports
+// are rendered with; either is empty when the exercise uses the built-in one
+func (r *PostgresRepository) GetSitrepTemplateDB(ctx context.Context, exerciseID int) (models.SitrepTemplate, bool) {
+	tmpl := models.SitrepTemplate{ExerciseID: exerciseID}
+	err := r.db.QueryRowContext(ctx, "SELECT markdown, html FROM sitrep_templates WHERE exercise_id = $1", exerciseID).
+		Scan(&tmpl.Markdown, &tmpl.HTML)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "Error fetching SITREP template", "exercise_id", exerciseID, "error", err)
+		return tmpl, false
+	}
+	return tmpl, true
+}
+
+// SetSitrepTemplateDB replaces an exercise's situation report templates
+func (r *PostgresRepository) SetSitrepTemplateDB(ctx context.Context, tmpl models.SitrepTemplate) bool {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sitrep_templates (exercise_id, markdown, html)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (exercise_id) DO UPDATE
+		SET markdown = EXCLUDED.markdown, html = EXCLUDED.html, updated_at = CURRENT_TIMESTAMP`,
+		tmpl.ExerciseID, tmpl.Markdown, tmpl.HTML)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error saving SITREP template", "exercise_id", tmpl.ExerciseID, "error", err)
+		return false
+	}
+	changes.Emit(ctx, tx, changes.SitrepTemplateUpdated, tmpl.ExerciseID, tmpl)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return false
+	}
+	return true
+}
+
+// GetOpenTasksDueDB returns an exercise's tasks that are not completed and
+// are due before the given time, soonest due first, with the names of the
+// teams they are assigned to
+func (r *PostgresRepository) GetOpenTasksDueDB(ctx context.Context, exerciseID int, before time.Time) ([]models.Task, bool) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.exercise_id, t.name, COALESCE(t.description, ''), COALESCE(t.status, 'pending'),
+		       t.due_date, COALESCE(t.assigned_to, ''), t.created_at, t.updated_at,
+		       ARRAY(SELECT tm.name FROM task_teams tt JOIN teams tm ON tm.id = tt.team_id
+		             WHERE tt.task_id = t.id ORDER BY tm.name)
+		FROM tasks t
+		WHERE t.exercise_id = $1 AND COALESCE(t.status, 'pending') <> 'completed'
+		  AND t.due_date IS NOT NULL AND t.due_date < $2
+		ORDER BY t.due_date, t.id`, exerciseID, before)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching due tasks", "exercise_id", exerciseID, "error", err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var task models.Task
+		var dueDate time.Time
+		var teamNames []string
+		err := rows.Scan(&task.ID, &task.ExerciseID, &task.Name, &task.Description, &task.Status,
+			&dueDate, &task.AssignedTo, &task.CreatedAt, &task.UpdatedAt, pq.Array(&teamNames))
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning due task", "error", err)
+			return nil, false
+		}
+		task.DueDate = &dueDate
+		task.Teams = []models.Team{}
+		for _, name := range teamNames {
+			task.Teams = append(task.Teams, models.Team{Name: name, ExerciseID: exerciseID})
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err() == nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+	"time"
+)
+
+// expiredWindow selects teams whose status window has ended. An end at
+// midnight is a whole day and lasts through that day; any other end is the
+// moment the window closes.
+const expiredWindow = `status_end IS NOT NULL
+	AND CASE WHEN status_end = date_trunc('day', status_end) THEN status_end + INTERVAL '1 day' ELSE status_end END <= CURRENT_TIMESTAMP`
+
+// ExpireTeamStatusesDB ends every status window that has passed: the team
+// takes the revertTo status and its window is cleared. Each team records a
+// status expiry with what it had before. It returns how many teams expired.
+func (r *PostgresRepository) ExpireTeamStatusesDB(ctx context.Context, revertTo string) (int, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return 0, false
+	}
+	defer tx.Rollback()
+
+	type expired struct {
+		id                     int
+		status                 string
+		statusStart, statusEnd sql.NullTime
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, COALESCE(status, 'green'), status_start, status_end
+		FROM teams
+		WHERE `+expiredWindow+`
+		FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding expired statuses", "error", err)
+		return 0, false
+	}
+	var teams []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.id, &e.status, &e.statusStart, &e.statusEnd); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "Error scanning expired status", "error", err)
+			return 0, false
+		}
+		teams = append(teams, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error finding expired statuses", "error", err)
+		return 0, false
+	}
+
+	for _, e := range teams {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE teams
+			SET status = $2, status_start = NULL, status_end = NULL, updated_at = CURRENT_TIMESTAMP,
+			    status_changed_at = CASE WHEN $2 = $3 THEN status_changed_at ELSE CURRENT_TIMESTAMP END
+			WHERE id = $1`, e.id, revertTo, e.status)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error expiring status", "team_id", e.id, "error", err)
+			return 0, false
+		}
+		if err := recordStatusPeriod(ctx, tx, e.id); err != nil {
+			slog.ErrorContext(ctx, "Error recording team status", "team_id", e.id, "error", err)
+			return 0, false
+		}
+		team, _ := getTeam(ctx, tx, e.id)
+		changes.Emit(ctx, tx, changes.TeamStatusExpired, team.ExerciseID, map[string]interface{}{
+			"team":                  team,
+			"previous_status":       e.status,
+			"previous_status_start": e.statusStart.Time,
+			"previous_status_end":   e.statusEnd.Time,
+		})
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return 0, false
+	}
+	return len(teams), true
+}
+
+// FlagStaleTeamsDB flags the teams of running exercises whose status has not
+// been set or confirmed within staleAfter. A team is flagged, and records the
+// change, once until its status is next confirmed. It returns how many teams
+// were flagged.
+func (r *PostgresRepository) FlagStaleTeamsDB(ctx context.Context, staleAfter time.Duration) (int, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return 0, false
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE teams t
+		SET stale_flagged_at = CURRENT_TIMESTAMP
+		FROM exercises e
+		WHERE e.id = t.exercise_id
+		  AND e.start_date <= CURRENT_TIMESTAMP AND e.end_date + INTERVAL '1 day' > CURRENT_TIMESTAMP
+		  AND t.stale_flagged_at IS NULL
+		  AND COALESCE(t.status_confirmed_at, t.created_at) < CURRENT_TIMESTAMP - make_interval(secs => $1)
+		RETURNING t.id`, staleAfter.Seconds())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error flagging stale statuses", "error", err)
+		return 0, false
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "Error scanning stale status", "error", err)
+			return 0, false
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error flagging stale statuses", "error", err)
+		return 0, false
+	}
+
+	for _, id := range ids {
+		team, _ := getTeam(ctx, tx, id)
+		changes.Emit(ctx, tx, changes.TeamStatusStale, team.ExerciseID, team)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return 0, false
+	}
+	return len(ids), true
+}
+
+// ConfirmTeamStatusDB records that a team's status is still accurate and
+// clears its stale flag
+func (r *PostgresRepository) ConfirmTeamStatusDB(ctx context.Context, id int) (models.Team, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return models.Team{}, false
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE teams SET status_confirmed_at = CURRENT_TIMESTAMP, stale_flagged_at = NULL WHERE id = $1", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error confirming team status", "team_id", id, "error", err)
+		return models.Team{}, false
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.Team{}, false
+	}
+
+	team, found := getTeam(ctx, tx, id)
+	if !found {
+		return team, false
+	}
+	changes.Emit(ctx, tx, changes.TeamStatusConfirmed, team.ExerciseID, team)
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return models.Team{}, false
+	}
+	return team, true
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+)
+
+// recordStatusPeriod brings a team's status history up to date with its
+// stored status and status window: when either differs from the open period,
+// that period ends now and a new one starts. Call it after every write that
+// can change a team's status or window.
+func recordStatusPeriod(ctx context.Context, q changes.Execer, teamID int) error {
+	_, err := q.ExecContext(ctx, `
+		WITH cur AS (
+			SELECT id, exercise_id, COALESCE(status, 'green') AS status, status_start, status_end
+			FROM teams WHERE id = $1
+		), closed AS (
+			UPDATE team_status_periods p
+			SET ended_at = CURRENT_TIMESTAMP
+			FROM cur
+			WHERE p.team_id = cur.id AND p.ended_at IS NULL
+			  AND (p.status, p.status_start, p.status_end) IS DISTINCT FROM (cur.status, cur.status_start, cur.status_end)
+			RETURNING p.id
+		)
+		INSERT INTO team_status_periods (team_id, exercise_id, status, status_start, status_end, started_at)
+		SELECT id, exercise_id, status, status_start, status_end, CURRENT_TIMESTAMP
+		FROM cur
+		WHERE NOT EXISTS (
+			SELECT 1 FROM team_status_periods p
+			WHERE p.team_id = cur.id AND p.ended_at IS NULL
+			  AND (p.status, p.status_start, p.status_end) IS NOT DISTINCT FROM (cur.status, cur.status_start, cur.status_end))`, teamID)
+	return err
+}
+
+// GetStatusPeriodsDB returns the status history of an exercise's teams, each
+// team's periods in order
+func (r *PostgresRepository) GetStatusPeriodsDB(ctx context.Context, exerciseID int) ([]models.StatusPeriod, bool) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT team_id, status, status_start, status_end, started_at, ended_at
+		FROM team_status_periods
+		WHERE exercise_id = $1
+		ORDER BY team_id, started_at, id`, exerciseID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching status periods", "exercise_id", exerciseID, "error", err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	periods := []models.StatusPeriod{}
+	for rows.Next() {
+		var period models.StatusPeriod
+		var statusStart, statusEnd, endedAt sql.NullTime
+		if err := rows.Scan(&period.TeamID, &period.Status, &statusStart, &statusEnd, &period.StartedAt, &endedAt); err != nil {
+			slog.ErrorContext(ctx, "Error scanning status period", "error", err)
+			return nil, false
+		}
+		period.StatusStart, period.StatusEnd = statusStart.Time, statusEnd.Time
+		if endedAt.Valid {
+			period.EndedAt = &endedAt.Time
+		}
+		periods = append(periods, period)
+	}
+	return periods, rows.Err() == nil
+}
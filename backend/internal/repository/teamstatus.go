@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const teamColumnList = `id, exercise_id, division_id, name, COALESCE(poc, ''), COALESCE(status, 'green'),
+	status_start, status_end, COALESCE(comments, ''), sort_order, readiness_weight,
+	COALESCE(status_changed_at, created_at), COALESCE(status_confirmed_at, created_at), stale_flagged_at IS NOT NULL`
+
+// queryTeams returns the teams selected by where, in division order
+func (r *PostgresRepository) queryTeams(ctx context.Context, where string, args ...interface{}) ([]models.Team, bool) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+teamColumnList+`
+		FROM teams
+		`+where+`
+		ORDER BY exercise_id, (SELECT sort_order FROM divisions WHERE id = teams.division_id), division_id, sort_order, id`, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching teams", "error", err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var team models.Team
+		var statusStart, statusEnd sql.NullTime
+		err := rows.Scan(&team.ID, &team.ExerciseID, &team.DivisionID, &team.Name, &team.POC, &team.Status,
+			&statusStart, &statusEnd, &team.Comments, &team.SortOrder, &team.ReadinessWeight,
+			&team.StatusChangedAt, &team.StatusConfirmedAt, &team.StatusStale)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning team", "error", err)
+			return nil, false
+		}
+		team.StatusStart = statusStart.Time
+		team.StatusEnd = statusEnd.Time
+		teams = append(teams, team)
+	}
+	return teams, rows.Err() == nil
+}
+
+// GetTeamsByIDsDB returns the teams with the given IDs that exist
+func (r *PostgresRepository) GetTeamsByIDsDB(ctx context.Context, ids []int) ([]models.Team, bool) {
+	return r.queryTeams(ctx, "WHERE id = ANY($1)", pq.Array(ids))
+}
+
+// GetTeamsForExerciseDB returns every team of an exercise
+func (r *PostgresRepository) GetTeamsForExerciseDB(ctx context.Context, exerciseID int) ([]models.Team, bool) {
+	return r.queryTeams(ctx, "WHERE exercise_id = $1", exerciseID)
+}
+
+// SetTeamStatusesDB writes the given fields of several teams in one
+// transaction, so either every team is updated or none is. Each team records
+// a single change: a status change when its status differs from the stored
+// one, otherwise an update. It returns each team's previous status.
+func (r *PostgresRepository) SetTeamStatusesDB(ctx context.Context, teams []models.Team, fields []string) (map[int]string, bool) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting transaction", "error", err)
+		return nil, false
+	}
+	defer tx.Rollback()
+
+	previous := map[int]string{}
+	for _, team := range teams {
+		previousStatus, found, err := patchTeam(ctx, tx, team, fields)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error updating team status", "team_id", team.ID, "error", err)
+			return nil, false
+		}
+		if !found {
+			slog.WarnContext(ctx, "Team disappeared during bulk status update", "team_id", team.ID)
+			return nil, false
+		}
+		previous[team.ID] = previousStatus
+
+		if previousStatus != team.Status {
+			changes.Emit(ctx, tx, changes.TeamStatusChanged, team.ExerciseID, map[string]interface{}{
+				"team":            team,
+				"previous_status": previousStatus,
+			})
+		} else {
+			changes.Emit(ctx, tx, changes.TeamUpdated, team.ExerciseID, team)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "Error committing transaction", "error", err)
+		return nil, false
+	}
+	return previous, true
+}
@@ -0,0 +1,56 @@
+// Package scheduler runs periodic background jobs. Each job runs once when
+// the scheduler starts and then at its interval until the context is
+// cancelled. A failed run is logged and counted, and the job carries on at
+// its next interval.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"srd-calendar-project/backend/internal/metrics"
+	"sync"
+	"time"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+var jobs []job
+
+// Add registers a job. Jobs added after Start are not run.
+func Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	jobs = append(jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start runs every registered job in its own goroutine until ctx is
+// cancelled
+func Start(ctx context.Context, wg *sync.WaitGroup) {
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+
+			for {
+				err := j.run(ctx)
+				switch {
+				case err == nil:
+					metrics.Jobs.Inc(j.name, "success")
+				case ctx.Err() == nil:
+					slog.ErrorContext(ctx, "Background job failed", "job", j.name, "error", err)
+					metrics.Jobs.Inc(j.name, "error")
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(j)
+	}
+}
@@ -0,0 +1,332 @@
+// Package sitrep builds an exercise's daily situation report and renders it
+// as Markdown or HTML.
+//
+// A report covers one day as of a moment: now for today, the end of the day
+// for a past day and its start for a future one. It lists the division and
+// team statuses in effect that day (see package readiness), the status
+// changes in the 24 hours before the moment, the day's and the next day's
+// events, and the open tasks that are overdue or due within the configured
+// interval.
+//
+// Reports are rendered with Go templates, text/template for Markdown and
+// html/template for HTML, given the report as data. Each exercise may
+// replace the built-in templates with its own.
+package sitrep
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/models"
+	"srd-calendar-project/backend/internal/readiness"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Formats lists the formats a report renders to besides JSON
+var Formats = []string{"markdown", "html"}
+
+var settings = config.Default().Sitrep
+
+// Configure sets the due-soon interval. Call it once at startup.
+func Configure(cfg config.SitrepConfig) {
+	settings = cfg
+}
+
+// DueSoon returns how far ahead open tasks count as due soon
+func DueSoon() time.Duration {
+	return time.Duration(settings.DueSoon)
+}
+
+// AsOf returns the moment a report on day is taken at
+func AsOf(day, now time.Time) time.Time {
+	day = readiness.Day(day)
+	switch {
+	case now.Before(day):
+		return day
+	case now.Before(day.AddDate(0, 0, 1)):
+		return now
+	default:
+		return day.AddDate(0, 0, 1)
+	}
+}
+
+// onDay reports whether an event runs on day. An end at midnight lasts
+// through that day.
+func onDay(event models.Event, day time.Time) bool {
+	return event.StartDate.Before(day.AddDate(0, 0, 1)) && !event.EndDate.Before(day)
+}
+
+// Build assembles the report on day. tasks are the exercise's open tasks due
+// before the end of the due-soon interval.
+func Build(exercise models.Exercise, periods []models.StatusPeriod, events []models.Event, tasks []models.Task, day, now time.Time) models.Sitrep {
+	day = readiness.Day(day)
+	asOf := AsOf(day, now)
+	rollup := readiness.Exercise(exercise, day)
+	report := models.Sitrep{
+		ExerciseID:     exercise.ID,
+		Name:           exercise.Name,
+		Date:           day.Format("2006-01-02"),
+		Tomorrow:       day.AddDate(0, 0, 1).Format("2006-01-02"),
+		AsOf:           asOf,
+		GeneratedAt:    now,
+		Readiness:      rollup.Readiness,
+		Divisions:      []models.SitrepDivision{},
+		StatusChanges:  []models.SitrepStatusChange{},
+		EventsToday:    []models.Event{},
+		EventsTomorrow: []models.Event{},
+		OverdueTasks:   []models.Task{},
+		DueSoonTasks:   []models.Task{},
+	}
+
+	byTeam := map[int][]models.StatusPeriod{}
+	for _, period := range periods {
+		byTeam[period.TeamID] = append(byTeam[period.TeamID], period)
+	}
+	since := asOf.Add(-24 * time.Hour)
+	for i, division := range exercise.Divisions {
+		sd := models.SitrepDivision{
+			DivisionID: division.ID,
+			Name:       division.Name,
+			POC:        division.POC,
+			Readiness:  rollup.Divisions[i].Readiness,
+			Teams:      []models.SitrepTeam{},
+		}
+		for _, team := range division.Teams {
+			sd.Teams = append(sd.Teams, models.SitrepTeam{
+				TeamID:      team.ID,
+				Name:        team.Name,
+				Status:      readiness.TeamStatus(team, day),
+				StatusStart: team.StatusStart,
+				StatusEnd:   team.StatusEnd,
+				StatusSince: team.StatusChangedAt,
+				POC:         team.POC,
+				Comments:    team.Comments,
+			})
+
+			history := byTeam[team.ID]
+			for j := 1; j < len(history); j++ {
+				changedAt := history[j].StartedAt
+				if !changedAt.After(since) || changedAt.After(asOf) || history[j].Status == history[j-1].Status {
+					continue
+				}
+				report.StatusChanges = append(report.StatusChanges, models.SitrepStatusChange{
+					TeamID:         team.ID,
+					TeamName:       team.Name,
+					DivisionName:   division.Name,
+					PreviousStatus: history[j-1].Status,
+					Status:         history[j].Status,
+					ChangedAt:      changedAt,
+				})
+			}
+		}
+		report.Divisions = append(report.Divisions, sd)
+	}
+	sort.SliceStable(report.StatusChanges, func(i, j int) bool {
+		return report.StatusChanges[i].ChangedAt.Before(report.StatusChanges[j].ChangedAt)
+	})
+
+	tomorrow := day.AddDate(0, 0, 1)
+	for _, event := range events {
+		if onDay(event, day) {
+			report.EventsToday = append(report.EventsToday, event)
+		}
+		if onDay(event, tomorrow) {
+			report.EventsTomorrow = append(report.EventsTomorrow, event)
+		}
+	}
+	for _, list := range [][]models.Event{report.EventsToday, report.EventsTomorrow} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].StartDate.Before(list[j].StartDate) })
+	}
+
+	dueBy := asOf.Add(DueSoon())
+	for _, task := range tasks {
+		if task.Status == "completed" || task.DueDate == nil {
+			continue
+		}
+		switch {
+		case task.DueDate.Before(asOf):
+			report.OverdueTasks = append(report.OverdueTasks, task)
+		case task.DueDate.Before(dueBy):
+			report.DueSoonTasks = append(report.DueSoonTasks, task)
+		}
+	}
+	return report
+}
+
+// when shows a time as a date when it is midnight UTC, which is how whole
+// days are stored, and as a date and time otherwise
+func when(t time.Time) string {
+	t = t.UTC()
+	if t.IsZero() {
+		return ""
+	}
+	if t.Equal(readiness.Day(t)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04Z")
+}
+
+// cell makes text safe for a Markdown table cell
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// teamNames lists the names of teams, comma-separated
+func teamNames(teams []models.Team) string {
+	names := make([]string, len(teams))
+	for i, team := range teams {
+		names[i] = team.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// funcs are the functions templates may call
+var funcs = map[string]interface{}{
+	"when":  when,
+	"upper": strings.ToUpper,
+	"cell":  cell,
+	"teams": teamNames,
+}
+
+// Source returns the template an exercise renders format with: its own, or
+// the built-in one
+func Source(tmpl models.SitrepTemplate, format string) string {
+	switch format {
+	case "markdown":
+		if tmpl.Markdown != "" {
+			return tmpl.Markdown
+		}
+		return DefaultMarkdown
+	case "html":
+		if tmpl.HTML != "" {
+			return tmpl.HTML
+		}
+		return DefaultHTML
+	}
+	return ""
+}
+
+// Render renders a report to format with the exercise's template for it
+func Render(tmpl models.SitrepTemplate, format string, report models.Sitrep) ([]byte, error) {
+	var out bytes.Buffer
+	switch format {
+	case "markdown":
+		t, err := texttemplate.New("sitrep").Funcs(funcs).Parse(Source(tmpl, format))
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&out, report); err != nil {
+			return nil, err
+		}
+	case "html":
+		t, err := htmltemplate.New("sitrep").Funcs(funcs).Parse(Source(tmpl, format))
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(&out, report); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return out.Bytes(), nil
+}
+
+// DefaultMarkdown is the built-in Markdown template
+const DefaultMarkdown = `# SITREP: {{.Name}}, {{.Date}}
+
+As of {{when .AsOf}}. Overall readiness: **{{upper .Readiness.Status}}** ({{.Readiness.Green}} green, {{.Readiness.Yellow}} yellow, {{.Readiness.Red}} red).
+
+## Divisions and teams
+{{range .Divisions}}
+### {{.Name}}: {{upper .Readiness.Status}}{{if .POC}} (lead: {{.POC}}){{end}}
+
+| Team | Status | Since | POC | Comments |
+| --- | --- | --- | --- | --- |
+{{range .Teams}}| {{cell .Name}} | {{upper .Status}} | {{when .StatusSince}} | {{cell .POC}} | {{cell .Comments}} |
+{{end}}{{end}}
+## Status changes in the last 24 hours
+
+{{range .StatusChanges}}- {{when .ChangedAt}} {{.DivisionName}} / {{.TeamName}}: {{upper .PreviousStatus}} to {{upper .Status}}
+{{else}}None.
+{{end}}
+## Events today ({{.Date}})
+
+{{range .EventsToday}}- {{template "event" .}}
+{{else}}None.
+{{end}}
+## Events tomorrow ({{.Tomorrow}})
+
+{{range .EventsTomorrow}}- {{template "event" .}}
+{{else}}None.
+{{end}}
+## Overdue tasks
+
+{{range .OverdueTasks}}- {{template "task" .}}
+{{else}}None.
+{{end}}
+## Tasks due soon
+
+{{range .DueSoonTasks}}- {{template "task" .}}
+{{else}}None.
+{{end}}
+{{- define "event"}}{{.Name}} ({{.Type}}, {{.Status}}): {{when .StartDate}} to {{when .EndDate}}{{if .Location}}, {{.Location}}{{end}}{{if .POC}}, POC {{.POC}}{{end}}{{end}}
+{{- define "task"}}{{.Name}}, due {{when .DueDate}} ({{.Status}}){{if .Teams}}, {{teams .Teams}}{{end}}{{if .AssignedTo}}, assigned to {{.AssignedTo}}{{end}}{{end}}
+`
+
+// DefaultHTML is the built-in HTML template
+const DefaultHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>SITREP: {{.Name}}, {{.Date}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.green { background: #d4edda; } .yellow { background: #fff3cd; } .red { background: #f8d7da; }
+</style>
+</head>
+<body>
+<h1>SITREP: {{.Name}}, {{.Date}}</h1>
+<p>As of {{when .AsOf}}. Overall readiness: <strong class="{{.Readiness.Status}}">{{upper .Readiness.Status}}</strong>
+({{.Readiness.Green}} green, {{.Readiness.Yellow}} yellow, {{.Readiness.Red}} red).</p>
+
+<h2>Divisions and teams</h2>
+{{range .Divisions}}
+<h3>{{.Name}}: <span class="{{.Readiness.Status}}">{{upper .Readiness.Status}}</span>{{if .POC}} (lead: {{.POC}}){{end}}</h3>
+<table>
+<tr><th>Team</th><th>Status</th><th>Since</th><th>POC</th><th>Comments</th></tr>
+{{range .Teams}}<tr><td>{{.Name}}</td><td class="{{.Status}}">{{upper .Status}}</td><td>{{when .StatusSince}}</td><td>{{.POC}}</td><td>{{.Comments}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Status changes in the last 24 hours</h2>
+{{if .StatusChanges}}<ul>
+{{range .StatusChanges}}<li>{{when .ChangedAt}} {{.DivisionName}} / {{.TeamName}}: {{upper .PreviousStatus}} to {{upper .Status}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}
+
+<h2>Events today ({{.Date}})</h2>
+{{template "events" .EventsToday}}
+
+<h2>Events tomorrow ({{.Tomorrow}})</h2>
+{{template "events" .EventsTomorrow}}
+
+<h2>Overdue tasks</h2>
+{{template "tasks" .OverdueTasks}}
+
+<h2>Tasks due soon</h2>
+{{template "tasks" .DueSoonTasks}}
+</body>
+</html>
+{{- define "events"}}{{if .}}<ul>
+{{range .}}<li>{{.Name}} ({{.Type}}, {{.Status}}): {{when .StartDate}} to {{when .EndDate}}{{if .Location}}, {{.Location}}{{end}}{{if .POC}}, POC {{.POC}}{{end}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}{{end}}
+{{- define "tasks"}}{{if .}}<ul>
+{{range .}}<li>{{.Name}}, due {{when .DueDate}} ({{.Status}}){{if .Teams}}, {{teams .Teams}}{{end}}{{if .AssignedTo}}, assigned to {{.AssignedTo}}{{end}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}{{end}}
+`
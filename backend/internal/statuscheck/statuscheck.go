@@ -0,0 +1,43 @@
+// Package statuscheck keeps team statuses current. On every run it ends
+// status windows that have passed, reverting each team to the configured
+// status, and flags teams of running exercises whose status has not been set
+// or confirmed within the configured interval.
+package statuscheck
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"srd-calendar-project/backend/internal/config"
+	"srd-calendar-project/backend/internal/repository"
+	"time"
+)
+
+var settings = config.Default().Status
+
+// Configure sets the revert status and stale interval. Call it once at
+// startup.
+func Configure(cfg config.StatusConfig) {
+	settings = cfg
+}
+
+// StaleAfter returns how long a status may go unconfirmed before it is stale
+func StaleAfter() time.Duration {
+	return time.Duration(settings.StaleAfter)
+}
+
+// Run expires passed status windows and flags stale statuses
+func Run(ctx context.Context) error {
+	expired, ok := repository.ExpireTeamStatuses(ctx, settings.RevertTo)
+	if !ok {
+		return errors.New("expiring team statuses failed")
+	}
+	flagged, ok := repository.FlagStaleTeams(ctx, StaleAfter())
+	if !ok {
+		return errors.New("flagging stale team statuses failed")
+	}
+	if expired > 0 || flagged > 0 {
+		slog.InfoContext(ctx, "Status check", "expired", expired, "flagged_stale", flagged)
+	}
+	return nil
+}
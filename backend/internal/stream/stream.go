@@ -0,0 +1,268 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/metrics"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// channel is the Postgres NOTIFY channel carrying new change_log IDs
+	channel = "change_log"
+	// retention is how long changes are kept for Last-Event-ID resumes
+	retention = 7 * 24 * time.Hour
+	// subscriberBuffer is the number of changes queued for a slow client
+	// before it is disconnected and left to resume from the change log
+	subscriberBuffer = 256
+)
+
+// Subscriber receives changes from the hub until it is unsubscribed or its
+// channel is closed because it fell behind
+type Subscriber struct {
+	C chan changes.Change
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = map[*Subscriber]struct{}{}
+	lastID      int64
+	closed      bool
+)
+
+// Record is a changes.Sink that appends the change to the change log and
+// notifies every API instance of its ID. The notification is only delivered
+// once the surrounding transaction commits.
+func Record(ctx context.Context, q changes.Execer, change changes.Change) error {
+	data, err := json.Marshal(change.Data)
+	if err != nil {
+		return err
+	}
+
+	var exerciseID interface{}
+	if change.ExerciseID != 0 {
+		exerciseID = change.ExerciseID
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO change_log (type, exercise_id, payload)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		)
+		SELECT pg_notify('` + channel + `', id::text) FROM inserted
+	`
+
+	_, err = q.ExecContext(ctx, query, change.Type, exerciseID, string(data))
+	return err
+}
+
+// Start listens for change notifications from every API instance and fans
+// them out to local subscribers until ctx is cancelled
+func Start(ctx context.Context, wg *sync.WaitGroup) {
+	listener := pq.NewListener(database.ConnInfo(), time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				slog.InfoContext(ctx, "Change stream listener error", "error", err)
+			}
+		})
+	if err := listener.Listen(channel); err != nil {
+		slog.ErrorContext(ctx, "Error listening for change notifications", "error", err)
+		return
+	}
+
+	if err := database.DB.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM change_log").Scan(&lastID); err != nil {
+		slog.ErrorContext(ctx, "Error reading change log position", "error", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer listener.Close()
+		pruneTicker := time.NewTicker(time.Hour)
+		defer pruneTicker.Stop()
+		pingTicker := time.NewTicker(90 * time.Second)
+		defer pingTicker.Stop()
+
+		prune(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				if n == nil {
+					// The connection was re-established; notifications sent
+					// while it was down are recovered from the change log
+					catchUp(ctx)
+					continue
+				}
+				var id int64
+				if err := json.Unmarshal([]byte(n.Extra), &id); err != nil {
+					slog.ErrorContext(ctx, "Invalid change notification", "payload", n.Extra, "error", err)
+					continue
+				}
+				if change, ok := getChange(ctx, id); ok {
+					publish(change)
+				}
+			case <-pingTicker.C:
+				go listener.Ping()
+			case <-pruneTicker.C:
+				prune(ctx)
+			}
+		}
+	}()
+	slog.InfoContext(ctx, "Change stream listener started")
+}
+
+// Subscribe registers a new subscriber with the hub. Once the hub is closed
+// the subscriber's channel is closed straight away.
+func Subscribe() *Subscriber {
+	sub := &Subscriber{C: make(chan changes.Change, subscriberBuffer)}
+	mu.Lock()
+	if closed {
+		close(sub.C)
+	} else {
+		subscribers[sub] = struct{}{}
+	}
+	mu.Unlock()
+	return sub
+}
+
+// Close disconnects every subscriber so open streams end and the server can
+// shut down; clients reconnect and resume from the change log elsewhere
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	closed = true
+	for sub := range subscribers {
+		delete(subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// Unsubscribe removes a subscriber from the hub
+func Unsubscribe(sub *Subscriber) {
+	mu.Lock()
+	if _, ok := subscribers[sub]; ok {
+		delete(subscribers, sub)
+		close(sub.C)
+	}
+	mu.Unlock()
+}
+
+// publish sends a change to every subscriber, dropping any that are too far
+// behind to keep up
+func publish(change changes.Change) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if change.ID > lastID {
+		lastID = change.ID
+	}
+	for sub := range subscribers {
+		select {
+		case sub.C <- change:
+		default:
+			delete(subscribers, sub)
+			close(sub.C)
+		}
+	}
+}
+
+// catchUp publishes changes recorded after the last one seen
+func catchUp(ctx context.Context) {
+	mu.Lock()
+	since := lastID
+	mu.Unlock()
+
+	for _, change := range GetChangesSince(ctx, since, 0, 1000) {
+		publish(change)
+	}
+}
+
+// GetChangesSince returns up to limit changes recorded after the given ID,
+// optionally restricted to one exercise
+func GetChangesSince(ctx context.Context, since int64, exerciseID int, limit int) []changes.Change {
+	query := `
+		SELECT id, type, COALESCE(exercise_id, 0), payload, created_at
+		FROM change_log
+		WHERE id > $1 AND ($2 = 0 OR exercise_id = $2)
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, since, exerciseID, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching change log", "error", err)
+		return []changes.Change{}
+	}
+	defer rows.Close()
+
+	result := []changes.Change{}
+	for rows.Next() {
+		change, err := scanChange(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning change", "error", err)
+			continue
+		}
+		result = append(result, change)
+	}
+
+	return result
+}
+
+// getChange loads a single change from the change log
+func getChange(ctx context.Context, id int64) (changes.Change, bool) {
+	query := `
+		SELECT id, type, COALESCE(exercise_id, 0), payload, created_at
+		FROM change_log
+		WHERE id = $1
+	`
+
+	change, err := scanChange(database.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching change", "change_id", id, "error", err)
+		}
+		return change, false
+	}
+
+	return change, true
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanChange(row rowScanner) (changes.Change, error) {
+	var change changes.Change
+	var payload []byte
+
+	err := row.Scan(&change.ID, &change.Type, &change.ExerciseID, &payload, &change.OccurredAt)
+	if err != nil {
+		return change, err
+	}
+
+	change.Data = json.RawMessage(payload)
+	return change, nil
+}
+
+// prune removes changes older than the retention period
+func prune(ctx context.Context) {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM change_log WHERE created_at < CURRENT_TIMESTAMP - ($1 * INTERVAL '1 second')",
+		int(retention.Seconds()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error pruning change log", "error", err)
+		metrics.Jobs.Inc("change_log_prune", "error")
+		return
+	}
+	metrics.Jobs.Inc("change_log_prune", "success")
+}
@@ -0,0 +1,215 @@
+// Package validation checks request payloads against rules declared in
+// `validate` struct tags and reports every invalid field at once.
+//
+// Rules are comma-separated:
+//
+//	required       the field must be set: non-blank text, a non-zero ID or
+//	               time, or a non-empty list
+//	max=N          text may be at most N characters
+//	min=N          a number may not be less than N
+//	oneof=a b c    text, when set, must be one of the listed values
+//	notbefore=F    a time, when set, must not be before time field F
+//	exists=kind    an ID, when set, must name an existing exercise, division,
+//	               team, event, task or user
+//
+// On a list, every rule except required applies to each element.
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"srd-calendar-project/backend/internal/database"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error codes
+const (
+	CodeRequired  = "required"
+	CodeTooLong   = "too_long"
+	CodeOneOf     = "invalid_choice"
+	CodeDateOrder = "date_order"
+	CodeNotFound  = "not_found"
+	CodeInvalid   = "invalid"
+)
+
+// FieldError describes one invalid field, named as in the JSON payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is every problem found in a payload
+type Errors []FieldError
+
+// Add records a problem with a field
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Has reports whether a field already has an error, so that a later check
+// on the same field can be skipped
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Write responds 422 with the field errors
+func Write(w http.ResponseWriter, errs Errors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "validation_failed",
+		"message": "The request has invalid fields",
+		"fields":  errs,
+	})
+}
+
+// tables names the table checked by each exists=kind rule
+var tables = map[string]string{
+	"exercise": "exercises",
+	"division": "divisions",
+	"team":     "teams",
+	"event":    "events",
+	"task":     "tasks",
+	"user":     "users",
+}
+
+// Struct checks the tagged fields of v, a struct or pointer to one, and
+// returns the problems found. Fields of embedded structs are checked too.
+func Struct(ctx context.Context, v interface{}) Errors {
+	errs := Errors{}
+	value := reflect.Indirect(reflect.ValueOf(v))
+	checkStruct(ctx, value, &errs)
+	return errs
+}
+
+func checkStruct(ctx context.Context, value reflect.Value, errs *Errors) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			checkStruct(ctx, value.Field(i), errs)
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := JSONName(field)
+		for _, rule := range strings.Split(tag, ",") {
+			rule, arg, _ := strings.Cut(rule, "=")
+			if errs.Has(name) {
+				break
+			}
+			checkRule(ctx, value, value.Field(i), name, rule, arg, errs)
+		}
+	}
+}
+
+func checkRule(ctx context.Context, parent, fv reflect.Value, name, rule, arg string, errs *Errors) {
+	if rule == "required" {
+		if isZero(fv) {
+			errs.Add(name, CodeRequired, "is required")
+		}
+		return
+	}
+
+	// Other rules apply to each element of a list, and only to values that are set
+	if fv.Kind() == reflect.Slice {
+		for j := 0; j < fv.Len(); j++ {
+			checkRule(ctx, parent, fv.Index(j), name+"["+strconv.Itoa(j)+"]", rule, arg, errs)
+		}
+		return
+	}
+	if isZero(fv) {
+		return
+	}
+	fv = reflect.Indirect(fv)
+
+	switch rule {
+	case "max":
+		limit, _ := strconv.Atoi(arg)
+		if utf8.RuneCountInString(fv.String()) > limit {
+			errs.Add(name, CodeTooLong, fmt.Sprintf("must be at most %d characters", limit))
+		}
+	case "min":
+		limit, _ := strconv.ParseInt(arg, 10, 64)
+		if fv.Int() < limit {
+			errs.Add(name, CodeInvalid, fmt.Sprintf("must be at least %d", limit))
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		for _, a := range allowed {
+			if fv.String() == a {
+				return
+			}
+		}
+		errs.Add(name, CodeOneOf, "must be one of "+strings.Join(allowed, ", "))
+	case "notbefore":
+		other, ok := parent.Type().FieldByName(arg)
+		if !ok {
+			panic("validation: notbefore names unknown field " + arg)
+		}
+		start, isTime := reflect.Indirect(parent.FieldByIndex(other.Index)).Interface().(time.Time)
+		end, _ := fv.Interface().(time.Time)
+		if isTime && !start.IsZero() && end.Before(start) {
+			errs.Add(name, CodeDateOrder, "must not be before "+JSONName(other))
+		}
+	case "exists":
+		table, ok := tables[arg]
+		if !ok {
+			panic("validation: exists names unknown kind " + arg)
+		}
+		var exists bool
+		err := database.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = $1)", fv.Int()).Scan(&exists)
+		if err == nil && !exists {
+			errs.Add(name, CodeNotFound, arg+" does not exist")
+		}
+	default:
+		panic("validation: unknown rule " + rule)
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr:
+		return v.IsNil()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	return v.IsZero()
+}
+
+// JSONName returns the name a struct field has in JSON
+func JSONName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
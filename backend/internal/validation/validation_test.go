@@ -0,0 +1,153 @@
+package validation
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"srd-calendar-project/backend/internal/database"
+	"strings"
+	"testing"
+	"time"
+)
+
+// existsDriver answers the exists rule's query from a fixed set of IDs
+type existsDriver struct{ ids map[int64]bool }
+
+func (d existsDriver) Open(string) (driver.Conn, error) { return existsConn(d), nil }
+
+type existsConn existsDriver
+
+func (existsConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (existsConn) Close() error                        { return nil }
+func (existsConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c existsConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "FROM teams") {
+		return nil, errors.New("unexpected query: " + query)
+	}
+	return &existsRows{exists: c.ids[args[0].Value.(int64)]}, nil
+}
+
+type existsRows struct {
+	exists bool
+	done   bool
+}
+
+func (r *existsRows) Columns() []string { return []string{"exists"} }
+func (r *existsRows) Close() error      { return nil }
+
+func (r *existsRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.exists
+	return nil
+}
+
+func init() {
+	sql.Register("validation-exists", existsDriver{ids: map[int64]bool{7: true}})
+}
+
+// Window is embedded in payload to check that embedded fields are validated
+type Window struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end" validate:"notbefore=Start"`
+}
+
+type payload struct {
+	Window
+	Name     string   `json:"name" validate:"required,max=5"`
+	Tags     []string `json:"tags" validate:"max=3"`
+	Weight   int      `json:"weight" validate:"min=0"`
+	Priority string   `json:"priority,omitempty" validate:"oneof=high low"`
+	Owners   []string `validate:"required"`
+	TeamID   int      `json:"team_id" validate:"exists=team"`
+}
+
+func TestStruct(t *testing.T) {
+	db, err := sql.Open("validation-exists", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := database.DB
+	database.DB = db
+	defer func() { database.DB = previous }()
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	before, after := day(1), day(3)
+	valid := func() payload {
+		return payload{Name: "Alpha", Owners: []string{"ops"}, Tags: []string{"a"}, Priority: "high", TeamID: 7,
+			Window: Window{Start: day(2), End: &after}}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*payload)
+		want   []FieldError
+	}{
+		{"valid", func(p *payload) {}, nil},
+		{"unset optional fields", func(p *payload) {
+			p.Tags, p.Priority, p.TeamID, p.Window = nil, "", 0, Window{}
+		}, nil},
+		{"required text", func(p *payload) { p.Name = "  " }, []FieldError{
+			{"name", CodeRequired, "is required"},
+		}},
+		{"required list, named without a json tag", func(p *payload) { p.Owners = []string{} }, []FieldError{
+			{"Owners", CodeRequired, "is required"},
+		}},
+		{"max counts characters", func(p *payload) { p.Name = "Ålpha" }, nil},
+		{"max", func(p *payload) { p.Name = "Alphas" }, []FieldError{
+			{"name", CodeTooLong, "must be at most 5 characters"},
+		}},
+		{"max on each list element", func(p *payload) { p.Tags = []string{"abc", "abcd", "ab", "abcde"} }, []FieldError{
+			{"tags[1]", CodeTooLong, "must be at most 3 characters"},
+			{"tags[3]", CodeTooLong, "must be at most 3 characters"},
+		}},
+		{"min", func(p *payload) { p.Weight = -1 }, []FieldError{
+			{"weight", CodeInvalid, "must be at least 0"},
+		}},
+		{"oneof", func(p *payload) { p.Priority = "urgent" }, []FieldError{
+			{"priority", CodeOneOf, "must be one of high, low"},
+		}},
+		{"notbefore, in an embedded struct", func(p *payload) { p.End = &before }, []FieldError{
+			{"end", CodeDateOrder, "must not be before start"},
+		}},
+		{"notbefore without a start", func(p *payload) { p.Start = time.Time{}; p.End = &before }, nil},
+		{"exists", func(p *payload) { p.TeamID = 8 }, []FieldError{
+			{"team_id", CodeNotFound, "team does not exist"},
+		}},
+		{"every field reported once", func(p *payload) { p.Name = ""; p.Weight = -2; p.Priority = "x" }, []FieldError{
+			{"name", CodeRequired, "is required"},
+			{"weight", CodeInvalid, "must be at least 0"},
+			{"priority", CodeOneOf, "must be one of high, low"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.change(&p)
+			got := Struct(context.Background(), &p)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual([]FieldError(got), tt.want) {
+				t.Errorf("Struct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorsHas(t *testing.T) {
+	var errs Errors
+	errs.Add("name", CodeRequired, "is required")
+	if !errs.Has("name") || errs.Has("tags") {
+		t.Errorf("Has() on %v is wrong", errs)
+	}
+	if got, want := errs.Error(), "name: is required"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"srd-calendar-project/backend/internal/changes"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+)
+
+// Enqueue is a changes.Sink that queues one delivery for every active
+// subscription whose event filter matches the change
+func Enqueue(ctx context.Context, q changes.Execer, change changes.Change) error {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload, status, next_attempt_at)
+		SELECT id, $1, $2, 'pending', CURRENT_TIMESTAMP
+		FROM webhook_subscriptions
+		WHERE active AND ($1 = ANY(events) OR $3 = ANY(events) OR '*' = ANY(events))
+	`
+
+	_, err = q.ExecContext(ctx, query, change.Type, string(payload), changes.Category(change.Type))
+	return err
+}
+
+// GetDeliveries returns the most recent deliveries for a subscription. A
+// non-zero beforeID returns only deliveries older than that one, for paging.
+func GetDeliveries(ctx context.Context, subscriptionID int, status string, beforeID, limit int) []models.WebhookDelivery {
+	query := `
+		SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_attempt_at, response_status, COALESCE(last_error, ''), delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2) AND ($4 = 0 OR id < $4)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, subscriptionID, status, limit, beforeID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching webhook deliveries", "error", err)
+		return []models.WebhookDelivery{}
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning webhook delivery", "error", err)
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries
+}
+
+// GetDelivery returns a single delivery together with its attempt log
+func GetDelivery(ctx context.Context, id int) (models.WebhookDelivery, bool) {
+	query := `
+		SELECT id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_attempt_at, response_status, COALESCE(last_error, ''), delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE id = $1
+	`
+
+	delivery, err := scanDelivery(database.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching webhook delivery", "delivery_id", id, "error", err)
+		}
+		return delivery, false
+	}
+
+	delivery.AttemptLog = getAttempts(ctx, id)
+	return delivery, true
+}
+
+// Redeliver puts a delivery back on the queue for immediate delivery,
+// regardless of whether it previously succeeded or failed
+func Redeliver(ctx context.Context, id int) bool {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, delivered_at = NULL
+		WHERE id = $1
+	`
+
+	result, err := database.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error scheduling redelivery of webhook delivery", "delivery_id", id, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// getAttempts returns the attempt log for a delivery, oldest first
+func getAttempts(ctx context.Context, deliveryID int) []models.WebhookDeliveryAttempt {
+	query := `
+		SELECT id, delivery_id, attempted_at, response_status, COALESCE(error, ''), COALESCE(duration_ms, 0)
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempted_at, id
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching webhook delivery attempts", "error", err)
+		return []models.WebhookDeliveryAttempt{}
+	}
+	defer rows.Close()
+
+	attempts := []models.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var attempt models.WebhookDeliveryAttempt
+		var responseStatus sql.NullInt64
+		err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.AttemptedAt, &responseStatus,
+			&attempt.Error, &attempt.DurationMS)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning webhook delivery attempt", "error", err)
+			continue
+		}
+		if responseStatus.Valid {
+			status := int(responseStatus.Int64)
+			attempt.ResponseStatus = &status
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDelivery(row rowScanner) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	var nextAttemptAt, lastAttemptAt, deliveredAt sql.NullTime
+	var responseStatus sql.NullInt64
+
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &nextAttemptAt, &lastAttemptAt, &responseStatus,
+		&delivery.LastError, &deliveredAt, &delivery.CreatedAt)
+	if err != nil {
+		return delivery, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+
+	return delivery, nil
+}
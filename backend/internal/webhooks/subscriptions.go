@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"log/slog"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetSubscriptions returns all webhook subscriptions without their secrets
+func GetSubscriptions(ctx context.Context) []models.WebhookSubscription {
+	query := `
+		SELECT id, url, events, COALESCE(description, ''), active, created_at, updated_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching webhook subscriptions", "error", err)
+		return []models.WebhookSubscription{}
+	}
+	defer rows.Close()
+
+	subscriptions := []models.WebhookSubscription{}
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		err := rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.Description,
+			&sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning webhook subscription", "error", err)
+			continue
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions
+}
+
+// GetSubscription returns a single webhook subscription without its secret
+func GetSubscription(ctx context.Context, id int) (models.WebhookSubscription, bool) {
+	query := `
+		SELECT id, url, events, COALESCE(description, ''), active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	var sub models.WebhookSubscription
+	err := database.DB.QueryRowContext(ctx, query, id).Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events),
+		&sub.Description, &sub.Active, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "Error fetching webhook subscription", "subscription_id", id, "error", err)
+		}
+		return sub, false
+	}
+
+	return sub, true
+}
+
+// CreateSubscription stores a new subscription, generating a signing secret
+// when none is supplied. The returned subscription includes the secret.
+func CreateSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if sub.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return sub, err
+		}
+		sub.Secret = secret
+	}
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, events, description, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := database.DB.QueryRowContext(ctx, query, sub.URL, sub.Secret, pq.Array(sub.Events), sub.Description, sub.Active).
+		Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating webhook subscription", "error", err)
+		return sub, err
+	}
+
+	return sub, nil
+}
+
+// UpdateSubscription updates a subscription's URL, filters and state. The
+// secret is only replaced when a new one is supplied.
+func UpdateSubscription(ctx context.Context, sub models.WebhookSubscription) bool {
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $2, events = $3, description = $4, active = $5,
+		    secret = COALESCE(NULLIF($6, ''), secret), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	result, err := database.DB.ExecContext(ctx, query, sub.ID, sub.URL, pq.Array(sub.Events), sub.Description, sub.Active, sub.Secret)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating webhook subscription", "subscription_id", sub.ID, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// DeleteSubscription removes a subscription and its delivery log
+func DeleteSubscription(ctx context.Context, id int) bool {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting webhook subscription", "subscription_id", id, "error", err)
+		return false
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0
+}
+
+// generateSecret returns a random signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,244 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"srd-calendar-project/backend/internal/database"
+	"srd-calendar-project/backend/internal/metrics"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is how often the worker looks for due deliveries
+	pollInterval = 5 * time.Second
+	// batchSize is the maximum number of deliveries claimed per poll
+	batchSize = 20
+	// leaseDuration keeps a claimed delivery from being picked up by another
+	// instance while it is in flight
+	leaseDuration = 2 * time.Minute
+	// maxAttempts is the number of attempts before a delivery is marked failed
+	maxAttempts = 10
+	// baseBackoff and maxBackoff bound the exponential retry delay
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+type pendingDelivery struct {
+	id        int
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+// StartWorker starts the background delivery loop. When ctx is cancelled the
+// delivery in flight is finished and recorded before the worker stops.
+func StartWorker(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				processDue(ctx)
+			}
+		}
+	}()
+	slog.InfoContext(ctx, "Webhook delivery worker started")
+}
+
+// processDue claims and delivers every delivery that is currently due
+func processDue(ctx context.Context) {
+	for {
+		batch, err := claimDue(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Error claiming webhook deliveries", "error", err)
+				metrics.Jobs.Inc("webhook_claim", "error")
+			}
+			return
+		}
+
+		// Deliveries left unsent at shutdown keep their lease and are
+		// picked up again once it runs out
+		for _, d := range batch {
+			if ctx.Err() != nil {
+				return
+			}
+			deliver(context.WithoutCancel(ctx), d)
+		}
+
+		if len(batch) < batchSize {
+			return
+		}
+	}
+}
+
+// claimQuery leases up to $2 due deliveries of active subscriptions for $1
+// seconds. Deliveries of a paused subscription stay pending and are sent
+// once it is active again.
+const claimQuery = `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + ($1 * INTERVAL '1 second')
+		FROM webhook_subscriptions s
+		WHERE d.subscription_id = s.id AND s.active
+		  AND d.id IN (
+			SELECT wd.id FROM webhook_deliveries wd
+			JOIN webhook_subscriptions ws ON ws.id = wd.subscription_id AND ws.active
+			WHERE wd.status = 'pending' AND wd.next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY wd.next_attempt_at, wd.id
+			LIMIT $2
+			FOR UPDATE OF wd SKIP LOCKED
+		  )
+		RETURNING d.id, d.event_type, d.payload, d.attempts, s.url, s.secret
+	`
+
+// claimDue leases a batch of due deliveries. SKIP LOCKED lets several API
+// instances share the queue without delivering the same row twice.
+func claimDue(ctx context.Context) ([]pendingDelivery, error) {
+	rows, err := database.DB.QueryContext(ctx, claimQuery, int(leaseDuration.Seconds()), batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []pendingDelivery
+	for rows.Next() {
+		var d pendingDelivery
+		if err := rows.Scan(&d.id, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			slog.ErrorContext(ctx, "Error scanning webhook delivery", "error", err)
+			continue
+		}
+		batch = append(batch, d)
+	}
+
+	return batch, rows.Err()
+}
+
+// deliver sends a single delivery and records the outcome
+func deliver(ctx context.Context, d pendingDelivery) {
+	started := time.Now()
+	statusCode, err := send(ctx, d)
+	duration := time.Since(started)
+
+	var responseStatus interface{}
+	if statusCode != 0 {
+		responseStatus = statusCode
+	}
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	_, logErr := database.DB.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, response_status, error, duration_ms)
+		VALUES ($1, $2, $3, $4)`,
+		d.id, responseStatus, errMsg, duration.Milliseconds())
+	if logErr != nil {
+		slog.ErrorContext(ctx, "Error recording attempt for webhook delivery", "delivery_id", d.id, "error", logErr)
+	}
+
+	attempts := d.attempts + 1
+	var updateErr error
+	outcome := "retry"
+	if err == nil {
+		outcome = "delivered"
+		_, updateErr = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'delivered', attempts = $2, last_attempt_at = CURRENT_TIMESTAMP,
+			    response_status = $3, last_error = NULL, delivered_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			d.id, attempts, responseStatus)
+	} else if attempts >= maxAttempts {
+		outcome = "failed"
+		slog.ErrorContext(ctx, "Webhook delivery failed permanently", "delivery_id", d.id, "attempts", attempts, "error", err)
+		_, updateErr = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'failed', attempts = $2, last_attempt_at = CURRENT_TIMESTAMP,
+			    response_status = $3, last_error = $4
+			WHERE id = $1`,
+			d.id, attempts, responseStatus, errMsg)
+	} else {
+		_, updateErr = database.DB.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET attempts = $2, last_attempt_at = CURRENT_TIMESTAMP, response_status = $3, last_error = $4,
+			    next_attempt_at = CURRENT_TIMESTAMP + ($5 * INTERVAL '1 millisecond')
+			WHERE id = $1`,
+			d.id, attempts, responseStatus, errMsg, backoff(attempts).Milliseconds())
+	}
+	if updateErr != nil {
+		slog.ErrorContext(ctx, "Error updating webhook delivery", "delivery_id", d.id, "error", updateErr)
+	}
+	metrics.Jobs.Inc("webhook_delivery", outcome)
+}
+
+// send POSTs the payload to the subscriber. Any non-2xx response is an error.
+func send(ctx context.Context, d pendingDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AOC-Event-Tracker-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.eventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.id))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(d.secret, timestamp, d.payload))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the hex HMAC-SHA256 of "<timestamp>.<body>" with the
+// subscription secret. Receivers recompute it to verify the X-Webhook-Signature
+// header and reject stale timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the delay before the next attempt, doubling with each
+// attempt up to maxBackoff and jittered by up to 20%
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay + jitter
+}
@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Receivers depend on this exact value: hex HMAC-SHA256 of "<timestamp>.<body>"
+	got := Sign("topsecret", "1767225600", []byte(`{"type":"team.status_changed"}`))
+	want := "7bb35be83f8fe53845d5e75f557e5f77584c1bdbeab7b640f62dee504be7a6cb"
+	if got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+	if Sign("topsecret", "1767225601", []byte(`{"type":"team.status_changed"}`)) == want {
+		t.Error("Sign() ignores the timestamp")
+	}
+	if Sign("othersecret", "1767225600", []byte(`{"type":"team.status_changed"}`)) == want {
+		t.Error("Sign() ignores the secret")
+	}
+}
+
+func TestSendHeaders(t *testing.T) {
+	var got http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	d := pendingDelivery{id: 42, eventType: "team.status_changed", payload: []byte(`{"id":7}`), url: server.URL, secret: "topsecret"}
+	if status, err := send(context.Background(), d); err != nil || status != http.StatusOK {
+		t.Fatalf("send() = %d, %v", status, err)
+	}
+
+	timestamp := got.Get("X-Webhook-Timestamp")
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Errorf("X-Webhook-Timestamp = %q, want the current Unix time", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("topsecret"))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got.Get("X-Webhook-Signature") != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got.Get("X-Webhook-Signature"), want)
+	}
+	if got.Get("X-Webhook-Event") != "team.status_changed" || got.Get("X-Webhook-Delivery") != "42" {
+		t.Errorf("X-Webhook-Event = %q, X-Webhook-Delivery = %q", got.Get("X-Webhook-Event"), got.Get("X-Webhook-Delivery"))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		// Jitter adds up to 20% on top of the delay, never takes any off
+		max := tt.min + tt.min/5
+		for i := 0; i < 200; i++ {
+			if got := backoff(tt.attempts); got < tt.min || got >= max {
+				t.Fatalf("backoff(%d) = %s, want in [%s, %s)", tt.attempts, got, tt.min, max)
+			}
+		}
+	}
+}
+
+func TestClaimSkipsInactiveSubscriptions(t *testing.T) {
+	// Without a database this pins the claim query: both the rows leased and
+	// the rows returned must belong to active subscriptions, or a paused
+	// subscription keeps receiving its queued deliveries
+	query := strings.Join(strings.Fields(claimQuery), " ")
+	for _, want := range []string{
+		"WHERE d.subscription_id = s.id AND s.active",
+		"JOIN webhook_subscriptions ws ON ws.id = wd.subscription_id AND ws.active",
+		"FOR UPDATE OF wd SKIP LOCKED",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("claim query is missing %q:\n%s", want, claimQuery)
+		}
+	}
+}
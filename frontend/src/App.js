@@ -3,16 +3,58 @@ import './App.css';
 import ExerciseModal from './components/ExerciseModal';
 import GanttChart from './components/GanttChart'; // Import the new component
 import Chatbot from './components/Chatbot';
+import Login from './components/Login';
+import { makeCan } from './permissions';
 
 function App() {
+  const [user, setUser] = useState(null);
+  const [authChecked, setAuthChecked] = useState(false);
+  const [grants, setGrants] = useState([]);
   const [exercises, setExercises] = useState([]);
   const [selectedExercise, setSelectedExercise] = useState(null);
   const [showModal, setShowModal] = useState(false);
   const [filteredView, setFilteredView] = useState(null); // { type: 'division', id: 123, name: 'Division Name' } or { type: 'team', id: 456, name: 'Team Name' }
 
   useEffect(() => {
+    fetch('/api/auth/me')
+      .then(response => (response.ok ? response.json() : null))
+      .then(data => setUser(data))
+      .catch(() => setUser(null))
+      .finally(() => setAuthChecked(true));
+  }, []);
+
+  useEffect(() => {
+    if (!user) return;
+    fetch('/api/me/permissions')
+      .then(response => (response.ok ? response.json() : []))
+      .then(data => setGrants(Array.isArray(data) ? data : []))
+      .catch(() => setGrants([]));
+  }, [user]);
+
+  useEffect(() => {
+    if (!user) return;
     fetchExercises();
-  }, [filteredView]);
+  }, [filteredView, user]);
+
+  // Refresh whenever the backend reports a change, instead of waiting for the next manual reload
+  useEffect(() => {
+    if (!user) return;
+    const source = new EventSource('/api/stream');
+    const changeTypes = ['exercise', 'division', 'team', 'event', 'task'].flatMap(kind =>
+      ['created', 'updated', 'deleted'].map(action => `${kind}.${action}`)
+    );
+    changeTypes.forEach(type => source.addEventListener(type, () => fetchExercises()));
+    return () => source.close();
+  }, [filteredView, user]);
+
+  const handleLogout = () => {
+    fetch('/api/auth/logout', { method: 'POST' })
+      .finally(() => {
+        setUser(null);
+        setGrants([]);
+        setExercises([]);
+      });
+  };
 
   const handleExerciseClick = (exercise) => {
     setSelectedExercise(exercise);
@@ -64,10 +106,29 @@ function App() {
     setFilteredView(null);
   };
 
+  if (!authChecked) {
+    return null;
+  }
+
+  if (!user) {
+    return (
+      <div className="App">
+        <header className="App-header">
+          <h1>AOC Event Tracker</h1>
+        </header>
+        <Login onLogin={setUser} />
+      </div>
+    );
+  }
+
   return (
     <div className="App">
       <header className="App-header">
         <h1>AOC Event Tracker</h1>
+        <div className="App-user">
+          Signed in as {user.display_name || user.username}
+          <button className="btn btn-sm btn-outline-light ms-2" onClick={handleLogout}>Sign out</button>
+        </div>
       </header>
       <main className="container-fluid mt-4"> {/* Use container-fluid for more width */}
         <div className="mb-3">
@@ -92,6 +153,7 @@ function App() {
         exercise={selectedExercise}
         onDivisionClick={handleDivisionClick}
         onTeamClick={handleTeamClick}
+        can={makeCan(grants)}
       />
       <Chatbot />
     </div>
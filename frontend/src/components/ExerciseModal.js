@@ -15,7 +15,7 @@ const getDivisionColor = (teams) => {
   return 'green';
 };
 
-const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamClick }) => {
+const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamClick, can = () => true }) => {
   // Calculate exercise readiness percentage
   const calculateReadiness = () => {
     if (!divisions || divisions.length === 0) return null;
@@ -139,7 +139,7 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
       comments: editValues.comments
     };
 
-    fetch(`/api/team/update`, {
+    fetch(`/api/teams/${team.id}`, {
       method: 'PUT',
       headers: {
         'Content-Type': 'application/json',
@@ -150,12 +150,15 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
       if (!response.ok) {
         throw new Error('Network response was not ok');
       }
+      return response.json();
+    })
+    .then(savedTeam => {
       // Update local state
       const updatedDivisions = divisions.map(div => {
         if (div.id === divisionId) {
           const updatedTeams = div.teams.map(t => {
             if (t.id === teamId) {
-              return updatedTeam;
+              return savedTeam;
             }
             return t;
           });
@@ -809,9 +812,11 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
         <div className="mb-3">
           <div className="d-flex justify-content-between align-items-center mb-2">
             <h5>Tasks</h5>
-            <Button variant="outline-success" size="sm" onClick={() => setShowAddTask(true)}>
-              + Add Task
-            </Button>
+            {can('task:create', { exerciseId: exercise.id }) && (
+              <Button variant="outline-success" size="sm" onClick={() => setShowAddTask(true)}>
+                + Add Task
+              </Button>
+            )}
           </div>
           
           {/* Add Task Form */}
@@ -917,9 +922,11 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
                               <Button variant="outline-primary" size="sm" onClick={() => startEditingTask(task)}>
                                 Edit
                               </Button>
-                              <Button variant="outline-danger" size="sm" onClick={() => deleteTask(task.id)}>
-                                Delete
-                              </Button>
+                              {can('task:delete', { exerciseId: exercise.id }) && (
+                                <Button variant="outline-danger" size="sm" onClick={() => deleteTask(task.id)}>
+                                  Delete
+                                </Button>
+                              )}
                             </div>
                           </div>
                         </div>
@@ -1005,13 +1012,15 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
         
         <div className="d-flex justify-content-between align-items-center mb-3">
           <h5>Participating Divisions</h5>
-          <Button 
-            variant="success" 
-            size="sm"
-            onClick={() => setShowAddDivision(true)}
-          >
-            + Add Division
-          </Button>
+          {can('division:create', { exerciseId: exercise.id }) && (
+            <Button 
+              variant="success" 
+              size="sm"
+              onClick={() => setShowAddDivision(true)}
+            >
+              + Add Division
+            </Button>
+          )}
         </div>
         
         {/* Add Division Form */}
@@ -1050,17 +1059,19 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
                       <span className={`me-2 text-${statusColorMap[divisionColor]}`}>●</span>
                       <span>{division.name}</span>
                     </div>
-                    <Button
-                      variant="outline-danger"
-                      size="sm"
-                      onClick={(e) => {
-                        e.stopPropagation();
-                        deleteDivision(division.id);
-                      }}
-                      title="Delete division and all its teams"
-                    >
-                      Delete
-                    </Button>
+                    {can('division:delete', { exerciseId: exercise.id, divisionId: division.id }) && (
+                      <Button
+                        variant="outline-danger"
+                        size="sm"
+                        onClick={(e) => {
+                          e.stopPropagation();
+                          deleteDivision(division.id);
+                        }}
+                        title="Delete division and all its teams"
+                      >
+                        Delete
+                      </Button>
+                    )}
                   </div>
                 </Accordion.Header>
                 <Accordion.Body>
@@ -1114,13 +1125,15 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
                   {/* Teams Section */}
                   <div className="d-flex justify-content-between align-items-center mb-3">
                     <h6>Teams</h6>
-                    <Button 
-                      variant="outline-success" 
-                      size="sm"
-                      onClick={() => setShowAddTeam({ ...showAddTeam, [division.id]: true })}
-                    >
-                      + Add Team
-                    </Button>
+                    {can('team:create', { exerciseId: exercise.id, divisionId: division.id }) && (
+                      <Button 
+                        variant="outline-success" 
+                        size="sm"
+                        onClick={() => setShowAddTeam({ ...showAddTeam, [division.id]: true })}
+                      >
+                        + Add Team
+                      </Button>
+                    )}
                   </div>
                   
                   {/* Add Team Form */}
@@ -1177,14 +1190,16 @@ const ExerciseModal = ({ show, handleClose, exercise, onDivisionClick, onTeamCli
                               >
                                 Edit
                               </Button>
-                              <Button
-                                variant="outline-danger"
-                                size="sm"
-                                onClick={() => deleteTeam(team.id)}
-                                title="Delete team"
-                              >
-                                Delete
-                              </Button>
+                              {can('team:delete', { exerciseId: exercise.id, divisionId: division.id, teamId: team.id }) && (
+                                <Button
+                                  variant="outline-danger"
+                                  size="sm"
+                                  onClick={() => deleteTeam(team.id)}
+                                  title="Delete team"
+                                >
+                                  Delete
+                                </Button>
+                              )}
                             </div>
                           ) : (
                             <div>
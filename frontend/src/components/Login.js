@@ -0,0 +1,124 @@
+import React, { useState, useEffect } from 'react';
+import { Form, Button, Alert } from 'react-bootstrap';
+
+const ssoErrors = {
+  provider_error: 'The identity provider did not complete the sign-in.',
+  invalid_state: 'The sign-in attempt could not be verified. Please try again.',
+  expired: 'The sign-in attempt expired. Please try again.',
+  username_conflict: 'Your username already belongs to a local account. Ask an administrator for help.',
+  inactive: 'Your account has been deactivated.',
+  failed: 'Single sign-on failed. Please try again.',
+};
+
+const Login = ({ onLogin }) => {
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [error, setError] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+  const [sso, setSso] = useState(null);
+
+  useEffect(() => {
+    fetch('/api/auth/config')
+      .then(response => (response.ok ? response.json() : null))
+      .then(data => setSso(data && data.oidc && data.oidc.enabled ? data.oidc : null))
+      .catch(() => setSso(null));
+
+    // The backend reports single sign-on failures back through the URL
+    const params = new URLSearchParams(window.location.search);
+    const ssoError = params.get('sso_error');
+    if (ssoError) {
+      setError(ssoErrors[ssoError] || ssoErrors.failed);
+      window.history.replaceState(null, '', window.location.pathname);
+    }
+  }, []);
+
+  const handleSso = async () => {
+    setError('');
+    setSubmitting(true);
+    try {
+      const response = await fetch('/api/auth/oidc/login', { method: 'POST' });
+      if (!response.ok) {
+        setError('Single sign-on is unavailable right now.');
+        setSubmitting(false);
+        return;
+      }
+      const data = await response.json();
+      window.location.assign(data.authorization_url);
+    } catch (err) {
+      console.error('Error starting single sign-on:', err);
+      setError('Unable to reach the server.');
+      setSubmitting(false);
+    }
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    setSubmitting(true);
+
+    try {
+      const response = await fetch('/api/auth/login', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ username, password }),
+      });
+
+      if (!response.ok) {
+        setError(response.status === 401 ? 'Invalid username or password.' : 'Unable to sign in. Please try again.');
+        return;
+      }
+
+      const data = await response.json();
+      onLogin(data.user);
+    } catch (err) {
+      console.error('Error signing in:', err);
+      setError('Unable to reach the server.');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <div className="container mt-5" style={{ maxWidth: '400px' }}>
+      <h2 className="mb-4">Sign in</h2>
+      {error && <Alert variant="danger">{error}</Alert>}
+      <Form onSubmit={handleSubmit}>
+        <Form.Group className="mb-3" controlId="loginUsername">
+          <Form.Label>Username</Form.Label>
+          <Form.Control
+            type="text"
+            autoComplete="username"
+            value={username}
+            onChange={(e) => setUsername(e.target.value)}
+            required
+          />
+        </Form.Group>
+        <Form.Group className="mb-3" controlId="loginPassword">
+          <Form.Label>Password</Form.Label>
+          <Form.Control
+            type="password"
+            autoComplete="current-password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            required
+          />
+        </Form.Group>
+        <Button type="submit" variant="primary" disabled={submitting}>
+          {submitting ? 'Signing in...' : 'Sign in'}
+        </Button>
+      </Form>
+      {sso && (
+        <>
+          <hr />
+          <Button variant="outline-primary" className="w-100" onClick={handleSso} disabled={submitting}>
+            Sign in with {sso.name}
+          </Button>
+        </>
+      )}
+    </div>
+  );
+};
+
+export default Login;
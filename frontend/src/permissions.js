@@ -0,0 +1,20 @@
+// Mirrors the backend's scope rules so controls the signed-in user cannot use
+// can be hidden. The backend still enforces every check.
+
+const covers = (grant, scope) => {
+  if (grant.team_id != null) return grant.team_id === scope.teamId;
+  if (grant.division_id != null) return grant.division_id === scope.divisionId;
+  if (grant.exercise_id != null) return grant.exercise_id === scope.exerciseId;
+  return true;
+};
+
+// makeCan turns the response of GET /api/me/permissions into a
+// can(permission, { exerciseId, divisionId, teamId }) function
+export const makeCan = (grants) => (permission, scope = {}) =>
+  grants.some(grant => {
+    if (!grant.permissions.includes(permission)) return false;
+    if (permission === 'read') {
+      return grant.exercise_id == null || grant.exercise_id === scope.exerciseId;
+    }
+    return covers(grant, scope);
+  });